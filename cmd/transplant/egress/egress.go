@@ -9,6 +9,8 @@ package egress
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/codeactual/transplant/cmd/transplant/egress/graph"
+	"github.com/codeactual/transplant/cmd/transplant/egress/history"
 	"github.com/codeactual/transplant/cmd/transplant/egress/run"
 	"github.com/codeactual/transplant/cmd/transplant/egress/why"
 )
@@ -18,6 +20,8 @@ func NewCommand() *cobra.Command {
 		Use:   "export",
 		Short: "Commands for copying a project from the origin module to a standalone module",
 	}
+	cmd.AddCommand(graph.NewCommand())
+	cmd.AddCommand(history.NewCommand())
 	cmd.AddCommand(run.NewCommand())
 	cmd.AddCommand(why.NewCommand())
 	return cmd
@@ -0,0 +1,148 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package graph
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/codeactual/transplant/internal/cage/cli/handler"
+	handler_cobra "github.com/codeactual/transplant/internal/cage/cli/handler/cobra"
+	log_zap "github.com/codeactual/transplant/internal/cage/cli/handler/mixin/log/zap"
+	cage_errors "github.com/codeactual/transplant/internal/cage/errors"
+	cage_reflect "github.com/codeactual/transplant/internal/cage/reflect"
+	"github.com/codeactual/transplant/internal/transplant"
+)
+
+// Handler defines the sub-command flags and logic.
+type Handler struct {
+	handler.Session
+
+	ConfigFile string `usage:"configuration file (.json/.toml/.yaml/.yml)"`
+	Op         string `usage:"Ops.Id value from the config file"`
+	Format     string `usage:"Output format: dot,json"`
+	File       string `usage:"Write the graph to a file instead of standard output"`
+	Package    bool   `usage:"Collapse globals into one node per package directory"`
+	Target     string `usage:"Only include paths from the Ops.From files to a global, e.g. example.com/proj/dep.Type.Method"`
+	Pruned     bool   `usage:"Include Ops.Dep globals which will be pruned, highlighted and without edges"`
+
+	Log *log_zap.Mixin
+
+	config transplant.Config
+}
+
+// Init defines the command, its environment variable prefix, etc.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Init() handler_cobra.Init {
+	h.Log = &log_zap.Mixin{}
+
+	return handler_cobra.Init{
+		Cmd: &cobra.Command{
+			Use:   "graph",
+			Short: "Write the graph of Ops.Dep global identifier usage which decides what is pruned",
+		},
+		EnvPrefix: "TRANSPLANT",
+		Mixins: []handler.Mixin{
+			h.Log,
+		},
+	}
+}
+
+// BindFlags binds the flags to Handler fields.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) BindFlags(cmd *cobra.Command) []string {
+	cmd.Flags().StringVarP(&h.ConfigFile, "config", "", "", cage_reflect.GetFieldTag(*h, "ConfigFile", "usage"))
+	cmd.Flags().StringVarP(&h.Op, "op", "", "", cage_reflect.GetFieldTag(*h, "Op", "usage"))
+	cmd.Flags().StringVarP(&h.Format, "format", "", "dot", cage_reflect.GetFieldTag(*h, "Format", "usage"))
+	cmd.Flags().StringVarP(&h.File, "file", "", "", cage_reflect.GetFieldTag(*h, "File", "usage"))
+	cmd.Flags().BoolVarP(&h.Package, "package", "", false, cage_reflect.GetFieldTag(*h, "Package", "usage"))
+	cmd.Flags().StringVarP(&h.Target, "target", "", "", cage_reflect.GetFieldTag(*h, "Target", "usage"))
+	cmd.Flags().BoolVarP(&h.Pruned, "pruned", "", false, cage_reflect.GetFieldTag(*h, "Pruned", "usage"))
+	return []string{"op"}
+}
+
+// PreRun executes after flag parsing and before Run.
+//
+// If it returns an error, Run and PostRun are not executed.
+//
+// It implements cli/handler.PreRun
+func (h *Handler) PreRun(ctx context.Context, args []string) error {
+	if h.Format != "dot" && h.Format != "json" {
+		return errors.Errorf("--format [%s] must be dot or json", h.Format)
+	}
+	return nil
+}
+
+// Run performs the sub-command logic.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	errs := h.config.ReadFile(h.ConfigFile, h.Op)
+	errsLen := len(errs)
+	if errsLen > 0 {
+		errs = append(errs, errors.Errorf("config file contains %d issue(s), canceled [%s] operation", errsLen, h.Op))
+		cage_errors.WriteErrList(h.Err(), errs...)
+		h.Log.ErrToFile(errs...)
+		os.Exit(1)
+	}
+
+	op, ok := h.config.Ops[h.Op]
+	if !ok {
+		var opList string
+		for id := range h.config.Ops {
+			opList += "\n\t" + id
+		}
+		fmt.Fprintf(h.Err(), "available operations:%s\n", opList)
+		h.Log.ExitOnErr(1, errors.Errorf("config file [%s] does not contain operation [%s]", h.ConfigFile, h.Op))
+		return
+	}
+
+	audit := transplant.NewEgressAudit(op)
+	audit.Progress = h.Err() // the audit takes most of a run's time, explain the delay
+
+	errs = audit.Generate()
+	h.Log.ExitOnErr(1, errs...)
+
+	if len(audit.UnconfiguredDirs) > 0 {
+		audit.PrintUnconfiguredDirs(h.Err())
+		h.Log.ExitOnErr(1, errors.Errorf("operation [%s] config does not account for at least one dependency", h.Op))
+	}
+
+	g, err := audit.Graph(transplant.GraphConfig{Package: h.Package, Target: h.Target, Pruned: h.Pruned})
+	h.Log.ExitOnErr(1, err)
+
+	var b bytes.Buffer
+	if h.Format == "json" {
+		h.Log.ExitOnErr(1, g.WriteJSON(&b))
+	} else {
+		h.Log.ExitOnErr(1, g.WriteDot(&b))
+	}
+
+	if h.File == "" {
+		_, err = b.WriteTo(h.Out())
+		h.Log.ExitOnErr(1, errors.WithStack(err))
+		return
+	}
+	h.Log.ExitOnErr(1, errors.Wrapf(ioutil.WriteFile(h.File, b.Bytes(), 0644), "failed to write file [%s]", h.File))
+}
+
+// New returns a cobra command instance based on Handler.
+func NewCommand() *cobra.Command {
+	return handler_cobra.NewHandler(&Handler{
+		Session: &handler.DefaultSession{},
+	})
+}
+
+var _ handler_cobra.Handler = (*Handler)(nil)
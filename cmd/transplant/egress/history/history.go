@@ -0,0 +1,95 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package history
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/codeactual/transplant/internal/cage/cli/handler"
+	handler_cobra "github.com/codeactual/transplant/internal/cage/cli/handler/cobra"
+	log_zap "github.com/codeactual/transplant/internal/cage/cli/handler/mixin/log/zap"
+	cage_reflect "github.com/codeactual/transplant/internal/cage/reflect"
+	"github.com/codeactual/transplant/internal/transplant"
+)
+
+// Handler defines the sub-command flags and logic.
+type Handler struct {
+	handler.Session
+
+	ConfigFile    string `usage:"configuration file (.json/.toml/.yaml/.yml)"`
+	Op            string `usage:"Ops.Id value from the config file"`
+	Since         string `usage:"Origin revision, e.g. tag or commit SHA, after which commits are replayed"`
+	SumWarn       bool   `usage:"Print go.sum verification failures as warnings instead of canceling the copy"`
+	GoVersionWarn bool   `usage:"Print uses of Go features newer than the go.mod go directive as warnings instead of canceling the copy"`
+
+	Log *log_zap.Mixin
+}
+
+// Init defines the command, its environment variable prefix, etc.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Init() handler_cobra.Init {
+	h.Log = &log_zap.Mixin{}
+
+	return handler_cobra.Init{
+		Cmd: &cobra.Command{
+			Use:   "history",
+			Short: "Replay origin commits as destination commits",
+		},
+		EnvPrefix: "TRANSPLANT",
+		Mixins: []handler.Mixin{
+			h.Log,
+		},
+	}
+}
+
+// BindFlags binds the flags to Handler fields.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) BindFlags(cmd *cobra.Command) []string {
+	cmd.Flags().StringVarP(&h.ConfigFile, "config", "", "", cage_reflect.GetFieldTag(*h, "ConfigFile", "usage"))
+	cmd.Flags().StringVarP(&h.Op, "op", "", "", cage_reflect.GetFieldTag(*h, "Op", "usage"))
+	cmd.Flags().StringVarP(&h.Since, "since", "", "", cage_reflect.GetFieldTag(*h, "Since", "usage"))
+	cmd.Flags().BoolVarP(&h.SumWarn, "sum-warn", "", false, cage_reflect.GetFieldTag(*h, "SumWarn", "usage"))
+	cmd.Flags().BoolVarP(&h.GoVersionWarn, "go-version-warn", "", false, cage_reflect.GetFieldTag(*h, "GoVersionWarn", "usage"))
+	return []string{"op", "since"}
+}
+
+// Run performs the sub-command logic.
+//
+// It implements cli/handler/cobra.Handler.
+func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	history := transplant.NewHistory(ctx, h.ConfigFile, h.Op, h.Since)
+	history.ModuleSumWarn = h.SumWarn
+	history.GoVersionWarn = h.GoVersionWarn
+	history.Progress = h.Out()
+	history.Stderr = h.Err()
+
+	commits, errs := history.Run()
+	if len(errs) > 0 && len(commits) > 0 {
+		last := commits[len(commits)-1].OriginSha
+		errs = append(errs, errors.Errorf("replayed %d commit(s), resume with: --since %s", len(commits), last))
+	}
+	h.Log.ExitOnErr(1, errs...)
+
+	if len(commits) == 0 {
+		fmt.Fprintf(h.Out(), "no origin commits since [%s] change the operation's files\n", h.Since)
+	}
+}
+
+// New returns a cobra command instance based on Handler.
+func NewCommand() *cobra.Command {
+	return handler_cobra.NewHandler(&Handler{
+		Session: &handler.DefaultSession{},
+	})
+}
+
+var _ handler_cobra.Handler = (*Handler)(nil)
@@ -15,11 +15,12 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/codeactual/transplant/cmd/transplant/output"
+	"github.com/codeactual/transplant/cmd/transplant/why"
 	"github.com/codeactual/transplant/internal/cage/cli/handler"
 	handler_cobra "github.com/codeactual/transplant/internal/cage/cli/handler/cobra"
 	log_pprof "github.com/codeactual/transplant/internal/cage/cli/handler/mixin/log/pprof"
 	log_zap "github.com/codeactual/transplant/internal/cage/cli/handler/mixin/log/zap"
-	cage_errors "github.com/codeactual/transplant/internal/cage/errors"
 	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
 	cage_reflect "github.com/codeactual/transplant/internal/cage/reflect"
 	cage_strings "github.com/codeactual/transplant/internal/cage/strings"
@@ -30,17 +31,27 @@ import (
 type Handler struct {
 	handler.Session
 
-	ConfigFile string `usage:"configuration file (.json/.toml/.yaml/.yml)"`
-	Op         string `usage:"Ops.Id value from the config file"`
-	PlanFile   string `usage:"Dry-run mode, only write a plan file"`
-	PlanField  string `usage:"(comma-separated) Include extra field(s) in the plan file: PruneGlobalIds,PruneGoFiles"`
-	Progress   string `usage:"(comma-separated) Printed status message types: audit,copy,module"`
+	ConfigFile    string `usage:"configuration file (.json/.toml/.yaml/.yml)"`
+	Op            string `usage:"Ops.Id value from the config file"`
+	PlanFile      string `usage:"Dry-run mode, only write a plan file"`
+	PlanField     string `usage:"(comma-separated) Include extra field(s) in the plan file: Diff,Provenance,PruneGlobalIds,PruneGoFiles,Report"`
+	Progress      string `usage:"(comma-separated) Printed status message types: audit,copy,module"`
+	SumWarn       bool   `usage:"Print go.sum verification failures as warnings instead of canceling the copy"`
+	GoVersionWarn bool   `usage:"Print uses of Go features newer than the go.mod go directive as warnings instead of canceling the copy"`
+	OriginRev     string `usage:"Copy the origin at a git revision, e.g. tag or commit SHA, instead of its working tree"`
+	Diff          bool   `usage:"Print a unified diff of each added, overwritten, and removed file"`
+	Report        bool   `usage:"Print the size and pruning impact of the export on each Ops.Dep and its packages"`
+	WhyLog        string `usage:"Write the audit/copy activity log to a JSON file for why --from-log queries"`
+	Output        string `usage:"Progress and error message format: text,json (JSON lines on standard error)"`
 
 	Log     *log_zap.Mixin
 	Profile *log_pprof.Mixin
 
 	config transplant.Config
 
+	// events is non-nil if --output json is selected.
+	events *transplant.EventLog
+
 	progressTypes map[string]bool
 	planFields    *cage_strings.Set
 }
@@ -75,6 +86,13 @@ func (h *Handler) BindFlags(cmd *cobra.Command) []string {
 	cmd.Flags().StringVarP(&h.PlanField, "plan-field", "", "", cage_reflect.GetFieldTag(*h, "PlanField", "usage"))
 	cmd.Flags().StringVarP(&h.Op, "op", "", "", cage_reflect.GetFieldTag(*h, "Op", "usage"))
 	cmd.Flags().StringVarP(&h.Progress, "progress", "", "audit,copy,module", cage_reflect.GetFieldTag(*h, "Op", "progress"))
+	cmd.Flags().BoolVarP(&h.SumWarn, "sum-warn", "", false, cage_reflect.GetFieldTag(*h, "SumWarn", "usage"))
+	cmd.Flags().BoolVarP(&h.GoVersionWarn, "go-version-warn", "", false, cage_reflect.GetFieldTag(*h, "GoVersionWarn", "usage"))
+	cmd.Flags().StringVarP(&h.OriginRev, "origin-rev", "", "", cage_reflect.GetFieldTag(*h, "OriginRev", "usage"))
+	cmd.Flags().BoolVarP(&h.Diff, "diff", "", false, cage_reflect.GetFieldTag(*h, "Diff", "usage"))
+	cmd.Flags().BoolVarP(&h.Report, "report", "", false, cage_reflect.GetFieldTag(*h, "Report", "usage"))
+	cmd.Flags().StringVarP(&h.WhyLog, "why-log", "", "", cage_reflect.GetFieldTag(*h, "WhyLog", "usage"))
+	cmd.Flags().StringVarP(&h.Output, "output", "", output.Text, cage_reflect.GetFieldTag(*h, "Output", "usage"))
 	return []string{"op"}
 }
 
@@ -84,8 +102,17 @@ func (h *Handler) BindFlags(cmd *cobra.Command) []string {
 //
 // It implements cli/handler.PreRun
 func (h *Handler) PreRun(ctx context.Context, args []string) error {
-	for _, t := range strings.Split(h.Progress, ",") {
-		h.progressTypes[strings.TrimSpace(t)] = true
+	events, err := output.NewEventLog(h.Output, h.Err())
+	if err != nil {
+		return err
+	}
+	h.events = events
+
+	// Step start/end messages are emitted as events instead.
+	if h.events == nil {
+		for _, t := range strings.Split(h.Progress, ",") {
+			h.progressTypes[strings.TrimSpace(t)] = true
+		}
 	}
 
 	h.planFields = cage_strings.NewSet()
@@ -100,17 +127,19 @@ func (h *Handler) PreRun(ctx context.Context, args []string) error {
 //
 // It implements cli/handler/cobra.Handler.
 func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	stderr := output.Stderr(h.events, h.Err())
+
 	errs := h.config.ReadFile(h.ConfigFile, h.Op)
 	errsLen := len(errs)
 	if errsLen > 0 {
 		errs = append(errs, errors.Errorf("config file contains %d issue(s), canceled [%s] operation", errsLen, h.Op))
-		cage_errors.WriteErrList(h.Err(), errs...)
+		output.WriteErrList(h.events, h.Err(), errs...)
 		h.Log.ErrToFile(errs...)
 		os.Exit(1)
 	}
 
 	if len(h.config.Ops) == 0 {
-		h.Log.ExitOnErr(1, errors.Errorf("config file [%s] does not contain any operations", h.ConfigFile))
+		output.ExitOnErr(h.Log, h.events, 1, errors.Errorf("config file [%s] does not contain any operations", h.ConfigFile))
 		return
 	}
 
@@ -120,54 +149,132 @@ func (h *Handler) Run(ctx context.Context, input handler.Input) {
 		for id := range h.config.Ops {
 			opList += "\n\t" + id
 		}
-		fmt.Fprintf(h.Err(), "available operations:%s\n", opList)
-		h.Log.ExitOnErr(1, errors.Errorf("config file [%s] does not contain operation [%s]", h.ConfigFile, h.Op))
+		fmt.Fprintf(stderr, "available operations:%s\n", opList)
+		output.ExitOnErr(h.Log, h.events, 1, errors.Errorf("config file [%s] does not contain operation [%s]", h.ConfigFile, h.Op))
 		return
 	}
 
+	// Remove the origin worktree, if any, before exiting due to an error.
+	var worktree *transplant.Worktree
+	exitOnErr := func(errs ...error) {
+		output.ExitOnErr(h.Log, h.events, 1, worktree.RemoveOnErr(ctx, errs...)...)
+	}
+
+	if h.OriginRev != "" {
+		var worktreeErr error
+		worktree, worktreeErr = transplant.NewOriginWorktree(ctx, op, h.OriginRev)
+		output.ExitOnErr(h.Log, h.events, 1, worktreeErr)
+
+		revConfig := transplant.Config{OriginRoot: worktree.Root}
+		errs = revConfig.ReadFile(h.ConfigFile, h.Op)
+		if len(errs) > 0 {
+			errs = append(errs, errors.Errorf("config file contains %d issue(s) at origin revision [%s], canceled [%s] operation", len(errs), h.OriginRev, h.Op))
+		}
+		exitOnErr(errs...)
+
+		op = revConfig.Ops[h.Op]
+		op.From.Rev = worktree.Sha
+	}
+
 	if h.PlanFile != "" {
 		op.DryRun = true
 	}
 
+	gitCommit := op.Git.Commit && !op.DryRun
+	if gitCommit {
+		exitOnErr(transplant.GitCheckClean(ctx, op))
+	}
+
 	audit := transplant.NewEgressAudit(op)
 
+	var whyLog why.Log
+	if h.WhyLog != "" {
+		whyLog = make(why.Log)
+		audit.WhyLog = whyLog
+	}
+
+	audit.Events = h.events
 	if h.progressTypes["audit"] {
-		audit.Progress = h.Err()
+		audit.Progress = stderr
 	}
 
 	errs = audit.Generate()
-	h.Log.ExitOnErr(1, errs...)
+	exitOnErr(errs...)
 
 	if len(audit.UnconfiguredDirs) > 0 {
-		audit.PrintUnconfiguredDirs(h.Err())
-		h.Log.ExitOnErr(1, errors.Errorf("operation [%s] config does not account for at least one dependency", h.Op))
+		audit.PrintUnconfiguredDirs(stderr)
+		exitOnErr(errors.Errorf("operation [%s] config does not account for at least one dependency", h.Op))
 	}
 
 	copier, copyErr := transplant.NewCopier(ctx, audit)
-	h.Log.ExitOnErr(1, copyErr)
+	exitOnErr(copyErr)
 
 	copier.ModuleRequire = true
+	copier.ModuleSumWarn = h.SumWarn
+	copier.GoVersionWarn = h.GoVersionWarn
 	copier.OverwriteMin = true
-	copier.Stderr = h.Err()
+	copier.Diff = h.Diff || h.planFields.Contains("Diff")
+	copier.Provenance = h.planFields.Contains("Provenance")
+	copier.Report = h.Report || h.planFields.Contains("Report")
+	copier.Stderr = stderr
+	copier.Events = h.events
+	copier.WhyLog = whyLog
 
 	if h.progressTypes["copy"] {
-		copier.ProgressCore = h.Err()
+		copier.ProgressCore = stderr
 	}
 	if h.progressTypes["module"] {
-		copier.ProgressModule = h.Err()
+		copier.ProgressModule = stderr
 	}
 
 	plan, errs := copier.Run()
 	if len(errs) > 0 {
-		fmt.Fprintf(h.Err(), "(files staged for copy were saved here: %s)\n", plan.StagePath)
+		fmt.Fprintf(stderr, "(files staged for copy were saved here: %s)\n", plan.StagePath)
 	}
-	h.Log.ExitOnErr(1, errs...)
+	exitOnErr(errs...)
 
 	if h.PlanFile != "" {
-		h.Log.ExitOnErr(1, plan.WriteFile(h.PlanFile, h.planFields))
+		exitOnErr(plan.WriteFile(h.PlanFile, h.planFields))
 	}
 
-	h.Log.ExitOnErr(1, cage_file.RemoveAllSafer(plan.StagePath))
+	if h.WhyLog != "" {
+		// Index the activity by the configured paths because the worktree is removed below.
+		if worktree != nil {
+			whyLog = whyLog.Rebase(worktree.Unapply)
+		}
+		exitOnErr(why.WriteLogFile(h.WhyLog, "export", h.Op, h.ConfigFile, whyLog))
+	}
+
+	if h.Diff {
+		for _, d := range plan.Diff {
+			fmt.Fprint(h.Out(), d)
+		}
+	}
+
+	if h.Report && plan.Report != nil {
+		fmt.Fprint(h.Out(), plan.Report)
+	}
+
+	exitOnErr(cage_file.RemoveAllSafer(plan.StagePath))
+
+	if worktree != nil {
+		output.ExitOnErr(h.Log, h.events, 1, worktree.Remove(ctx))
+		worktree = nil
+	}
+
+	if gitCommit {
+		gitRes, gitErr := transplant.GitCommit(ctx, op)
+		exitOnErr(gitErr)
+
+		if gitRes.Sha == "" {
+			fmt.Fprintln(h.Out(), "no changes to commit")
+		} else {
+			fmt.Fprintf(h.Out(), "commit %s\n", gitRes.Sha)
+		}
+		if gitRes.Tag != "" {
+			fmt.Fprintf(h.Out(), "tag %s\n", gitRes.Tag)
+		}
+	}
 
 	if h.Profile.CpuFile != "" {
 		fmt.Fprintf(h.Out(), "go tool pprof -top -cum %s | head -20\n", h.Profile.CpuFile)
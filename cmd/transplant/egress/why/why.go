@@ -14,18 +14,18 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/codeactual/transplant/cmd/transplant/output"
 	"github.com/codeactual/transplant/cmd/transplant/why"
 	"github.com/codeactual/transplant/internal/cage/cli/handler"
 	handler_cobra "github.com/codeactual/transplant/internal/cage/cli/handler/cobra"
 	log_zap "github.com/codeactual/transplant/internal/cage/cli/handler/mixin/log/zap"
-	cage_errors "github.com/codeactual/transplant/internal/cage/errors"
 	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
 	cage_filepath "github.com/codeactual/transplant/internal/cage/path/filepath"
 	cage_reflect "github.com/codeactual/transplant/internal/cage/reflect"
 	"github.com/codeactual/transplant/internal/transplant"
 )
 
-const exampleText = "file /path/to/file"
+const exampleText = "/path/to/file [/path/to/other ...], or --global example.com/proj/dep.Type.Method"
 
 // Handler defines the sub-command flags and logic.
 type Handler struct {
@@ -33,10 +33,16 @@ type Handler struct {
 
 	ConfigFile string `usage:"YAML configuration file"`
 	Op         string `usage:"Ops.Id value from the config file"`
+	FromLog    string `usage:"Read the activity log from a file written by export run --why-log, instead of repeating the dry-run"`
+	Global     string `usage:"Explain why an Ops.Dep global, e.g. example.com/proj/dep.Type.Method, is kept or pruned"`
+	Output     string `usage:"Progress and error message format: text,json (JSON lines on standard error)"`
 
 	Log *log_zap.Mixin
 
 	config transplant.Config
+
+	// events is non-nil if --output json is selected.
+	events *transplant.EventLog
 }
 
 // Init defines the command, its environment variable prefix, etc.
@@ -48,7 +54,7 @@ func (h *Handler) Init() handler_cobra.Init {
 	return handler_cobra.Init{
 		Cmd: &cobra.Command{
 			Use:     "why",
-			Short:   "Display a log of audit/copy activity related to the file/dir path",
+			Short:   "Display a log of audit/copy activity related to the file/dir path(s), or why an Ops.Dep global is kept",
 			Example: exampleText,
 		},
 		EnvPrefix: "TRANSPLANT",
@@ -64,24 +70,43 @@ func (h *Handler) Init() handler_cobra.Init {
 func (h *Handler) BindFlags(cmd *cobra.Command) []string {
 	cmd.Flags().StringVarP(&h.ConfigFile, "config", "", "", cage_reflect.GetFieldTag(*h, "ConfigFile", "usage"))
 	cmd.Flags().StringVarP(&h.Op, "op", "", "", cage_reflect.GetFieldTag(*h, "Op", "usage"))
+	cmd.Flags().StringVarP(&h.FromLog, "from-log", "", "", cage_reflect.GetFieldTag(*h, "FromLog", "usage"))
+	cmd.Flags().StringVarP(&h.Global, "global", "", "", cage_reflect.GetFieldTag(*h, "Global", "usage"))
+	cmd.Flags().StringVarP(&h.Output, "output", "", output.Text, cage_reflect.GetFieldTag(*h, "Output", "usage"))
 	return []string{"op"}
 }
 
+// PreRun executes after flag parsing and before Run.
+//
+// If it returns an error, Run and PostRun are not executed.
+//
+// It implements cli/handler.PreRun
+func (h *Handler) PreRun(ctx context.Context, args []string) error {
+	events, err := output.NewEventLog(h.Output, h.Err())
+	if err != nil {
+		return err
+	}
+	h.events = events
+	return nil
+}
+
 // Run performs the sub-command logic.
 //
 // It implements cli/handler/cobra.Handler.
 func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	stderr := output.Stderr(h.events, h.Err())
+
 	errs := h.config.ReadFile(h.ConfigFile, h.Op)
 	errsLen := len(errs)
 	if errsLen > 0 {
 		errs = append(errs, errors.Errorf("config file contains %d issue(s), canceled [%s] operation", errsLen, h.Op))
-		cage_errors.WriteErrList(h.Err(), errs...)
+		output.WriteErrList(h.events, h.Err(), errs...)
 		h.Log.ErrToFile(errs...)
 		os.Exit(1)
 	}
 
 	if len(h.config.Ops) == 0 {
-		h.Log.ExitOnErr(1, errors.Errorf("config file [%s] does not contain any operations", h.ConfigFile))
+		output.ExitOnErr(h.Log, h.events, 1, errors.Errorf("config file [%s] does not contain any operations", h.ConfigFile))
 		return
 	}
 
@@ -91,53 +116,92 @@ func (h *Handler) Run(ctx context.Context, input handler.Input) {
 		for id := range h.config.Ops {
 			opList += "\n\t" + id
 		}
-		fmt.Fprintf(h.Err(), "Available operations:%s\n", opList)
-		h.Log.ExitOnErr(1, errors.Errorf("config file [%s] does not contain operation [%s]", h.ConfigFile, h.Op))
+		fmt.Fprintf(stderr, "Available operations:%s\n", opList)
+		output.ExitOnErr(h.Log, h.events, 1, errors.Errorf("config file [%s] does not contain operation [%s]", h.ConfigFile, h.Op))
 		return
 	}
 
-	if input.Args[0] == "" {
-		h.Exitf(1, "missing argument, example: "+exampleText)
+	if h.Global == "" {
+		if len(input.Args) == 0 || input.Args[0] == "" {
+			h.Exitf(1, "missing argument, example: "+exampleText)
+		}
+
+		for n := range input.Args {
+			if absErr := cage_filepath.Abs(&input.Args[n]); absErr != nil {
+				output.ExitOnErr(h.Log, h.events, 1, absErr)
+			}
+		}
 	}
 
-	if absErr := cage_filepath.Abs(&input.Args[0]); absErr != nil {
-		h.Log.ExitOnErr(1, absErr)
+	if h.FromLog != "" && h.Global == "" {
+		whyLog, readErr := why.ReadLogFile(stderr, h.FromLog, "export", h.Op, h.ConfigFile)
+		output.ExitOnErr(h.Log, h.events, 1, readErr)
+		printLogs(h, whyLog, input.Args)
+		return
 	}
 
 	whyLog := make(why.Log)
 
 	op.DryRun = true
-	fmt.Fprintln(h.Err(), "Starting dry-run to collect file/dir activity logs ...")
+	if h.Global == "" {
+		fmt.Fprintln(stderr, "Starting dry-run to collect file/dir activity logs ...")
+	} else {
+		fmt.Fprintln(stderr, "Starting audit to collect global identifier usage ...")
+	}
 
 	audit := transplant.NewEgressAudit(op)
 
-	audit.Progress = h.Err() //  dry-run takes almost as long as full runs, explain the delay
+	audit.Events = h.events
+	if h.events == nil {
+		audit.Progress = stderr //  dry-run takes almost as long as full runs, explain the delay
+	}
 	audit.WhyLog = whyLog
 
 	errs = audit.Generate()
-	h.Log.ExitOnErr(1, errs...)
+	output.ExitOnErr(h.Log, h.events, 1, errs...)
 
 	if len(audit.UnconfiguredDirs) > 0 {
-		audit.PrintUnconfiguredDirs(h.Err())
-		h.Log.ExitOnErr(1, errors.Errorf("operation [%s] config does not account for at least one dependency", h.Op))
+		audit.PrintUnconfiguredDirs(stderr)
+		output.ExitOnErr(h.Log, h.events, 1, errors.Errorf("operation [%s] config does not account for at least one dependency", h.Op))
+	}
+
+	// The audit alone builds the global usage graph, so the copy is not needed.
+	if h.Global != "" {
+		res, whyErr := audit.WhyGlobal(h.Global)
+		output.ExitOnErr(h.Log, h.events, 1, whyErr)
+		res.Print(h.Out())
+		return
 	}
 
 	copier, copyErr := transplant.NewCopier(ctx, audit)
-	h.Log.ExitOnErr(1, copyErr)
+	output.ExitOnErr(h.Log, h.events, 1, copyErr)
 
-	copier.Stderr = h.Err()
-	copier.ProgressCore = h.Err() //  dry-run takes almost as long as full runs, explain the delay
+	copier.Stderr = stderr
+	copier.Events = h.events
+	if h.events == nil {
+		copier.ProgressCore = stderr //  dry-run takes almost as long as full runs, explain the delay
+	}
 	copier.WhyLog = whyLog
 
 	plan, errs := copier.Run()
 	if len(errs) > 0 {
-		fmt.Fprintf(h.Err(), "(files staged for copy were saved here: %s)\n", plan.StagePath)
+		fmt.Fprintf(stderr, "(files staged for copy were saved here: %s)\n", plan.StagePath)
 	}
-	h.Log.ExitOnErr(1, errs...)
+	output.ExitOnErr(h.Log, h.events, 1, errs...)
+
+	printLogs(h, whyLog, input.Args)
 
-	why.PrintLog(h.Out(), whyLog, input.Args[0])
+	output.ExitOnErr(h.Log, h.events, 1, cage_file.RemoveAllSafer(plan.StagePath))
+}
 
-	h.Log.ExitOnErr(1, cage_file.RemoveAllSafer(plan.StagePath))
+// printLogs writes the activity of each absolute path, separated by a blank line.
+func printLogs(h *Handler, whyLog why.Log, absPaths []string) {
+	for n, absPath := range absPaths {
+		if n > 0 {
+			fmt.Fprintln(h.Out())
+		}
+		why.PrintLog(h.Out(), whyLog, absPath)
+	}
 }
 
 // New returns a cobra command instance based on Handler.
@@ -10,16 +10,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/codeactual/transplant/cmd/transplant/output"
+	"github.com/codeactual/transplant/cmd/transplant/why"
 	"github.com/codeactual/transplant/internal/cage/cli/handler"
 	handler_cobra "github.com/codeactual/transplant/internal/cage/cli/handler/cobra"
 	log_pprof "github.com/codeactual/transplant/internal/cage/cli/handler/mixin/log/pprof"
 	log_zap "github.com/codeactual/transplant/internal/cage/cli/handler/mixin/log/zap"
-	cage_errors "github.com/codeactual/transplant/internal/cage/errors"
 	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
 	cage_reflect "github.com/codeactual/transplant/internal/cage/reflect"
 	cage_strings "github.com/codeactual/transplant/internal/cage/strings"
@@ -33,14 +35,22 @@ type Handler struct {
 	ConfigFile string `usage:"configuration file (.json/.toml/.yaml/.yml)"`
 	Op         string `usage:"Ops.Id value from the config file"`
 	PlanFile   string `usage:"Dry-run mode, only write a plan file"`
-	PlanField  string `usage:"(comma-separated) Include extra field(s) in the plan file: PruneGlobalIds,PruneGoFiles"`
+	PlanField  string `usage:"(comma-separated) Include extra field(s) in the plan file: Diff,Provenance,PruneGlobalIds,PruneGoFiles"`
 	Progress   string `usage:"(comma-separated) Printed status message types: audit,copy"`
+	CopyRev    string `usage:"Read the copy at a git revision, e.g. branch or commit SHA, instead of its working tree"`
+	Patch      string `usage:"Apply a unified diff file to the copy, at --copy-rev or HEAD, before reading it"`
+	Diff       bool   `usage:"Print a unified diff of each added, overwritten, and removed file"`
+	WhyLog     string `usage:"Write the audit/copy activity log to a JSON file for why --from-log queries"`
+	Output     string `usage:"Progress and error message format: text,json (JSON lines on standard error)"`
 
 	Log     *log_zap.Mixin
 	Profile *log_pprof.Mixin
 
 	config transplant.Config
 
+	// events is non-nil if --output json is selected.
+	events *transplant.EventLog
+
 	progressTypes map[string]bool
 	planFields    *cage_strings.Set
 }
@@ -74,7 +84,12 @@ func (h *Handler) BindFlags(cmd *cobra.Command) []string {
 	cmd.Flags().StringVarP(&h.PlanFile, "plan", "", "", cage_reflect.GetFieldTag(*h, "PlanFile", "usage"))
 	cmd.Flags().StringVarP(&h.PlanField, "plan-field", "", "", cage_reflect.GetFieldTag(*h, "PlanField", "usage"))
 	cmd.Flags().StringVarP(&h.Op, "op", "", "", cage_reflect.GetFieldTag(*h, "Op", "usage"))
+	cmd.Flags().StringVarP(&h.CopyRev, "copy-rev", "", "", cage_reflect.GetFieldTag(*h, "CopyRev", "usage"))
+	cmd.Flags().StringVarP(&h.Patch, "patch", "", "", cage_reflect.GetFieldTag(*h, "Patch", "usage"))
+	cmd.Flags().BoolVarP(&h.Diff, "diff", "", false, cage_reflect.GetFieldTag(*h, "Diff", "usage"))
+	cmd.Flags().StringVarP(&h.WhyLog, "why-log", "", "", cage_reflect.GetFieldTag(*h, "WhyLog", "usage"))
 	cmd.Flags().StringVarP(&h.Progress, "progress", "", "audit,copy", cage_reflect.GetFieldTag(*h, "Op", "progress"))
+	cmd.Flags().StringVarP(&h.Output, "output", "", output.Text, cage_reflect.GetFieldTag(*h, "Output", "usage"))
 	return []string{"op"}
 }
 
@@ -84,8 +99,17 @@ func (h *Handler) BindFlags(cmd *cobra.Command) []string {
 //
 // It implements cli/handler.PreRun
 func (h *Handler) PreRun(ctx context.Context, args []string) error {
-	for _, t := range strings.Split(h.Progress, ",") {
-		h.progressTypes[strings.TrimSpace(t)] = true
+	events, err := output.NewEventLog(h.Output, h.Err())
+	if err != nil {
+		return err
+	}
+	h.events = events
+
+	// Step start/end messages are emitted as events instead.
+	if h.events == nil {
+		for _, t := range strings.Split(h.Progress, ",") {
+			h.progressTypes[strings.TrimSpace(t)] = true
+		}
 	}
 
 	h.planFields = cage_strings.NewSet()
@@ -100,17 +124,19 @@ func (h *Handler) PreRun(ctx context.Context, args []string) error {
 //
 // It implements cli/handler/cobra.Handler.
 func (h *Handler) Run(ctx context.Context, input handler.Input) {
+	stderr := output.Stderr(h.events, h.Err())
+
 	errs := h.config.ReadFile(h.ConfigFile, h.Op)
 	errsLen := len(errs)
 	if errsLen > 0 {
 		errs = append(errs, errors.Errorf("config file contains %d issue(s), canceled [%s] operation", errsLen, h.Op))
-		cage_errors.WriteErrList(h.Err(), errs...)
+		output.WriteErrList(h.events, h.Err(), errs...)
 		h.Log.ErrToFile(errs...)
 		os.Exit(1)
 	}
 
 	if len(h.config.Ops) == 0 {
-		h.Log.ExitOnErr(1, errors.Errorf("config file [%s] does not contain any operations", h.ConfigFile))
+		output.ExitOnErr(h.Log, h.events, 1, errors.Errorf("config file [%s] does not contain any operations", h.ConfigFile))
 		return
 	}
 
@@ -120,50 +146,110 @@ func (h *Handler) Run(ctx context.Context, input handler.Input) {
 		for id := range h.config.Ops {
 			opList += "\n\t" + id
 		}
-		fmt.Fprintf(h.Err(), "available operations:%s\n", opList)
-		h.Log.ExitOnErr(1, errors.Errorf("config file [%s] does not contain operation [%s]", h.ConfigFile, h.Op))
+		fmt.Fprintf(stderr, "available operations:%s\n", opList)
+		output.ExitOnErr(h.Log, h.events, 1, errors.Errorf("config file [%s] does not contain operation [%s]", h.ConfigFile, h.Op))
 		return
 	}
 
+	// Remove the copy worktree, if any, before exiting due to an error.
+	var worktree *transplant.Worktree
+	exitOnErr := func(errs ...error) {
+		output.ExitOnErr(h.Log, h.events, 1, worktree.RemoveOnErr(ctx, errs...)...)
+	}
+
+	if h.CopyRev != "" || h.Patch != "" {
+		rev := h.CopyRev
+		if rev == "" {
+			rev = "HEAD"
+		}
+
+		var patchPath string
+		if h.Patch != "" {
+			var absErr error
+			patchPath, absErr = filepath.Abs(h.Patch)
+			output.ExitOnErr(h.Log, h.events, 1, errors.Wrapf(absErr, "failed to get absolute path of patch [%s]", h.Patch))
+		}
+
+		var worktreeErr error
+		worktree, worktreeErr = transplant.NewCopyWorktree(ctx, op, rev)
+		output.ExitOnErr(h.Log, h.events, 1, worktreeErr)
+
+		if patchPath != "" {
+			exitOnErr(worktree.ApplyPatch(ctx, patchPath))
+		}
+
+		op.To.ModuleFilePath = worktree.Root.Apply(op.To.ModuleFilePath)
+	}
+
 	if h.PlanFile != "" {
 		op.DryRun = true
 	}
 
 	audit := transplant.NewIngressAudit(op)
 
+	var whyLog why.Log
+	if h.WhyLog != "" {
+		whyLog = make(why.Log)
+		audit.WhyLog = whyLog
+	}
+
+	audit.Events = h.events
 	if h.progressTypes["audit"] {
-		audit.Progress = h.Err()
+		audit.Progress = stderr
 	}
 
 	errs = audit.Generate()
-	h.Log.ExitOnErr(1, errs...)
+	exitOnErr(errs...)
 
 	if len(audit.UnconfiguredDirs) > 0 {
-		audit.PrintUnconfiguredDirs(h.Err())
-		h.Log.ExitOnErr(1, errors.Errorf("operation [%s] config does not account for at least one dependency", h.Op))
+		audit.PrintUnconfiguredDirs(stderr)
+		exitOnErr(errors.Errorf("operation [%s] config does not account for at least one dependency", h.Op))
 	}
 
 	copier, copyErr := transplant.NewCopier(ctx, audit)
-	h.Log.ExitOnErr(1, copyErr)
+	exitOnErr(copyErr)
 
 	copier.OverwriteMin = true
-	copier.Stderr = h.Err()
+	copier.Diff = h.Diff || h.planFields.Contains("Diff")
+	copier.Provenance = h.planFields.Contains("Provenance")
+	copier.Stderr = stderr
+	copier.Events = h.events
+	copier.WhyLog = whyLog
 
 	if h.progressTypes["copy"] {
-		copier.ProgressCore = h.Err()
+		copier.ProgressCore = stderr
 	}
 
 	plan, errs := copier.Run()
 	if len(errs) > 0 {
-		fmt.Fprintf(h.Err(), "(files staged for copy were saved here: %s)\n", plan.StagePath)
+		fmt.Fprintf(stderr, "(files staged for copy were saved here: %s)\n", plan.StagePath)
 	}
-	h.Log.ExitOnErr(1, errs...)
+	exitOnErr(errs...)
 
 	if h.PlanFile != "" {
-		h.Log.ExitOnErr(1, plan.WriteFile(h.PlanFile, h.planFields))
+		exitOnErr(plan.WriteFile(h.PlanFile, h.planFields))
 	}
 
-	h.Log.ExitOnErr(1, cage_file.RemoveAllSafer(plan.StagePath))
+	if h.WhyLog != "" {
+		// Index the activity by the configured paths because the worktree is removed below.
+		if worktree != nil {
+			whyLog = whyLog.Rebase(worktree.Unapply)
+		}
+		exitOnErr(why.WriteLogFile(h.WhyLog, "import", h.Op, h.ConfigFile, whyLog))
+	}
+
+	if h.Diff {
+		for _, d := range plan.Diff {
+			fmt.Fprint(h.Out(), d)
+		}
+	}
+
+	exitOnErr(cage_file.RemoveAllSafer(plan.StagePath))
+
+	if worktree != nil {
+		output.ExitOnErr(h.Log, h.events, 1, worktree.Remove(ctx))
+		worktree = nil
+	}
 
 	if h.Profile.CpuFile != "" {
 		fmt.Fprintf(h.Out(), "go tool pprof -top -cum %s | head -20\n", h.Profile.CpuFile)
@@ -0,0 +1,78 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package output supports the --output flag of commands which select between free-text and
+// JSON-lines progress/error messages.
+package output
+
+import (
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	log_zap "github.com/codeactual/transplant/internal/cage/cli/handler/mixin/log/zap"
+	cage_errors "github.com/codeactual/transplant/internal/cage/errors"
+	"github.com/codeactual/transplant/internal/transplant"
+)
+
+// Values of the --output flag.
+const (
+	// Text selects free-text messages on standard error.
+	Text = "text"
+
+	// JSON selects transplant.Event JSON lines on standard error.
+	JSON = "json"
+)
+
+// NewEventLog returns a log which writes to w if the format is JSON, or nil if it is Text.
+func NewEventLog(format string, w io.Writer) (*transplant.EventLog, error) {
+	switch format {
+	case Text:
+		return nil, nil
+	case JSON:
+		return transplant.NewEventLog(w), nil
+	}
+	return nil, errors.Errorf("--output [%s] must be %s or %s", format, Text, JSON)
+}
+
+// Stderr returns a writer which emits each line as a transplant.EventMessage if the log is non-nil,
+// or the standard error writer otherwise.
+func Stderr(events *transplant.EventLog, stderr io.Writer) io.Writer {
+	if events == nil {
+		return stderr
+	}
+	return events.MessageWriter()
+}
+
+// WriteErrList emits the errors if the log is non-nil, or writes them as a list otherwise.
+func WriteErrList(events *transplant.EventLog, w io.Writer, errs ...error) {
+	if events == nil {
+		cage_errors.WriteErrList(w, errs...)
+		return
+	}
+	events.Errors(errs...)
+}
+
+// ExitOnErr emits the errors if the log is non-nil, and then exits if at least one is non-nil.
+//
+// If the log is nil, it delegates to log_zap.Mixin.ExitOnErr.
+func ExitOnErr(log *log_zap.Mixin, events *transplant.EventLog, code int, errs ...error) {
+	if events == nil {
+		log.ExitOnErr(code, errs...)
+		return
+	}
+
+	errsLen := len(errs)
+	if errsLen == 0 || (errsLen == 1 && errs[0] == nil) {
+		return
+	}
+
+	events.Errors(errs...)
+	log.ErrToFile(errs...)
+
+	os.Exit(code)
+}
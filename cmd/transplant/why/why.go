@@ -7,11 +7,18 @@
 package why
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	cage_strings "github.com/codeactual/transplant/internal/cage/strings"
 	"io"
+	"io/ioutil"
 	"path/filepath"
 	"strings"
+
+	"github.com/pkg/errors"
+
+	cage_strings "github.com/codeactual/transplant/internal/cage/strings"
 )
 
 // Log holds file/dir activity messages which support `{egress,ingress} why` queries.
@@ -19,6 +26,90 @@ import (
 // The messages are indexed by file/dir absolute paths.
 type Log map[string][]string
 
+// Rebase returns a copy of the log whose paths are replaced by the function's return values, e.g. to
+// report configured paths instead of those of a temporary git worktree.
+func (l Log) Rebase(f func(string) string) Log {
+	rebased := make(Log, len(l))
+	for absPath, messages := range l {
+		rebased[f(absPath)] = messages
+	}
+	return rebased
+}
+
+// LogFile is written by `{export,import} run --why-log` to support `{export,import} why --from-log` queries
+// without repeating the audit and copy.
+type LogFile struct {
+	// Mode is "export" or "import".
+	Mode string
+
+	// Op is the Ops.Id value of the run.
+	Op string
+
+	// ConfigHash is the hex-encoded SHA-256 of the config file's content at the time of the run.
+	ConfigHash string
+
+	Log Log
+}
+
+// ConfigHash returns the hex-encoded SHA-256 of the config file's content.
+func ConfigHash(configFile string) (string, error) {
+	b, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read config file [%s]", configFile)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// WriteLogFile writes the log and the run's details as JSON.
+func WriteLogFile(name, mode, op, configFile string, log Log) error {
+	hash, err := ConfigHash(configFile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	b, err := json.MarshalIndent(LogFile{Mode: mode, Op: op, ConfigHash: hash, Log: log}, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal why log")
+	}
+
+	if err = ioutil.WriteFile(name, b, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write why log [%s]", name)
+	}
+
+	return nil
+}
+
+// ReadLogFile reads a file written by WriteLogFile and verifies that it was written by a run of the same
+// mode and operation.
+//
+// If the config file's content changed since the run, a warning is written to w.
+func ReadLogFile(w io.Writer, name, mode, op, configFile string) (Log, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read why log [%s]", name)
+	}
+
+	var f LogFile
+	if err = json.Unmarshal(b, &f); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse why log [%s]", name)
+	}
+
+	if f.Mode != mode || f.Op != op {
+		return nil, errors.Errorf("why log [%s] was written by [%s run --op %s], expected [%s run --op %s]", name, f.Mode, f.Op, mode, op)
+	}
+
+	hash, err := ConfigHash(configFile)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if hash != f.ConfigHash {
+		fmt.Fprintf(w, "warning: config file [%s] changed since why log [%s] was written, results may be outdated\n", configFile, name)
+	}
+
+	return f.Log, nil
+}
+
 func FileQuery(log Log, absPath string) (messages []string) {
 	if _, ok := log[absPath]; ok {
 		messages = make([]string, len(log[absPath]))
@@ -0,0 +1,100 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package why_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/codeactual/transplant/cmd/transplant/why"
+)
+
+// newLogFixture returns a temporary directory which contains a config file, and the path to a why log
+// in the same directory.
+func newLogFixture(t *testing.T) (dir, configFile, logFile string) {
+	dir, err := ioutil.TempDir("", "transplant-why-")
+	require.NoError(t, err)
+
+	configFile = filepath.Join(dir, "transplant.yml")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte("Ops: {}\n"), 0644))
+
+	return dir, configFile, filepath.Join(dir, "why.json")
+}
+
+func TestLogFileRoundTrip(t *testing.T) {
+	dir, configFile, logFile := newLogFixture(t)
+	defer os.RemoveAll(dir)
+
+	log := why.Log{
+		"/path/to/origin/local/local.go": {"matched Ops.From.GoFilePath pattern"},
+		"/path/to/origin/dep1/dep1.go":   {"matched Ops.Dep.From.GoFilePath pattern", "pruned 1 global identifier(s)"},
+	}
+	require.NoError(t, why.WriteLogFile(logFile, "export", "proj", configFile, log))
+
+	var warnings bytes.Buffer
+	actual, err := why.ReadLogFile(&warnings, logFile, "export", "proj", configFile)
+	require.NoError(t, err)
+	require.Exactly(t, log, actual)
+	require.Empty(t, warnings.String())
+}
+
+func TestLogFileMismatch(t *testing.T) {
+	dir, configFile, logFile := newLogFixture(t)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, why.WriteLogFile(logFile, "export", "proj", configFile, why.Log{}))
+
+	_, err := why.ReadLogFile(ioutil.Discard, logFile, "import", "proj", configFile)
+	require.EqualError(t, err, "why log ["+logFile+"] was written by [export run --op proj], expected [import run --op proj]")
+
+	_, err = why.ReadLogFile(ioutil.Discard, logFile, "export", "other", configFile)
+	require.EqualError(t, err, "why log ["+logFile+"] was written by [export run --op proj], expected [export run --op other]")
+}
+
+func TestLogFileConfigChanged(t *testing.T) {
+	dir, configFile, logFile := newLogFixture(t)
+	defer os.RemoveAll(dir)
+
+	log := why.Log{"/path/to/origin/local/local.go": {"matched Ops.From.GoFilePath pattern"}}
+	require.NoError(t, why.WriteLogFile(logFile, "export", "proj", configFile, log))
+
+	require.NoError(t, ioutil.WriteFile(configFile, []byte("Ops:\n  proj: {}\n"), 0644))
+
+	var warnings bytes.Buffer
+	actual, err := why.ReadLogFile(&warnings, logFile, "export", "proj", configFile)
+	require.NoError(t, err)
+	require.Exactly(t, log, actual)
+	require.Exactly(t, "warning: config file ["+configFile+"] changed since why log ["+logFile+"] was written, results may be outdated\n", warnings.String())
+}
+
+func TestLogRebase(t *testing.T) {
+	log := why.Log{
+		"/tmp/worktree/local/local.go": {"matched Ops.From.GoFilePath pattern"},
+		"/path/to/dest/go.mod":         {"copied"},
+	}
+
+	rebased := log.Rebase(func(p string) string {
+		if p == "/tmp/worktree/local/local.go" {
+			return "/path/to/origin/local/local.go"
+		}
+		return p
+	})
+
+	require.Exactly(
+		t,
+		why.Log{
+			"/path/to/origin/local/local.go": {"matched Ops.From.GoFilePath pattern"},
+			"/path/to/dest/go.mod":           {"copied"},
+		},
+		rebased,
+	)
+}
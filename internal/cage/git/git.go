@@ -0,0 +1,219 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package git runs the local git binary to query and update repositories without network access.
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	cage_exec "github.com/codeactual/transplant/internal/cage/os/exec"
+)
+
+// Signature identifies the author of a commit.
+type Signature struct {
+	Name  string
+	Email string
+	When  time.Time
+}
+
+// CommitInfo describes a commit.
+type CommitInfo struct {
+	// Sha is the full commit SHA.
+	Sha string
+
+	Author Signature
+
+	// Message is the commit message without trailing newlines.
+	Message string
+}
+
+// Repo runs git commands in a working tree.
+type Repo struct {
+	// Dir is the working directory of each command. It may be any directory of the working tree.
+	Dir string
+
+	// Env holds "key=value" pairs which override the current process's environment.
+	Env []string
+
+	// Executor runs the commands.
+	Executor cage_exec.Executor
+}
+
+// NewRepo returns an initialized instance.
+func NewRepo(dir string) *Repo {
+	return &Repo{Dir: dir, Executor: cage_exec.CommonExecutor{}}
+}
+
+// Run executes git with the arguments and returns its stdout with surrounding whitespace trimmed.
+func (r *Repo) Run(ctx context.Context, args ...string) (stdout string, err error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.Dir
+	cmd.Env = append(os.Environ(), r.Env...)
+
+	stdoutBuf, stderrBuf, _, err := r.Executor.Buffered(ctx, cmd)
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to run [git %s] in [%s]: %s", strings.Join(args, " "), r.Dir, strings.TrimSpace(stderrBuf.String()))
+	}
+
+	return strings.TrimSpace(stdoutBuf.String()), nil
+}
+
+// TopLevel returns the absolute path to the root of the working tree.
+func (r *Repo) TopLevel(ctx context.Context) (string, error) {
+	return r.Run(ctx, "rev-parse", "--show-toplevel")
+}
+
+// RevParse returns the full commit SHA of the revision, e.g. "HEAD" or a tag name.
+func (r *Repo) RevParse(ctx context.Context, rev string) (string, error) {
+	return r.Run(ctx, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+}
+
+// Status returns the `git status --porcelain` lines of modified, staged, and untracked files.
+//
+// If paths are selected, only their changes are included.
+func (r *Repo) Status(ctx context.Context, paths ...string) (lines []string, err error) {
+	args := []string{"status", "--porcelain", "--untracked-files=all"}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+
+	stdout, err := r.Run(ctx, args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, line := range strings.Split(stdout, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+// Commit stages all changes to the paths, or the whole working tree if none are selected, and commits them.
+//
+// It returns an empty SHA, and creates no commit, if there are no changes.
+func (r *Repo) Commit(ctx context.Context, message string, paths ...string) (sha string, err error) {
+	return r.commit(ctx, nil, message, paths...)
+}
+
+// CommitAs is Commit with the author's name, email, and date selected by the signature.
+func (r *Repo) CommitAs(ctx context.Context, author Signature, message string, paths ...string) (sha string, err error) {
+	return r.commit(ctx, []string{
+		"--author", author.Name + " <" + author.Email + ">",
+		"--date", author.When.Format(time.RFC3339),
+	}, message, paths...)
+}
+
+func (r *Repo) commit(ctx context.Context, commitArgs []string, message string, paths ...string) (sha string, err error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	if _, err = r.Run(ctx, append([]string{"add", "--all", "--"}, paths...)...); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	// Exit code 1 indicates staged changes.
+	if _, diffErr := r.Run(ctx, append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...); diffErr == nil {
+		return "", nil
+	}
+
+	args := append([]string{"commit", "--quiet", "--message", message}, commitArgs...)
+	if _, err = r.Run(ctx, args...); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return r.RevParse(ctx, "HEAD")
+}
+
+// ReadCommit returns the SHA, author, and message of the revision.
+func (r *Repo) ReadCommit(ctx context.Context, rev string) (info CommitInfo, err error) {
+	stdout, err := r.Run(ctx, "show", "--no-patch", "--format=%H%x00%an%x00%ae%x00%aI%x00%B", rev+"^{commit}")
+	if err != nil {
+		return CommitInfo{}, errors.WithStack(err)
+	}
+
+	fields := strings.SplitN(stdout, "\x00", 5)
+	if len(fields) != 5 {
+		return CommitInfo{}, errors.Errorf("failed to parse commit [%s] details: %q", rev, stdout)
+	}
+
+	when, err := time.Parse(time.RFC3339, fields[3])
+	if err != nil {
+		return CommitInfo{}, errors.Wrapf(err, "failed to parse author date of commit [%s]", rev)
+	}
+
+	return CommitInfo{
+		Sha:     fields[0],
+		Author:  Signature{Name: fields[1], Email: fields[2], When: when},
+		Message: strings.TrimRight(fields[4], "\n"),
+	}, nil
+}
+
+// RevList returns the SHAs of the commits in the range, e.g. "v1.0.0..HEAD", oldest first and
+// with parents before children.
+//
+// If paths are selected, only commits which change them are included.
+func (r *Repo) RevList(ctx context.Context, revRange string, paths ...string) (shas []string, err error) {
+	args := []string{"rev-list", "--reverse", "--topo-order", revRange}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+
+	stdout, err := r.Run(ctx, args...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, line := range strings.Split(stdout, "\n") {
+		if line != "" {
+			shas = append(shas, line)
+		}
+	}
+
+	return shas, nil
+}
+
+// AddWorktree checks out the revision, with a detached HEAD, in a new linked working tree
+// at the directory, which must be absent or empty.
+func (r *Repo) AddWorktree(ctx context.Context, dir, rev string) error {
+	_, err := r.Run(ctx, "worktree", "add", "--detach", "--quiet", dir, rev)
+	return errors.WithStack(err)
+}
+
+// RemoveWorktree removes a linked working tree created by AddWorktree, including any changes made to it.
+func (r *Repo) RemoveWorktree(ctx context.Context, dir string) error {
+	_, err := r.Run(ctx, "worktree", "remove", "--force", dir)
+	return errors.WithStack(err)
+}
+
+// Apply applies the patch file, e.g. from `git diff` or `git format-patch`, to the working tree.
+//
+// The index is not updated. No file is changed if any hunk fails to apply.
+func (r *Repo) Apply(ctx context.Context, name string) error {
+	_, err := r.Run(ctx, "apply", "--whitespace=nowarn", name)
+	return errors.WithStack(err)
+}
+
+// Tag creates a lightweight tag of the revision.
+func (r *Repo) Tag(ctx context.Context, name, rev string) error {
+	_, err := r.Run(ctx, "tag", name, rev)
+	return errors.WithStack(err)
+}
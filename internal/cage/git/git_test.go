@@ -0,0 +1,171 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package git_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	cage_git "github.com/codeactual/transplant/internal/cage/git"
+	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
+)
+
+// newTestRepo returns a Repo in a new temporary directory which has a commit identity configured.
+func newTestRepo(t *testing.T) *cage_git.Repo {
+	dir, err := ioutil.TempDir("", "cage_git")
+	require.NoError(t, err)
+
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	repo := cage_git.NewRepo(dir)
+	ctx := context.Background()
+	_, err = repo.Run(ctx, "init", "--quiet")
+	require.NoError(t, err)
+	_, err = repo.Run(ctx, "config", "user.name", "Test")
+	require.NoError(t, err)
+	_, err = repo.Run(ctx, "config", "user.email", "test@example.com")
+	require.NoError(t, err)
+
+	return repo
+}
+
+func TestCommit(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	defer func() {
+		require.NoError(t, cage_file.RemoveAllSafer(repo.Dir))
+	}()
+
+	topLevel, err := repo.TopLevel(ctx)
+	require.NoError(t, err)
+	require.Exactly(t, repo.Dir, topLevel)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(repo.Dir, "a.txt"), []byte("a"), 0644))
+
+	status, err := repo.Status(ctx)
+	require.NoError(t, err)
+	require.Exactly(t, []string{"?? a.txt"}, status)
+
+	sha, err := repo.Commit(ctx, "add a")
+	require.NoError(t, err)
+	require.Len(t, sha, 40)
+
+	head, err := repo.RevParse(ctx, "HEAD")
+	require.NoError(t, err)
+	require.Exactly(t, sha, head)
+
+	subject, err := repo.Run(ctx, "log", "-1", "--format=%s")
+	require.NoError(t, err)
+	require.Exactly(t, "add a", subject)
+
+	status, err = repo.Status(ctx)
+	require.NoError(t, err)
+	require.Empty(t, status)
+
+	// No changes, no commit.
+	sha, err = repo.Commit(ctx, "empty")
+	require.NoError(t, err)
+	require.Empty(t, sha)
+
+	require.NoError(t, repo.Tag(ctx, "v1.0.0", "HEAD"))
+	tagged, err := repo.RevParse(ctx, "v1.0.0")
+	require.NoError(t, err)
+	require.Exactly(t, head, tagged)
+
+	_, err = repo.RevParse(ctx, "missing")
+	require.Error(t, err)
+}
+
+func TestStatusPaths(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	defer func() {
+		require.NoError(t, cage_file.RemoveAllSafer(repo.Dir))
+	}()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(repo.Dir, "a.txt"), []byte("a"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(repo.Dir, "b.txt"), []byte("b"), 0644))
+
+	status, err := repo.Status(ctx, "b.txt")
+	require.NoError(t, err)
+	require.Exactly(t, []string{"?? b.txt"}, status)
+
+	sha, err := repo.Commit(ctx, "add b", "b.txt")
+	require.NoError(t, err)
+	require.NotEmpty(t, sha)
+
+	status, err = repo.Status(ctx)
+	require.NoError(t, err)
+	require.Exactly(t, []string{"?? a.txt"}, status)
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	defer func() {
+		require.NoError(t, cage_file.RemoveAllSafer(repo.Dir))
+	}()
+
+	author := cage_git.Signature{
+		Name:  "Author",
+		Email: "author@example.com",
+		When:  time.Date(2019, 1, 2, 3, 4, 5, 0, time.FixedZone("", -7*60*60)),
+	}
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(repo.Dir, "a.txt"), []byte("a"), 0644))
+	first, err := repo.CommitAs(ctx, author, "add a\n\nbody")
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(repo.Dir, "b.txt"), []byte("b"), 0644))
+	second, err := repo.Commit(ctx, "add b")
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(repo.Dir, "a.txt"), []byte("a2"), 0644))
+	third, err := repo.Commit(ctx, "update a")
+	require.NoError(t, err)
+
+	info, err := repo.ReadCommit(ctx, first)
+	require.NoError(t, err)
+	require.Exactly(t, first, info.Sha)
+	require.Exactly(t, "Author", info.Author.Name)
+	require.Exactly(t, "author@example.com", info.Author.Email)
+	require.True(t, author.When.Equal(info.Author.When))
+	require.Exactly(t, "add a\n\nbody", info.Message)
+
+	shas, err := repo.RevList(ctx, first+"..HEAD")
+	require.NoError(t, err)
+	require.Exactly(t, []string{second, third}, shas)
+
+	shas, err = repo.RevList(ctx, "HEAD", "a.txt")
+	require.NoError(t, err)
+	require.Exactly(t, []string{first, third}, shas)
+
+	worktreeDir, err := ioutil.TempDir("", "cage_git_worktree")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, cage_file.RemoveAllSafer(worktreeDir))
+	}()
+
+	require.NoError(t, repo.AddWorktree(ctx, worktreeDir, first))
+	a, err := ioutil.ReadFile(filepath.Join(worktreeDir, "a.txt"))
+	require.NoError(t, err)
+	require.Exactly(t, "a", string(a))
+	exists, _, err := cage_file.Exists(filepath.Join(worktreeDir, "b.txt"))
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, repo.RemoveWorktree(ctx, worktreeDir))
+	exists, _, err = cage_file.Exists(worktreeDir)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"go/ast"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// CgoImportPath is the pseudo-package imported by files which use cgo.
+	CgoImportPath = "C"
+
+	cgoExportDirective = "//export "
+
+	cgoGeneratedComment = "// Code generated by cmd/cgo; DO NOT EDIT."
+
+	lineDirective = "//line "
+)
+
+// IsCgoExport returns true if the function is marked by a cgo //export directive, i.e. callable from C code.
+func IsCgoExport(fn *ast.FuncDecl) bool {
+	if fn.Recv != nil || fn.Doc == nil {
+		return false
+	}
+	for _, c := range fn.Doc.List {
+		if strings.HasPrefix(c.Text, cgoExportDirective) {
+			return true
+		}
+	}
+	return false
+}
+
+// CgoSourceFilename returns the absolute path of the Go file from which cmd/cgo generated the source,
+// e.g. a x/tools/go/packages.Package.CompiledGoFiles element, based on the //line directive which
+// precedes the package clause.
+//
+// It returns an empty string if the source was not generated from a Go file by cmd/cgo.
+func CgoSourceFilename(src []byte) string {
+	var generated bool
+
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == cgoGeneratedComment {
+			generated = true
+			continue
+		}
+		if !generated || !strings.HasPrefix(line, lineDirective) {
+			if strings.HasPrefix(line, "package ") {
+				break
+			}
+			continue
+		}
+
+		// Trim the ":line" or ":line:col" suffix.
+		name := strings.TrimPrefix(line, lineDirective)
+		for n := 0; n < 2; n++ {
+			i := strings.LastIndexByte(name, ':')
+			if i == -1 {
+				break
+			}
+			if _, err := strconv.Atoi(name[i+1:]); err != nil {
+				break
+			}
+			name = name[:i]
+		}
+
+		if filepath.IsAbs(name) && strings.HasSuffix(name, ".go") {
+			return name
+		}
+		return ""
+	}
+
+	return ""
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package build_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cage_build "github.com/codeactual/transplant/internal/cage/go/build"
+)
+
+func TestIsCgoExport(t *testing.T) {
+	src := `package p
+
+import "C"
+
+//export Exported
+func Exported() {}
+
+// Documented is not exported.
+func Documented() {}
+
+func Undocumented() {}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "p.go", src, parser.ParseComments)
+	require.NoError(t, err)
+
+	exported := make(map[string]bool)
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			exported[fn.Name.Name] = cage_build.IsCgoExport(fn)
+		}
+	}
+	require.Exactly(t, map[string]bool{"Exported": true, "Documented": false, "Undocumented": false}, exported)
+}
+
+func TestCgoSourceFilename(t *testing.T) {
+	generated := "// Code generated by cmd/cgo; DO NOT EDIT.\n\n//line /path/to/p/p.go:1:1\npackage p\n"
+	require.Exactly(t, "/path/to/p/p.go", cage_build.CgoSourceFilename([]byte(generated)))
+
+	types := "// Code generated by cmd/cgo; DO NOT EDIT.\n\npackage p\n\n//line /path/to/p/p.go:5:1\nvar _ = 1\n"
+	require.Exactly(t, "", cage_build.CgoSourceFilename([]byte(types)))
+
+	handwritten := "//line /path/to/p/p.go:1:1\npackage p\n"
+	require.Exactly(t, "", cage_build.CgoSourceFilename([]byte(handwritten)))
+}
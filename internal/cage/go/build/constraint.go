@@ -0,0 +1,152 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package build
+
+import (
+	"bufio"
+	"go/build/constraint"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxRequireTagEval limits the number of other tags in a constraint which RequiresTag will enumerate
+// combinations of. Beyond it, the constraint is assumed to not require the tags.
+const maxRequireTagEval = 16
+
+// ReadConstraint returns the build constraint found in the header of the named Go file.
+//
+// A //go:build line takes precedence over // +build lines, which are AND-ed together.
+// It returns nil if the file has no constraint.
+func ReadConstraint(name string) (expr constraint.Expr, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file [%s]", name)
+	}
+	defer f.Close()
+
+	var goBuild constraint.Expr
+	var plusBuild []constraint.Expr
+	var inBlockComment bool
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if inBlockComment {
+			if strings.Contains(line, "*/") {
+				inBlockComment = false
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "/*") {
+			inBlockComment = !strings.Contains(line, "*/")
+			continue
+		}
+
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "//") { // end of the header, e.g. the package clause
+			break
+		}
+
+		if constraint.IsGoBuild(line) || constraint.IsPlusBuild(line) {
+			lineExpr, parseErr := constraint.Parse(line)
+			if parseErr != nil {
+				return nil, errors.Wrapf(parseErr, "failed to parse build constraint [%s] in file [%s]", line, name)
+			}
+			if constraint.IsGoBuild(line) {
+				goBuild = lineExpr
+			} else {
+				plusBuild = append(plusBuild, lineExpr)
+			}
+		}
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return nil, errors.Wrapf(scanErr, "failed to read file [%s]", name)
+	}
+
+	if goBuild != nil {
+		return goBuild, nil
+	}
+	for _, e := range plusBuild {
+		if expr == nil {
+			expr = e
+		} else {
+			expr = &constraint.AndExpr{X: expr, Y: e}
+		}
+	}
+	return expr, nil
+}
+
+// RequiresTag returns true if the constraint cannot be satisfied unless at least one of the tags is set.
+//
+// All combinations of the constraint's other tags are evaluated, so a constraint such as "linux && internal"
+// requires "internal" but "linux || internal" does not.
+func RequiresTag(expr constraint.Expr, tags ...string) bool {
+	if expr == nil || len(tags) == 0 {
+		return false
+	}
+
+	required := make(map[string]bool)
+	for _, t := range tags {
+		required[t] = true
+	}
+
+	var others []string
+	seen := make(map[string]bool)
+	var walk func(constraint.Expr)
+	walk = func(e constraint.Expr) {
+		switch x := e.(type) {
+		case *constraint.TagExpr:
+			if !required[x.Tag] && !seen[x.Tag] {
+				seen[x.Tag] = true
+				others = append(others, x.Tag)
+			}
+		case *constraint.NotExpr:
+			walk(x.X)
+		case *constraint.AndExpr:
+			walk(x.X)
+			walk(x.Y)
+		case *constraint.OrExpr:
+			walk(x.X)
+			walk(x.Y)
+		}
+	}
+	walk(expr)
+
+	if len(others) > maxRequireTagEval {
+		return false
+	}
+
+	for combo := 0; combo < 1<<uint(len(others)); combo++ {
+		set := make(map[string]bool)
+		for n, t := range others {
+			set[t] = combo&(1<<uint(n)) != 0
+		}
+		if expr.Eval(func(tag string) bool { return set[tag] }) { // required tags are always unset
+			return false
+		}
+	}
+
+	return true
+}
+
+// FileRequiresTag returns true if the named Go file's build constraint cannot be satisfied unless
+// at least one of the tags is set.
+func FileRequiresTag(name string, tags ...string) (bool, error) {
+	if len(tags) == 0 {
+		return false, nil
+	}
+	expr, err := ReadConstraint(name)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return RequiresTag(expr, tags...), nil
+}
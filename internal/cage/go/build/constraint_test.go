@@ -0,0 +1,69 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package build_test
+
+import (
+	"go/build/constraint"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cage_build "github.com/codeactual/transplant/internal/cage/go/build"
+	cage_file "github.com/codeactual/transplant/internal/cage/testkit/os/file"
+)
+
+func TestRequiresTag(t *testing.T) {
+	cases := []struct {
+		line     string
+		tags     []string
+		expected bool
+	}{
+		{line: "//go:build internal", tags: []string{"internal"}, expected: true},
+		{line: "//go:build internal", tags: []string{"corp"}, expected: false},
+		{line: "//go:build !internal", tags: []string{"internal"}, expected: false},
+		{line: "//go:build linux && internal", tags: []string{"internal"}, expected: true},
+		{line: "//go:build linux || internal", tags: []string{"internal"}, expected: false},
+		{line: "//go:build internal || corp", tags: []string{"internal"}, expected: false},
+		{line: "//go:build internal || corp", tags: []string{"internal", "corp"}, expected: true},
+		{line: "//go:build (internal && linux) || (corp && !linux)", tags: []string{"internal", "corp"}, expected: true},
+		{line: "// +build linux,internal", tags: []string{"internal"}, expected: true},
+	}
+
+	for _, c := range cases {
+		expr, err := constraint.Parse(c.line)
+		require.NoError(t, err)
+		require.Exactly(t, c.expected, cage_build.RequiresTag(expr, c.tags...), c.line)
+	}
+
+	require.False(t, cage_build.RequiresTag(nil, "internal"))
+}
+
+func TestFileRequiresTag(t *testing.T) {
+	_, goBuild := cage_file.FixturePath(t, "go_build.go")
+	_, plusBuild := cage_file.FixturePath(t, "plus_build.go")
+	_, none := cage_file.FixturePath(t, "none.go")
+
+	requires, err := cage_build.FileRequiresTag(goBuild, "internal")
+	require.NoError(t, err)
+	require.True(t, requires)
+
+	requires, err = cage_build.FileRequiresTag(goBuild, "corp")
+	require.NoError(t, err)
+	require.False(t, requires)
+
+	requires, err = cage_build.FileRequiresTag(plusBuild, "corp")
+	require.NoError(t, err)
+	require.True(t, requires)
+
+	requires, err = cage_build.FileRequiresTag(plusBuild, "linux")
+	require.NoError(t, err)
+	require.False(t, requires)
+
+	requires, err = cage_build.FileRequiresTag(none, "internal")
+	require.NoError(t, err)
+	require.False(t, requires)
+}
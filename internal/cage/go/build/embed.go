@@ -0,0 +1,165 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package build
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	embedDirective = "//go:embed"
+
+	// embedAllPrefix disables the omission of '.' and '_' prefixed files from directory matches.
+	embedAllPrefix = "all:"
+)
+
+// ReadEmbedPatterns returns the patterns of all //go:embed directives in the named Go file.
+func ReadEmbedPatterns(name string) (patterns []string, err error) {
+	// Parse the whole file because directives are attached to var declarations after the imports.
+	f, err := parser.ParseFile(token.NewFileSet(), name, nil, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse file [%s]", name)
+	}
+
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			if !strings.HasPrefix(c.Text, embedDirective) {
+				continue
+			}
+			args := strings.TrimPrefix(c.Text, embedDirective)
+			if args != "" && !unicode.IsSpace(rune(args[0])) { // e.g. "//go:embedded"
+				continue
+			}
+
+			linePatterns, parseErr := parseEmbedArgs(args)
+			if parseErr != nil {
+				return nil, errors.Wrapf(parseErr, "failed to parse directive [%s] in file [%s]", c.Text, name)
+			}
+			patterns = append(patterns, linePatterns...)
+		}
+	}
+
+	return patterns, nil
+}
+
+// parseEmbedArgs splits the space-separated, and optionally quoted, patterns of a //go:embed directive.
+func parseEmbedArgs(args string) (patterns []string, err error) {
+	args = strings.TrimSpace(args)
+
+	for args != "" {
+		var pattern string
+
+		switch args[0] {
+		case '"', '`':
+			end := strings.IndexByte(args[1:], args[0])
+			if end == -1 {
+				return nil, errors.Errorf("unterminated quoted pattern in [%s]", args)
+			}
+			quoted := args[:end+2]
+			unquoted, unquoteErr := strconv.Unquote(quoted)
+			if unquoteErr != nil {
+				return nil, errors.Wrapf(unquoteErr, "invalid quoted pattern [%s]", quoted)
+			}
+			pattern, args = unquoted, args[end+2:]
+		default:
+			end := strings.IndexFunc(args, unicode.IsSpace)
+			if end == -1 {
+				end = len(args)
+			}
+			pattern, args = args[:end], args[end:]
+		}
+
+		patterns = append(patterns, pattern)
+		args = strings.TrimLeftFunc(args, unicode.IsSpace)
+	}
+
+	return patterns, nil
+}
+
+// MatchEmbedPattern returns the absolute paths of files selected by a //go:embed pattern
+// declared in a file located in the package directory.
+//
+// It follows the rules of the embed package: matched directories are walked recursively and their
+// '.' and '_' prefixed files are omitted unless the pattern has an "all:" prefix.
+func MatchEmbedPattern(dir, pattern string) (files []string, err error) {
+	all := strings.HasPrefix(pattern, embedAllPrefix)
+	pattern = strings.TrimPrefix(pattern, embedAllPrefix)
+
+	if pattern == "" || strings.HasPrefix(pattern, "/") {
+		return nil, errors.Errorf("invalid pattern [%s]", pattern)
+	}
+	for _, elem := range strings.Split(pattern, "/") {
+		if elem == "." || elem == ".." || elem == "" {
+			return nil, errors.Errorf("invalid pattern [%s]", pattern)
+		}
+	}
+	if _, err = path.Match(pattern, ""); err != nil {
+		return nil, errors.Wrapf(err, "invalid pattern [%s]", pattern)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to match pattern [%s] in dir [%s]", pattern, dir)
+	}
+
+	for _, m := range matches {
+		fi, statErr := os.Stat(m)
+		if statErr != nil {
+			return nil, errors.Wrapf(statErr, "failed to stat [%s]", m)
+		}
+
+		if !fi.IsDir() {
+			files = append(files, m)
+			continue
+		}
+
+		walkErr := filepath.Walk(m, func(p string, info os.FileInfo, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if p != m {
+				base := info.Name()
+				if !all && (strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_")) {
+					if info.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+			}
+			if info.IsDir() {
+				if p != m {
+					// Nested modules are not embedded.
+					if _, modErr := os.Stat(filepath.Join(p, "go.mod")); modErr == nil {
+						return filepath.SkipDir
+					}
+				}
+				return nil
+			}
+			if info.Mode().IsRegular() {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if walkErr != nil {
+			return nil, errors.Wrapf(walkErr, "failed to walk dir [%s]", m)
+		}
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
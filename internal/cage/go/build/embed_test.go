@@ -0,0 +1,71 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package build_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cage_build "github.com/codeactual/transplant/internal/cage/go/build"
+	cage_file "github.com/codeactual/transplant/internal/cage/testkit/os/file"
+)
+
+func TestReadEmbedPatterns(t *testing.T) {
+	_, name := cage_file.FixturePath(t, "embed", "embed.go")
+
+	patterns, err := cage_build.ReadEmbedPatterns(name)
+	require.NoError(t, err)
+	require.Exactly(t, []string{"templates", "static/*.txt", "quoted name.txt", "all:static"}, patterns)
+}
+
+func TestMatchEmbedPattern(t *testing.T) {
+	_, dir := cage_file.FixturePath(t, "embed")
+
+	files, err := cage_build.MatchEmbedPattern(dir, "templates")
+	require.NoError(t, err)
+	require.Exactly(
+		t,
+		[]string{
+			filepath.Join(dir, "templates", "a.tmpl"),
+			filepath.Join(dir, "templates", "sub", "b.tmpl"),
+		},
+		files,
+	)
+
+	files, err = cage_build.MatchEmbedPattern(dir, "all:templates")
+	require.NoError(t, err)
+	require.Exactly(
+		t,
+		[]string{
+			filepath.Join(dir, "templates", ".hidden"),
+			filepath.Join(dir, "templates", "_skip.tmpl"),
+			filepath.Join(dir, "templates", "a.tmpl"),
+			filepath.Join(dir, "templates", "sub", "b.tmpl"),
+		},
+		files,
+	)
+
+	files, err = cage_build.MatchEmbedPattern(dir, "static/*.txt")
+	require.NoError(t, err)
+	require.Exactly(
+		t,
+		[]string{
+			filepath.Join(dir, "static", ".y.txt"),
+			filepath.Join(dir, "static", "x.txt"),
+		},
+		files,
+	)
+
+	files, err = cage_build.MatchEmbedPattern(dir, "missing/*")
+	require.NoError(t, err)
+	require.Empty(t, files)
+
+	_, err = cage_build.MatchEmbedPattern(dir, "../embed")
+	require.Error(t, err)
+}
@@ -0,0 +1,12 @@
+package embed
+
+import _ "embed"
+
+//go:embed templates
+var templates string
+
+//go:embed static/*.txt "quoted name.txt" `all:static`
+var static string
+
+//go:embedded not a directive
+var other string
@@ -0,0 +1,6 @@
+// Copyright header
+
+//go:build linux && internal
+// +build linux,internal
+
+package fixture
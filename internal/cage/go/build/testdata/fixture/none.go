@@ -0,0 +1,8 @@
+/*
+Package fixture has no build constraint.
+
+// +build internal
+*/
+package fixture
+
+// +build internal
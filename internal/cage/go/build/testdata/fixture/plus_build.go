@@ -0,0 +1,4 @@
+// +build linux darwin
+// +build corp
+
+package fixture
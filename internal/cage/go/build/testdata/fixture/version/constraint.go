@@ -0,0 +1,14 @@
+//go:build linux && go1.21
+
+package version
+
+func clamp[T int | float64](v, lo, hi T) T {
+	return max(lo, min(v, hi))
+}
+
+func sum() (n int) {
+	for i := range 10 {
+		n += i
+	}
+	return n
+}
@@ -0,0 +1,34 @@
+package version
+
+import (
+	"errors"
+	"strings"
+)
+
+type Pair[K comparable, V any] struct {
+	Key K
+	Val V
+}
+
+type limits struct {
+	max int
+}
+
+func Min(a, b int) int {
+	return min(a, b)
+}
+
+func Mask() int {
+	l := limits{max: 0b1010}
+	return l.max + 1_000
+}
+
+func Before(s string) (string, error) {
+	before, _, found := strings.Cut(s, ",")
+	if !found {
+		return "", errors.New("missing comma")
+	}
+	for range 3 {
+	}
+	return before, nil
+}
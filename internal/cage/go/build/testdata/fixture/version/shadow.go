@@ -0,0 +1,20 @@
+package version
+
+import (
+	strings "bytes"
+)
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func Split(b []byte) []byte {
+	return strings.TrimSpace(b)
+}
+
+func Larger() int {
+	return max(1, 2)
+}
@@ -0,0 +1,319 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package build
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// goVersion matches language versions, e.g. "1.13", and release versions, e.g. "1.21.0" or "1.21rc1".
+	goVersion *regexp.Regexp
+)
+
+func init() {
+	goVersion = regexp.MustCompile(`^1\.(\d+)(?:\.\d+|(?:rc|beta)\d+)?$`)
+}
+
+// stdlibPkgVersion holds the language versions which introduced standard library packages.
+var stdlibPkgVersion = map[string]string{
+	"cmp":            "1.21",
+	"crypto/ecdh":    "1.20",
+	"embed":          "1.16",
+	"io/fs":          "1.16",
+	"iter":           "1.23",
+	"log/slog":       "1.21",
+	"maps":           "1.21",
+	"math/rand/v2":   "1.22",
+	"net/netip":      "1.18",
+	"slices":         "1.21",
+	"structs":        "1.23",
+	"testing/fstest": "1.16",
+	"unique":         "1.23",
+}
+
+// stdlibAPIVersion holds the language versions which introduced package-level identifiers of standard
+// library packages which existed earlier. Keys use "<import path>.<identifier>" format.
+//
+// It is not exhaustive and covers identifiers which commonly replace older idioms.
+var stdlibAPIVersion = map[string]string{
+	"bytes.Clone":                    "1.20",
+	"bytes.Cut":                      "1.18",
+	"context.AfterFunc":              "1.21",
+	"context.Cause":                  "1.20",
+	"context.WithCancelCause":        "1.20",
+	"context.WithoutCancel":          "1.21",
+	"errors.As":                      "1.13",
+	"errors.ErrUnsupported":          "1.21",
+	"errors.Is":                      "1.13",
+	"errors.Join":                    "1.20",
+	"errors.Unwrap":                  "1.13",
+	"io.Discard":                     "1.16",
+	"io.NopCloser":                   "1.16",
+	"io.ReadAll":                     "1.16",
+	"net/http.NewRequestWithContext": "1.13",
+	"os.CopyFS":                      "1.23",
+	"os.CreateTemp":                  "1.16",
+	"os.DirFS":                       "1.16",
+	"os.MkdirTemp":                   "1.16",
+	"os.ReadDir":                     "1.16",
+	"os.ReadFile":                    "1.16",
+	"os.WriteFile":                   "1.16",
+	"os/signal.NotifyContext":        "1.16",
+	"path/filepath.WalkDir":          "1.16",
+	"strings.Clone":                  "1.18",
+	"strings.Cut":                    "1.18",
+	"strings.CutPrefix":              "1.20",
+	"strings.CutSuffix":              "1.20",
+	"sync.OnceFunc":                  "1.21",
+	"sync.OnceValue":                 "1.21",
+	"sync.OnceValues":                "1.21",
+	"testing.Testing":                "1.21",
+	"time.DateOnly":                  "1.20",
+	"time.DateTime":                  "1.20",
+	"time.TimeOnly":                  "1.20",
+	"time.UnixMicro":                 "1.17",
+	"time.UnixMilli":                 "1.17",
+	"unsafe.Add":                     "1.17",
+	"unsafe.Slice":                   "1.17",
+	"unsafe.SliceData":               "1.20",
+	"unsafe.String":                  "1.20",
+	"unsafe.StringData":              "1.20",
+}
+
+// universeVersion holds the language versions which introduced predeclared identifiers.
+var universeVersion = map[string]string{
+	"any":        "1.18",
+	"clear":      "1.21",
+	"comparable": "1.18",
+	"max":        "1.21",
+	"min":        "1.21",
+}
+
+// VersionRequirement describes a use of a language feature or standard library API which requires
+// a minimum Go version.
+type VersionRequirement struct {
+	// Version is the minimum language version, e.g. "1.18".
+	Version string
+
+	// Feature describes the language feature or identifies the API, e.g. "type parameters" or "strings.Cut".
+	Feature string
+
+	// Pos locates the first use in the file.
+	Pos token.Position
+}
+
+func (r VersionRequirement) String() string {
+	return fmt.Sprintf("%s: %s requires go %s", r.Pos, r.Feature, r.Version)
+}
+
+// ReadVersionRequirements returns the language features and standard library APIs, used by the named
+// Go file, which require a Go version newer than the minimum version.
+//
+// Each feature is reported once per file, at its first use, and requirements are returned in position order.
+//
+// If the file's build constraint requires a newer Go version than the minimum, e.g. "//go:build go1.21",
+// that version is the minimum instead because older toolchains do not build the file.
+//
+// Detection is syntactic: it does not cover features which require type information, e.g. ranging over
+// a function or an int-typed variable, and predeclared identifiers are assumed to be unshadowed if
+// the file does not declare them.
+func ReadVersionRequirements(name, min string) (reqs []VersionRequirement, err error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse file [%s]", name)
+	}
+
+	expr, err := ReadConstraint(name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if expr != nil {
+		if v := strings.TrimPrefix(constraint.GoVersion(expr), "go"); CompareGoVersion(v, min) > 0 {
+			min = v
+		}
+	}
+
+	found := make(map[string]bool)
+	add := func(pos token.Pos, version, feature string) {
+		if found[feature] || CompareGoVersion(version, min) <= 0 {
+			return
+		}
+		found[feature] = true
+		reqs = append(reqs, VersionRequirement{Version: version, Feature: feature, Pos: fset.Position(pos)})
+	}
+
+	// Index the file's import names to resolve selector expressions.
+	importNames := make(map[string]string)
+	for _, imp := range f.Imports {
+		importPath, unquoteErr := strconv.Unquote(imp.Path.Value)
+		if unquoteErr != nil {
+			return nil, errors.Wrapf(unquoteErr, "failed to unquote import path [%s] in [%s]", imp.Path.Value, name)
+		}
+		if v, ok := stdlibPkgVersion[importPath]; ok {
+			add(imp.Pos(), v, "package "+importPath)
+		}
+		if imp.Name != nil {
+			if imp.Name.Name != "_" && imp.Name.Name != "." {
+				importNames[imp.Name.Name] = importPath
+			}
+			continue
+		}
+		importNames[path.Base(importPath)] = importPath
+	}
+
+	// Predeclared identifiers which the file redeclares are not the predeclared ones.
+	declared := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.TypeSpec:
+			declared[x.Name.Name] = true
+		case *ast.ValueSpec:
+			for _, id := range x.Names {
+				declared[id.Name] = true
+			}
+		case *ast.FuncDecl:
+			if x.Recv == nil {
+				declared[x.Name.Name] = true
+			}
+		case *ast.Field:
+			for _, id := range x.Names {
+				declared[id.Name] = true
+			}
+		case *ast.AssignStmt:
+			if x.Tok == token.DEFINE {
+				for _, lhs := range x.Lhs {
+					if id, ok := lhs.(*ast.Ident); ok {
+						declared[id.Name] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	var inspect func(n ast.Node) bool
+	inspect = func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.BasicLit:
+			if feature := numberLitFeature(x); feature != "" {
+				add(x.Pos(), "1.13", feature)
+			}
+		case *ast.FuncType:
+			if x.TypeParams != nil && len(x.TypeParams.List) > 0 {
+				add(x.TypeParams.Pos(), "1.18", "type parameters")
+			}
+		case *ast.TypeSpec:
+			if x.TypeParams != nil && len(x.TypeParams.List) > 0 {
+				if x.Assign.IsValid() {
+					add(x.Pos(), "1.24", "generic type aliases")
+				}
+				add(x.TypeParams.Pos(), "1.18", "type parameters")
+			}
+		case *ast.IndexListExpr:
+			add(x.Pos(), "1.18", "type parameters")
+		case *ast.RangeStmt:
+			if lit, ok := x.X.(*ast.BasicLit); ok && lit.Kind == token.INT {
+				add(x.Pos(), "1.22", "range over int")
+			}
+		case *ast.Ident:
+			if v, ok := universeVersion[x.Name]; ok && !declared[x.Name] {
+				add(x.Pos(), v, "predeclared "+x.Name)
+			}
+		case *ast.SelectorExpr:
+			// Skip the selected identifier, e.g. a field named "max".
+			pkgId, ok := x.X.(*ast.Ident)
+			if !ok {
+				ast.Inspect(x.X, inspect)
+				return false
+			}
+			importPath, ok := importNames[pkgId.Name]
+			if !ok || declared[pkgId.Name] {
+				return false
+			}
+			api := importPath + "." + x.Sel.Name
+			if v, ok := stdlibAPIVersion[api]; ok {
+				add(x.Pos(), v, api)
+			}
+			return false
+		}
+		return true
+	}
+	ast.Inspect(f, inspect)
+
+	sort.SliceStable(reqs, func(i, j int) bool {
+		return reqs[i].Pos.Offset < reqs[j].Pos.Offset
+	})
+
+	return reqs, nil
+}
+
+// numberLitFeature returns a description of the Go 1.13 number literal syntax used by the literal,
+// or an empty string if it uses none.
+func numberLitFeature(lit *ast.BasicLit) string {
+	if lit.Kind != token.INT && lit.Kind != token.FLOAT && lit.Kind != token.IMAG {
+		return ""
+	}
+	v := strings.ToLower(lit.Value)
+	switch {
+	case strings.HasPrefix(v, "0b"):
+		return "binary integer literals"
+	case strings.HasPrefix(v, "0o"):
+		return "0o octal integer literals"
+	case strings.HasPrefix(v, "0x") && lit.Kind != token.INT:
+		return "hexadecimal floating-point literals"
+	case strings.Contains(v, "_"):
+		return "digit separators in number literals"
+	}
+	return ""
+}
+
+// IsGoVersion returns true if the string is a language version, e.g. "1.13", or release version, e.g. "1.21.0".
+func IsGoVersion(v string) bool {
+	return goVersion.MatchString(v)
+}
+
+// CompareGoVersion returns -1, 0, or 1 if the language version of the first Go version is older, the same,
+// or newer than the second's.
+//
+// Patch and pre-release suffixes are ignored, e.g. "1.21.3" and "1.21" are the same language version.
+// An invalid version is older than all valid versions.
+func CompareGoVersion(a, b string) int {
+	aMinor, bMinor := goMinorVersion(a), goMinorVersion(b)
+	switch {
+	case aMinor < bMinor:
+		return -1
+	case aMinor > bMinor:
+		return 1
+	}
+	return 0
+}
+
+// goMinorVersion returns the minor version number of a Go version, or -1 if it is invalid.
+func goMinorVersion(v string) int {
+	matches := goVersion.FindStringSubmatch(v)
+	if matches == nil {
+		return -1
+	}
+	minor, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return -1
+	}
+	return minor
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package build_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cage_build "github.com/codeactual/transplant/internal/cage/go/build"
+	cage_file "github.com/codeactual/transplant/internal/cage/testkit/os/file"
+)
+
+func versionFeatures(t *testing.T, name, min string) (features []string) {
+	reqs, err := cage_build.ReadVersionRequirements(name, min)
+	require.NoError(t, err)
+	for _, r := range reqs {
+		features = append(features, r.String()[len(name)+1:])
+	}
+	return features
+}
+
+func TestReadVersionRequirements(t *testing.T) {
+	_, name := cage_file.FixturePath(t, "version", "features.go")
+
+	require.Exactly(
+		t,
+		[]string{
+			"8:10: type parameters requires go 1.18",
+			"8:13: predeclared comparable requires go 1.18",
+			"8:27: predeclared any requires go 1.18",
+			"18:9: predeclared min requires go 1.21",
+			"27:22: strings.Cut requires go 1.18",
+			"31:2: range over int requires go 1.22",
+		},
+		versionFeatures(t, name, "1.13"),
+	)
+
+	require.Exactly(
+		t,
+		[]string{
+			"18:9: predeclared min requires go 1.21",
+			"31:2: range over int requires go 1.22",
+		},
+		versionFeatures(t, name, "1.18"),
+	)
+
+	require.Exactly(
+		t,
+		[]string{
+			"8:10: type parameters requires go 1.18",
+			"8:13: predeclared comparable requires go 1.18",
+			"8:27: predeclared any requires go 1.18",
+			"18:9: predeclared min requires go 1.21",
+			"22:19: binary integer literals requires go 1.13",
+			"23:17: digit separators in number literals requires go 1.13",
+			"27:22: strings.Cut requires go 1.18",
+			"31:2: range over int requires go 1.22",
+		},
+		versionFeatures(t, name, "1.12"),
+	)
+
+	require.Empty(t, versionFeatures(t, name, "1.24.1"))
+}
+
+func TestReadVersionRequirementsShadow(t *testing.T) {
+	_, name := cage_file.FixturePath(t, "version", "shadow.go")
+
+	require.Empty(t, versionFeatures(t, name, "1.13"))
+}
+
+func TestReadVersionRequirementsConstraint(t *testing.T) {
+	_, name := cage_file.FixturePath(t, "version", "constraint.go")
+
+	// The go1.21 constraint raises the minimum version from 1.13.
+	require.Exactly(t, []string{"10:2: range over int requires go 1.22"}, versionFeatures(t, name, "1.13"))
+	require.Exactly(t, []string{"10:2: range over int requires go 1.22"}, versionFeatures(t, name, "1.18"))
+	require.Empty(t, versionFeatures(t, name, "1.22"))
+}
+
+func TestCompareGoVersion(t *testing.T) {
+	require.Exactly(t, 0, cage_build.CompareGoVersion("1.21", "1.21.3"))
+	require.Exactly(t, -1, cage_build.CompareGoVersion("1.9", "1.13"))
+	require.Exactly(t, 1, cage_build.CompareGoVersion("1.22rc1", "1.21"))
+	require.Exactly(t, -1, cage_build.CompareGoVersion("go1.21", "1.13"))
+	require.True(t, cage_build.IsGoVersion("1.21.0"))
+	require.False(t, cage_build.IsGoVersion("1"))
+}
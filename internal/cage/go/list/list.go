@@ -93,6 +93,9 @@ type Query struct {
 
 	// modules holds the value of an "-m" option of "go list".
 	modules string
+
+	// env holds "key=value" pairs which override the current process's environment.
+	env []string
 }
 
 // NewQuery returns an initialized Query.
@@ -109,6 +112,12 @@ func (q *Query) Modules(optVal string) *Query {
 	return q
 }
 
+// Env selects "key=value" pairs which override the current process's environment.
+func (q *Query) Env(env ...string) *Query {
+	q.env = append(q.env, env...)
+	return q
+}
+
 // AllModules ia an alias for an "-m all" option of "go list".
 func (q *Query) AllModules() *Query {
 	return q.Modules("all")
@@ -124,7 +133,7 @@ func (q *Query) Run(ctx context.Context) (mods *ModuleSet, err error) {
 	}
 
 	cmd := exec.CommandContext(ctx, "go", args...)
-	cmd.Env = append(os.Environ(), "GO111MODULE=on")
+	cmd.Env = append(append(os.Environ(), "GO111MODULE=on"), q.env...)
 	cmd.Dir = q.dir
 	stdout, stderr, _, err := q.executor.Buffered(ctx, cmd)
 
@@ -142,7 +151,89 @@ func (q *Query) Run(ctx context.Context) (mods *ModuleSet, err error) {
 
 	for _, line := range lines {
 		parts := strings.Split(string(line), " ")
-		if len(parts) == 2 {
+
+		// Select the required version of replaced modules, e.g. "<path> <version> => <new path> [new version]".
+		// Main/workspace modules are omitted because they have no version.
+		if len(parts) == 2 || (len(parts) > 3 && parts[2] == "=>") {
+			mods.Add(Module{Path: parts[0], Version: parts[1]})
+		}
+	}
+
+	return mods, nil
+}
+
+// ModuleDirs returns the directories which hold the files of the selected modules, e.g. in the module cache,
+// indexed by module path.
+//
+// Each query is the path of a module in the build list of the module in dir, or a "<path>@<version>" query.
+// If a module is replaced, the replacement's directory is returned. Modules which are not in the module cache,
+// or whose queries fail to resolve, e.g. due to GOPROXY=off, are omitted.
+func ModuleDirs(ctx context.Context, executor cage_exec.Executor, dir string, env []string, queries ...string) (dirs map[string]string, err error) {
+	dirs = make(map[string]string)
+	if len(queries) == 0 {
+		return dirs, nil
+	}
+
+	// Use -e so a failed query is reported in its own output instead of failing the others.
+	args := append([]string{"list", "-m", "-e", "-f", "{{.Path}} {{if .Replace}}{{.Replace.Dir}}{{else}}{{.Dir}}{{end}}"}, queries...)
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Env = append(append(os.Environ(), "GO111MODULE=on"), env...)
+	cmd.Dir = dir
+	stdout, stderr, _, err := executor.Buffered(ctx, cmd)
+
+	ctxErr := ctx.Err()
+	if ctxErr != nil {
+		err = ctxErr
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get dirs of modules [%s]: %s", strings.Join(queries, " "), stderr.String())
+	}
+
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			continue
+		}
+		dirs[parts[0]] = parts[1]
+	}
+
+	return dirs, nil
+}
+
+// PackageModules returns the modules which provide the selected packages, or any package they transitively
+// import, as resolved by the build list of the module in dir.
+//
+// Standard library packages and packages of the main/workspace modules are omitted. Each Module.Version is the
+// version selected by the build list, i.e. the required version of replaced modules.
+func PackageModules(ctx context.Context, executor cage_exec.Executor, dir string, env []string, importPaths ...string) (mods *ModuleSet, err error) {
+	mods = NewModuleSet()
+	if len(importPaths) == 0 {
+		return mods, nil
+	}
+
+	args := append([]string{"list", "-deps", "-f", "{{with .Module}}{{.Path}} {{.Version}}{{end}}"}, importPaths...)
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Env = append(append(os.Environ(), "GO111MODULE=on"), env...)
+	cmd.Dir = dir
+	stdout, stderr, _, err := executor.Buffered(ctx, cmd)
+
+	ctxErr := ctx.Err()
+	if ctxErr != nil {
+		err = ctxErr
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get modules of packages [%s]: %s", strings.Join(importPaths, " "), stderr.String())
+	}
+
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		parts := strings.Split(strings.TrimSpace(line), " ")
+
+		// Main/workspace modules are omitted because they have no version.
+		if len(parts) == 2 && parts[1] != "" {
 			mods.Add(Module{Path: parts[0], Version: parts[1]})
 		}
 	}
@@ -129,6 +129,43 @@ func (s *ListSuite) TestAllModules() {
 	)
 }
 
+func (s *ListSuite) TestModuleDirs() {
+	t := s.T()
+
+	_, dir := cage_testkit_file.FixturePath(t, "all_mods")
+	dirs, err := cage_go_list.ModuleDirs(s.ctx, s.executor, dir, nil, "github.com/pkg/errors", "github.com/pkg/errors@v0.8.1")
+	require.NoError(t, err)
+	require.Len(t, dirs, 1)
+	require.True(t, strings.HasSuffix(dirs["github.com/pkg/errors"], filepath.Join("github.com", "pkg", "errors@v0.8.1")))
+
+	// Modules which cannot be resolved from the module cache are omitted without failing the others.
+	dirs, err = cage_go_list.ModuleDirs(s.ctx, s.executor, dir, []string{"GOPROXY=off"}, "domain.com/path/to/uncached@v1.0.0", "github.com/pkg/errors@v0.8.1")
+	require.NoError(t, err)
+	require.Len(t, dirs, 1)
+	require.True(t, strings.HasSuffix(dirs["github.com/pkg/errors"], filepath.Join("github.com", "pkg", "errors@v0.8.1")))
+}
+
+func (s *ListSuite) TestPackageModules() {
+	t := s.T()
+
+	_, dir := cage_testkit_file.FixturePath(t, "all_mods")
+	mods, err := cage_go_list.PackageModules(s.ctx, s.executor, dir, nil, "github.com/pkg/errors", "fmt")
+	require.NoError(t, err)
+	require.Exactly(t, []string{"github.com/pkg/errors"}, mods.GetPaths().SortedSlice())
+	require.Exactly(
+		t,
+		cage_go_list.Module{
+			Path:    "github.com/pkg/errors",
+			Version: "v0.8.1",
+		},
+		*mods.GetByPath("github.com/pkg/errors"),
+	)
+
+	mods, err = cage_go_list.PackageModules(s.ctx, s.executor, dir, nil)
+	require.NoError(t, err)
+	require.Empty(t, mods.GetPaths().SortedSlice())
+}
+
 func (s *ListSuite) TestResolveDir() {
 	t := s.T()
 	ctx := context.Background()
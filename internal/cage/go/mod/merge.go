@@ -0,0 +1,173 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package mod
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ModChange describes a directive which MergeMod added, updated, or removed.
+type ModChange struct {
+	// Directive is the directive name, e.g. "require".
+	Directive string
+
+	// Path is the `module` path, required path, or replaced path. It is empty for `go` directives.
+	Path string
+
+	// Old is the previous value. It is empty if the directive was added.
+	Old string
+
+	// New is the current value. It is empty if the directive was removed.
+	New string
+}
+
+func (c ModChange) String() string {
+	oldVal, newVal := c.Old, c.New
+	if oldVal == "" {
+		oldVal = "(none)"
+	}
+	if newVal == "" {
+		newVal = "(none)"
+	}
+	s := c.Directive
+	if c.Path != "" {
+		s += " " + c.Path
+	}
+	return s + ": " + oldVal + " -> " + newVal
+}
+
+// MergeMod updates the `require` and `replace` directives of the destination go.mod content to match the source.
+//
+// Other directives, e.g. `exclude`, `retract`, and `toolchain`, and comments are preserved. The destination's
+// `go` directive is also preserved, and only added from the source if missing. A destination `require` which
+// has a trailing comment, e.g. explaining a pin, keeps the comment after its version is updated.
+//
+// If the destination is empty, the source's String value is returned.
+func MergeMod(dst io.Reader, src *Mod) (merged string, changes []ModChange, err error) {
+	readBytes, err := ioutil.ReadAll(dst)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+
+	if strings.TrimSpace(string(readBytes)) == "" {
+		changes = append(changes, ModChange{Directive: "module", Path: src.Path, New: src.Path})
+		if src.Go != "" {
+			changes = append(changes, ModChange{Directive: "go", New: src.Go})
+		}
+		for _, r := range src.Requires() {
+			changes = append(changes, ModChange{Directive: "require", Path: r.Path, New: r.Version})
+		}
+		for _, r := range src.Replaces() {
+			changes = append(changes, ModChange{Directive: "replace", Path: r.Old, New: replaceSpec(r)})
+		}
+		return src.String(), changes, nil
+	}
+
+	dstMod, err := NewMod(bytes.NewReader(readBytes))
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to parse destination go.mod")
+	}
+
+	if dstMod.Path != src.Path {
+		changes = append(changes, ModChange{Directive: "module", Path: src.Path, Old: dstMod.Path, New: src.Path})
+		dstMod.Path = src.Path
+	}
+	if dstMod.Go == "" && src.Go != "" {
+		changes = append(changes, ModChange{Directive: "go", New: src.Go})
+		dstMod.Go = src.Go
+	}
+
+	srcRequires := make(map[string]ModRequire)
+	for _, r := range src.Requires() {
+		srcRequires[r.Path] = r
+	}
+	srcReplaces := make(map[string]ModReplace)
+	for _, r := range src.Replaces() {
+		srcReplaces[r.Old] = r
+	}
+
+	// Update/remove the destination's directives in file order.
+
+	dstRequires := make(map[string]bool)
+	for _, r := range dstMod.Requires() {
+		dstRequires[r.Path] = true
+
+		srcRequire, found := srcRequires[r.Path]
+		if !found {
+			changes = append(changes, ModChange{Directive: "require", Path: r.Path, Old: r.Version})
+			if err = dstMod.DelRequire(r.Path); err != nil {
+				return "", nil, errors.WithStack(err)
+			}
+			continue
+		}
+		if srcRequire.Version != r.Version {
+			changes = append(changes, ModChange{Directive: "require", Path: r.Path, Old: r.Version, New: srcRequire.Version})
+		}
+		if r.Comment != "" {
+			srcRequire.Comment = r.Comment
+		}
+		if srcRequire.Version != r.Version || srcRequire.Comment != r.Comment {
+			if err = dstMod.AddRequire(srcRequire); err != nil {
+				return "", nil, errors.WithStack(err)
+			}
+		}
+	}
+
+	dstReplaces := make(map[string]bool)
+	for _, r := range dstMod.Replaces() {
+		dstReplaces[r.Old] = true
+
+		srcReplace, found := srcReplaces[r.Old]
+		if !found {
+			changes = append(changes, ModChange{Directive: "replace", Path: r.Old, Old: replaceSpec(r)})
+			if err = dstMod.DelReplace(r.Old); err != nil {
+				return "", nil, errors.WithStack(err)
+			}
+			continue
+		}
+		if replaceSpec(srcReplace) != replaceSpec(r) {
+			changes = append(changes, ModChange{Directive: "replace", Path: r.Old, Old: replaceSpec(r), New: replaceSpec(srcReplace)})
+			if err = dstMod.AddReplace(srcReplace); err != nil {
+				return "", nil, errors.WithStack(err)
+			}
+		}
+	}
+
+	// Add the source's other directives.
+
+	for _, r := range src.Requires() {
+		if !dstRequires[r.Path] {
+			changes = append(changes, ModChange{Directive: "require", Path: r.Path, New: r.Version})
+			if err = dstMod.AddRequire(r); err != nil {
+				return "", nil, errors.WithStack(err)
+			}
+		}
+	}
+	for _, r := range src.Replaces() {
+		if !dstReplaces[r.Old] {
+			changes = append(changes, ModChange{Directive: "replace", Path: r.Old, New: replaceSpec(r)})
+			if err = dstMod.AddReplace(r); err != nil {
+				return "", nil, errors.WithStack(err)
+			}
+		}
+	}
+
+	return dstMod.String(), changes, nil
+}
+
+// replaceSpec returns the replacement path and optional version of the directive.
+func replaceSpec(r ModReplace) string {
+	if r.Version != "" {
+		return r.New + " " + r.Version
+	}
+	return r.New
+}
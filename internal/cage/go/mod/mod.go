@@ -7,39 +7,23 @@
 package mod
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
 
 	cage_io "github.com/codeactual/transplant/internal/cage/io"
 )
 
 var (
-	// modGOLine matches the `go <version>` directive.
-	modGoLine *regexp.Regexp
-
-	// modPathLine matches the `module <path>` directive.
-	modPathLine *regexp.Regexp
-
-	// modReplaceLine matches a go.mod `replace` directive.
-	// <old path> => <replacement spec>
-	modReplaceLine *regexp.Regexp
-
-	// modReplaceNew matches the replacement spec of a go.mod `replace` directive.
-	// <new path> [version]
-	modReplaceNew *regexp.Regexp
-
-	// modRequireSection matches the contents before, in, and after a go.mod `require (...)` directive block.
-	modRequireSection *regexp.Regexp
-
-	// modRequireLine matches a line of a go.mod `require (...)` directive block.
-	// <path> <version> [comment]
-	modRequireLine *regexp.Regexp
-
 	// sumLine matches lines with the hash of a module or module's go.mod.
 	//
 	// <path> <version> <hash>
@@ -47,13 +31,7 @@ var (
 )
 
 func init() {
-	modGoLine = regexp.MustCompile(`^go\s+(.+)`)
-	modPathLine = regexp.MustCompile(`^module\s+(.+)`)
-	modReplaceLine = regexp.MustCompile(`^replace\s+(\S+)\s+=>\s+(.+)`)
-	modReplaceNew = regexp.MustCompile(`(\S+)(\s+(\S+))?`)
-	modRequireLine = regexp.MustCompile(`^\t(\S+) (\S+)( \/\/.*)?`)
-	modRequireSection = regexp.MustCompile(`(?ms)(.*require \()([^)]+)(.*)`)
-	sumLine = regexp.MustCompile(`^(\S+) (\S+) (\S+)`)
+	sumLine = regexp.MustCompile(`^(\S+) (\S+) (\S+)$`)
 }
 
 // ModReplace defines one `replace` directive.
@@ -61,6 +39,9 @@ type ModReplace struct {
 	// Old is the replaced import path.
 	Old string
 
+	// OldVersion holds the optional version selection of the replaced path.
+	OldVersion string
+
 	// New is the replacement path.
 	New string
 
@@ -69,19 +50,35 @@ type ModReplace struct {
 }
 
 func (r ModReplace) String() string {
-	s := "replace " + r.Old + " => " + r.New
+	s := "replace " + r.Old
+	if r.OldVersion != "" {
+		s += " " + r.OldVersion
+	}
+	s += " => " + r.New
 	if r.Version != "" {
 		s += " " + r.Version
 	}
 	return s
 }
 
-// ModRequire defines one line of the `require (...)` directive block.
+// IsLocal returns true if the replacement is a filesystem path rather than a module path.
+//
+// It follows the go command's rule that such paths are absolute or begin with "./" or "../".
+func (r ModReplace) IsLocal() bool {
+	return r.New == "." || r.New == ".." ||
+		strings.HasPrefix(r.New, "./") || strings.HasPrefix(r.New, "../") ||
+		strings.HasPrefix(r.New, "/") || filepath.IsAbs(r.New)
+}
+
+// ModRequire defines one `require` directive.
 type ModRequire struct {
 	// Comment is the substring found after the version. If a comment was present, it contains
 	// the leading space.
 	Comment string
 
+	// Indirect is true if the comment marks the requirement as indirect, e.g. "// indirect".
+	Indirect bool
+
 	// Path is an import path.
 	Path string
 
@@ -93,6 +90,10 @@ func (r ModRequire) String() string {
 	return r.Path + " " + r.Version + r.Comment
 }
 
+// Mod describes a go.mod file.
+//
+// It is backed by a golang.org/x/mod/modfile File so that String preserves the directives and comments
+// read by NewMod, including those it does not provide accessors for.
 type Mod struct {
 	// Path is from the `module <path>` directive.
 	Path string
@@ -100,20 +101,9 @@ type Mod struct {
 	// Go is the `go` directive value.
 	Go string
 
-	beforeRequire string
-	afterRequire  string
-
-	// replaces indexes each ModReplace by import path.
-	replaces map[string]ModReplace
-
-	// replacesOrder holds the import paths in the order they were read.
-	replacesOrder []string
-
-	// requires indexes each ModRequire by import path.
-	requires map[string]ModRequire
-
-	// requiresOrder holds the import paths in the order they were read.
-	requiresOrder []string
+	// file holds the parsed content. If the Mod was not created by NewMod, it is created from
+	// Path and Go on first use.
+	file *modfile.File
 }
 
 func NewModFromFile(name string) (m *Mod, err error) {
@@ -127,183 +117,163 @@ func NewModFromFile(name string) (m *Mod, err error) {
 }
 
 func NewMod(r io.Reader) (m *Mod, err error) {
-	m = &Mod{}
-
 	readBytes, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	readStr := string(readBytes)
-
-	pathMatches := modPathLine.FindAllStringSubmatch(readStr, 1)
-	if pathMatches == nil {
+	file, err := modfile.Parse("go.mod", readBytes, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse go.mod")
+	}
+	if file.Module == nil {
 		return nil, errors.New("failed to parse go.mod module path")
 	}
-	m.Path = pathMatches[0][1]
 
-	sectionMatches := modRequireSection.FindAllStringSubmatch(readStr, 1)
-	if sectionMatches == nil {
-		m.beforeRequire = readStr
-		return m, nil
+	m = &Mod{Path: file.Module.Mod.Path, file: file}
+	if file.Go != nil {
+		m.Go = file.Go.Version
 	}
 
-	m.beforeRequire = sectionMatches[0][1]
-	m.afterRequire = sectionMatches[0][3]
-
-	m.requires = make(map[string]ModRequire)
-	for _, line := range strings.Split(sectionMatches[0][2], "\n") {
-		if line == "" {
-			continue
-		}
-
-		lineMatches := modRequireLine.FindAllStringSubmatch(line, 1)
-		if lineMatches == nil {
-			return nil, errors.Errorf("failed to parse go.mod line [%s]", line)
-		}
-
-		p := lineMatches[0][1]
+	return m, nil
+}
 
-		m.requires[p] = ModRequire{
-			Path:    p,
-			Version: lineMatches[0][2],
-			Comment: lineMatches[0][3],
-		}
-		m.requiresOrder = append(m.requiresOrder, p)
+// modFile returns the parsed content after applying Path and Go if they were modified.
+//
+// An invalid Go value is not applied, and the previous `go` directive, if any, is preserved.
+func (m *Mod) modFile() *modfile.File {
+	if m.file == nil {
+		m.file = &modfile.File{Syntax: &modfile.FileSyntax{}}
 	}
+	if m.file.Module == nil || m.file.Module.Mod.Path != m.Path {
+		_ = m.file.AddModuleStmt(m.Path) // it only returns nil
+	}
+	if m.Go != "" && (m.file.Go == nil || m.file.Go.Version != m.Go) {
+		_ = m.file.AddGoStmt(m.Go)
+	}
+	return m.file
+}
 
-	m.replaces = make(map[string]ModReplace)
-	collectReplace := func(raw string) error {
-		for _, line := range strings.Split(raw, "\n") {
-			if line == "" {
-				continue
-			}
-
-			lineMatches := modReplaceLine.FindAllStringSubmatch(line, 1)
-			if lineMatches == nil {
-				continue
-			}
-
-			oldPath := lineMatches[0][1]
+func (m *Mod) String() string {
+	f := m.modFile()
+	f.Cleanup()
+	return string(modfile.Format(f.Syntax))
+}
 
-			lineMatches = modReplaceNew.FindAllStringSubmatch(lineMatches[0][2], 1)
-			if lineMatches == nil {
-				return errors.Errorf("failed to parse go.mod line [%s]", line)
-			}
+// DelRequire removes the `require` directive with the import path.
+func (m *Mod) DelRequire(importPath string) error {
+	return errors.Wrapf(m.modFile().DropRequire(importPath), "failed to remove requirement [%s]", importPath)
+}
 
-			m.replaces[oldPath] = ModReplace{
-				Old:     oldPath,
-				New:     lineMatches[0][1],
-				Version: lineMatches[0][3],
-			}
-			m.replacesOrder = append(m.replacesOrder, oldPath)
+func (m *Mod) GetRequire(importPath string) (require ModRequire, found bool) {
+	for _, r := range m.Requires() {
+		if r.Path == importPath {
+			return r, true
 		}
-
-		return nil
-	}
-	if err := collectReplace(m.beforeRequire); err != nil {
-		return nil, errors.WithStack(err)
-	}
-	if err := collectReplace(m.afterRequire); err != nil {
-		return nil, errors.WithStack(err)
 	}
+	return ModRequire{}, false
+}
 
-	collectGo := func(raw string) {
-		for _, line := range strings.Split(raw, "\n") {
-			if line == "" {
-				continue
-			}
+// AddRequire appends a `require` directive, or overwrites the version and comment of an existing one
+// with the same import path.
+func (m *Mod) AddRequire(require ModRequire) error {
+	f := m.modFile()
 
-			lineMatches := modGoLine.FindAllStringSubmatch(line, 1)
-			if lineMatches == nil {
-				continue
-			}
+	if err := f.AddRequire(require.Path, require.Version); err != nil {
+		return errors.Wrapf(err, "failed to add requirement [%s %s]", require.Path, require.Version)
+	}
 
-			m.Go = lineMatches[0][1]
+	comment := strings.TrimSpace(require.Comment)
+	for _, r := range f.Require {
+		if r.Mod.Path != require.Path {
+			continue
+		}
+		r.Syntax.Suffix = nil
+		if comment != "" {
+			r.Syntax.Suffix = []modfile.Comment{{Token: comment, Suffix: true}}
 		}
+		r.Indirect = isIndirectComment(comment)
 	}
-	collectGo(m.beforeRequire)
-	collectGo(m.afterRequire)
 
-	return m, nil
+	return nil
 }
 
-func (m *Mod) String() string {
-	var b strings.Builder
-	b.WriteString("module ")
-	b.WriteString(m.Path)
-	b.WriteString("\n")
-	if m.Go != "" {
-		b.WriteString("\n")
-		b.WriteString("go ")
-		b.WriteString(m.Go)
-		b.WriteString("\n")
-	}
-	if len(m.requiresOrder) > 0 {
-		b.WriteString("\nrequire (")
-		for _, p := range m.requiresOrder {
-			b.WriteString("\n\t")
-			b.WriteString(m.requires[p].String())
-		}
-		b.WriteString("\n)")
-	}
-	replacesLen := len(m.replacesOrder)
-	if replacesLen > 0 {
-		b.WriteString("\n")
-		for n, p := range m.replacesOrder {
-			b.WriteString("\n")
-			b.WriteString(m.replaces[p].String())
-			if n < replacesLen-1 {
-				b.WriteString("\n")
+// AddReplace appends a `replace` directive, or overwrites an existing one with the same old import path.
+func (m *Mod) AddReplace(replace ModReplace) error {
+	f := m.modFile()
+
+	// Drop directives which replace other versions of the path because they would not be overwritten.
+	for _, r := range f.Replace {
+		if r.Old.Path == replace.Old && r.Old.Version != replace.OldVersion {
+			if err := f.DropReplace(r.Old.Path, r.Old.Version); err != nil {
+				return errors.Wrapf(err, "failed to remove replacement of [%s %s]", r.Old.Path, r.Old.Version)
 			}
 		}
 	}
-	b.WriteString("\n")
-	return b.String()
-}
 
-func (m *Mod) DelRequire(importPath string) (found bool) {
-	_, found = m.requires[importPath]
-	if found {
-		for n, p := range m.requiresOrder {
-			if p == importPath {
-				m.requiresOrder = append(m.requiresOrder[:n], m.requiresOrder[n+1:]...)
-				delete(m.requires, importPath)
-				return true
-			}
-		}
+	if err := f.AddReplace(replace.Old, replace.OldVersion, replace.New, replace.Version); err != nil {
+		return errors.Wrapf(err, "failed to add [%s]", replace.String())
 	}
-	return false
+
+	return nil
 }
 
-func (m *Mod) GetRequire(importPath string) (require ModRequire, found bool) {
-	require, found = m.requires[importPath]
-	return require, found
+// DelReplace removes the `replace` directives of any version of the old import path.
+func (m *Mod) DelReplace(oldPath string) error {
+	f := m.modFile()
+	for _, r := range f.Replace {
+		if r.Old.Path != oldPath {
+			continue
+		}
+		if err := f.DropReplace(r.Old.Path, r.Old.Version); err != nil {
+			return errors.Wrapf(err, "failed to remove replacement of [%s %s]", r.Old.Path, r.Old.Version)
+		}
+	}
+	return nil
 }
 
 // SetRequire overwrites the identified require's fields with new values.
-func (m *Mod) SetRequire(importPath string, require ModRequire) {
+func (m *Mod) SetRequire(importPath string, require ModRequire) error {
 	require.Path = importPath
 
 	// Retain the original comment because the destination go.mod/go/sum are more
 	// specific/accurate to its requirements than the source's.
-	require.Comment = m.requires[importPath].Comment
+	existing, _ := m.GetRequire(importPath)
+	require.Comment = existing.Comment
 
-	m.requires[importPath] = require
+	return m.AddRequire(require)
 }
 
-// Require returns all `require (...)` directives in the order they were read.
+// Require returns all `require` directives in the order they were read.
 func (m *Mod) Requires() (r []ModRequire) {
-	for _, p := range m.requiresOrder {
-		r = append(r, m.requires[p])
+	for _, req := range m.modFile().Require {
+		if req.Mod.Path == "" { // removed but not cleaned up
+			continue
+		}
+
+		var comments []string
+		for _, c := range req.Syntax.Suffix {
+			comments = append(comments, c.Token)
+		}
+
+		require := ModRequire{Path: req.Mod.Path, Version: req.Mod.Version}
+		if len(comments) > 0 {
+			require.Comment = " " + strings.Join(comments, " ")
+		}
+		require.Indirect = isIndirectComment(require.Comment)
+
+		r = append(r, require)
 	}
 	return r
 }
 
 // Replace returns all `replace` directives in the order they were read.
 func (m *Mod) Replaces() (r []ModReplace) {
-	for _, p := range m.replacesOrder {
-		r = append(r, m.replaces[p])
+	for _, rep := range m.modFile().Replace {
+		if rep.Old.Path == "" { // removed but not cleaned up
+			continue
+		}
+		r = append(r, ModReplace{Old: rep.Old.Path, OldVersion: rep.Old.Version, New: rep.New.Path, Version: rep.New.Version})
 	}
 	return r
 }
@@ -322,6 +292,16 @@ type Sum struct {
 	order []string
 }
 
+func NewSumFromFile(name string) (s *Sum, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer cage_io.CloseOrStderr(f, name)
+
+	return NewSum(f)
+}
+
 func NewSum(r io.Reader) (s *Sum, err error) {
 	s = &Sum{}
 
@@ -338,6 +318,9 @@ func NewSum(r io.Reader) (s *Sum, err error) {
 		}
 
 		lineMatches := sumLine.FindAllStringSubmatch(line, 1)
+		if lineMatches == nil {
+			return nil, errors.Errorf("failed to parse go.sum line [%s]", line)
+		}
 
 		idx := lineMatches[0][1] + lineMatches[0][2]
 		s.hashes[idx] = SumLine{
@@ -359,6 +342,29 @@ func (s *Sum) GetHash(importPath, version string) string {
 	return ""
 }
 
+// AddLine appends the line unless one with the same path and version was already read/added.
+func (s *Sum) AddLine(line SumLine) (added bool) {
+	if s.hashes == nil {
+		s.hashes = make(map[string]SumLine)
+	}
+	idx := line.Path + line.Version
+	if _, found := s.hashes[idx]; found {
+		return false
+	}
+	s.hashes[idx] = line
+	s.order = append(s.order, idx)
+	return true
+}
+
+func (s *Sum) String() string {
+	var b strings.Builder
+	for _, idx := range s.order {
+		line := s.hashes[idx]
+		b.WriteString(line.Path + " " + line.Version + " " + line.Hash + "\n")
+	}
+	return b.String()
+}
+
 // Lines returns SumLine values in the order they were read during NewSum.
 func (s *Sum) GetLines() (lines []SumLine) {
 	for _, idx := range s.order {
@@ -378,7 +384,9 @@ func SyncRequire(src, dst *Mod) (errs []error) {
 			continue
 		}
 
-		dst.SetRequire(r.Path, srcReq)
+		if err := dst.SetRequire(r.Path, srcReq); err != nil {
+			errs = append(errs, errors.WithStack(err))
+		}
 	}
 
 	if len(errs) > 0 {
@@ -406,3 +414,112 @@ func CompareSum(src, dst *Sum) (errs []error) {
 
 	return errs
 }
+
+// MissingSum returns the destination go.sum lines whose module version has no hash in the source.
+func MissingSum(src, dst *Sum) (missing []SumLine) {
+	for _, line := range dst.GetLines() {
+		if src.GetHash(line.Path, line.Version) == "" {
+			missing = append(missing, line)
+		}
+	}
+	return missing
+}
+
+// RequireConflict describes a module which multiple go.mod files require at different versions.
+type RequireConflict struct {
+	// Path is the required module's import path.
+	Path string
+
+	// Versions indexes each required version by the `module <path>` of the go.mod which requires it.
+	Versions map[string]string
+
+	// Selected is the highest required version, which the go command would also select.
+	Selected string
+}
+
+func (c RequireConflict) String() string {
+	var requirers []string
+	for p := range c.Versions {
+		requirers = append(requirers, p)
+	}
+	sort.Strings(requirers)
+
+	var versions []string
+	for _, p := range requirers {
+		versions = append(versions, p+"@"+c.Versions[p])
+	}
+
+	return fmt.Sprintf("%s: selected %s from [%s]", c.Path, c.Selected, strings.Join(versions, ", "))
+}
+
+// MergeRequires combines the `require (...)` directives of multiple go.mod files, e.g. from the modules
+// of a go.work, and selects the highest version of each module required by more than one go.mod.
+//
+// Conflicts are returned in import path order.
+func MergeRequires(mods ...*Mod) (merged map[string]ModRequire, conflicts []RequireConflict, err error) {
+	merged = make(map[string]ModRequire)
+	versions := make(map[string]map[string]string) // required path -> requiring module path -> version
+
+	for _, m := range mods {
+		for _, r := range m.Requires() {
+			if versions[r.Path] == nil {
+				versions[r.Path] = make(map[string]string)
+			}
+			versions[r.Path][m.Path] = r.Version
+
+			prev, found := merged[r.Path]
+			if !found {
+				merged[r.Path] = r
+				continue
+			}
+
+			higher, err := isHigherVersion(r.Version, prev.Version)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to compare [%s] versions required by [%s]", r.Path, m.Path)
+			}
+			if higher {
+				merged[r.Path] = r
+			}
+		}
+	}
+
+	var paths []string
+	for p := range versions {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		distinct := make(map[string]struct{})
+		for _, v := range versions[p] {
+			distinct[v] = struct{}{}
+		}
+		if len(distinct) < 2 {
+			continue
+		}
+		conflicts = append(conflicts, RequireConflict{
+			Path:     p,
+			Versions: versions[p],
+			Selected: merged[p].Version,
+		})
+	}
+
+	return merged, conflicts, nil
+}
+
+// isHigherVersion returns true if the first version has higher semver precedence than the second.
+func isHigherVersion(a, b string) (bool, error) {
+	for _, v := range []string{a, b} {
+		if !semver.IsValid(v) {
+			return false, errors.Errorf("failed to parse version [%s]", v)
+		}
+	}
+	return semver.Compare(a, b) > 0, nil
+}
+
+// isIndirectComment returns true if the comment marks a requirement as indirect,
+// e.g. "// indirect" or "// indirect; reason".
+func isIndirectComment(comment string) bool {
+	c := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comment), "//"))
+	return c == "indirect" || strings.HasPrefix(c, "indirect;")
+}
@@ -37,22 +37,22 @@ func TestModReplace(t *testing.T) {
 		[]cage_mod.ModReplace{
 			{
 				Old:     "replace0-old-domain.com/user/proj",
-				New:     "replace0-new-domain.com/user/proj",
+				New:     "../replace0-new",
 				Version: "",
 			},
 			{
 				Old:     "replace1-old-domain.com/user/proj",
 				New:     "replace1-new-domain.com/user/proj",
-				Version: "replace1-version",
+				Version: "v1.0.1",
 			},
 			{
 				Old:     "replace2-old-domain.com/user/proj",
 				New:     "replace2-new-domain.com/user/proj",
-				Version: "replace2-version",
+				Version: "v1.0.2",
 			},
 			{
 				Old:     "replace3-old-domain.com/user/proj",
-				New:     "replace3-new-domain.com/user/proj",
+				New:     "./replace3-new",
 				Version: "",
 			},
 		},
@@ -60,6 +60,15 @@ func TestModReplace(t *testing.T) {
 	)
 }
 
+func TestModReplaceIsLocal(t *testing.T) {
+	for _, newPath := range []string{".", "..", "./fork", "../forks/x", "/abs/fork"} {
+		require.True(t, cage_mod.ModReplace{Old: "domain.com/x", New: newPath}.IsLocal(), newPath)
+	}
+	for _, newPath := range []string{"domain.com/fork", "fork", ".fork/x"} {
+		require.False(t, cage_mod.ModReplace{Old: "domain.com/x", New: newPath, Version: "v1.0.0"}.IsLocal(), newPath)
+	}
+}
+
 func TestModGo(t *testing.T) {
 	srcModFile := cage_file.MustOpenFixturePath(t, "src.mod")
 	srcMod, err := cage_mod.NewMod(srcModFile)
@@ -67,9 +76,10 @@ func TestModGo(t *testing.T) {
 	require.Exactly(t, "1.12", srcMod.Go)
 }
 
+// TestModString asserts that unmodified content round-trips exactly.
 func TestModString(t *testing.T) {
 	srcModFile := cage_file.MustOpenFixturePath(t, "src.mod")
-	goldenFile := cage_file.MustOpenFixturePath(t, "src-string.mod")
+	goldenFile := cage_file.MustOpenFixturePath(t, "src.mod")
 
 	srcMod, err := cage_mod.NewMod(srcModFile)
 	require.NoError(t, err)
@@ -134,3 +144,183 @@ func TestCompareSum(t *testing.T) {
 	require.Exactly(t, errs[2].Error(), "expected hash for [github.com/spf13/pflag] version [v1.0.3] to be [h1:zPAT6CGy6wXeQ7NtTnaTerfKOsV6V6F8agHXFiazDkg=], found [h1:zPAT6CGy6wXeQ7NtTnaTerfKksV6V6F8agHXFiazDkg=]")
 
 }
+
+func TestMissingSum(t *testing.T) {
+	srcSumFile := cage_file.MustOpenFixturePath(t, "src.sum")
+	srcSum, err := cage_mod.NewSum(srcSumFile)
+	require.NoError(t, err)
+
+	require.Empty(t, cage_mod.MissingSum(srcSum, srcSum))
+
+	dstSum := &cage_mod.Sum{}
+	for _, line := range srcSum.GetLines() {
+		dstSum.AddLine(line)
+	}
+	unvetted := cage_mod.SumLine{Path: "domain.com/path/to/unvetted", Version: "v1.0.0", Hash: "h1:AAAA"}
+	dstSum.AddLine(unvetted)
+
+	require.Exactly(t, []cage_mod.SumLine{unvetted}, cage_mod.MissingSum(srcSum, dstSum))
+}
+
+func TestMergeRequires(t *testing.T) {
+	aMod, err := cage_mod.NewMod(cage_file.MustOpenFixturePath(t, "merge_a.mod"))
+	require.NoError(t, err)
+
+	bMod, err := cage_mod.NewMod(cage_file.MustOpenFixturePath(t, "merge_b.mod"))
+	require.NoError(t, err)
+
+	merged, conflicts, err := cage_mod.MergeRequires(aMod, bMod)
+	require.NoError(t, err)
+
+	mergedVersions := make(map[string]string)
+	for p, r := range merged {
+		mergedVersions[p] = r.Version
+	}
+	require.Exactly(
+		t,
+		map[string]string{
+			"github.com/pkg/errors":  "v0.9.1",
+			"github.com/spf13/cobra": "v0.0.3",
+			"github.com/spf13/pflag": "v1.0.3",
+			"golang.org/x/crypto":    "v0.0.0-20190123085648-057139ce5d2b",
+		},
+		mergedVersions,
+	)
+
+	require.Exactly(
+		t,
+		[]cage_mod.RequireConflict{
+			{
+				Path: "github.com/pkg/errors",
+				Versions: map[string]string{
+					"domain.com/path/to/a": "v0.8.1",
+					"domain.com/path/to/b": "v0.9.1",
+				},
+				Selected: "v0.9.1",
+			},
+			{
+				Path: "golang.org/x/crypto",
+				Versions: map[string]string{
+					"domain.com/path/to/a": "v0.0.0-20190123085648-057139ce5d2b",
+					"domain.com/path/to/b": "v0.0.0-20180904163835-0709b304e793",
+				},
+				Selected: "v0.0.0-20190123085648-057139ce5d2b",
+			},
+		},
+		conflicts,
+	)
+	require.Exactly(
+		t,
+		"github.com/pkg/errors: selected v0.9.1 from [domain.com/path/to/a@v0.8.1, domain.com/path/to/b@v0.9.1]",
+		conflicts[0].String(),
+	)
+}
+
+func TestModAdd(t *testing.T) {
+	goldenFile := cage_file.MustOpenFixturePath(t, "add_golden.mod")
+
+	m := &cage_mod.Mod{Path: "domain.com/path/to/add", Go: "1.12"}
+	require.NoError(t, m.AddRequire(cage_mod.ModRequire{Path: "github.com/pkg/errors", Version: "v0.8.0"}))
+	require.NoError(t, m.AddRequire(cage_mod.ModRequire{Path: "golang.org/x/sync", Version: "v0.0.0-20190423024810-112230192c58", Comment: " // indirect"}))
+	require.NoError(t, m.AddRequire(cage_mod.ModRequire{Path: "github.com/pkg/errors", Version: "v0.8.1"}))
+	require.NoError(t, m.AddReplace(cage_mod.ModReplace{Old: "domain.com/path/to/add/sub", New: "./sub"}))
+
+	testkit_require.ReadersMatch(t, "golden", goldenFile, "actual", strings.NewReader(m.String()))
+
+	require.Exactly(t, "module domain.com/path/to/add\n\ngo 1.12\n", (&cage_mod.Mod{Path: "domain.com/path/to/add", Go: "1.12"}).String())
+}
+
+func TestSumString(t *testing.T) {
+	srcSumFile := cage_file.MustOpenFixturePath(t, "src.sum")
+	srcSumFileBytes, err := ioutil.ReadAll(srcSumFile)
+	require.NoError(t, err)
+
+	srcSum, err := cage_mod.NewSum(strings.NewReader(string(srcSumFileBytes)))
+	require.NoError(t, err)
+	require.Exactly(t, strings.TrimSpace(string(srcSumFileBytes))+"\n", srcSum.String())
+
+	line := srcSum.GetLines()[0]
+	require.False(t, srcSum.AddLine(line))
+
+	added := &cage_mod.Sum{}
+	require.True(t, added.AddLine(line))
+	require.Exactly(t, line.Path+" "+line.Version+" "+line.Hash+"\n", added.String())
+}
+
+func TestModWithoutRequire(t *testing.T) {
+	m, err := cage_mod.NewMod(strings.NewReader("module domain.com/path/to/src\n\ngo 1.12\n\nreplace replace0-old-domain.com/user/proj => ../proj\n"))
+	require.NoError(t, err)
+	require.Exactly(t, "1.12", m.Go)
+	require.Empty(t, m.Requires())
+	require.Exactly(t, []cage_mod.ModReplace{{Old: "replace0-old-domain.com/user/proj", New: "../proj"}}, m.Replaces())
+}
+
+func TestMergeMod(t *testing.T) {
+	goldenFile := cage_file.MustOpenFixturePath(t, "merge_golden.mod")
+
+	srcMod, err := cage_mod.NewMod(cage_file.MustOpenFixturePath(t, "merge_src.mod"))
+	require.NoError(t, err)
+
+	merged, changes, err := cage_mod.MergeMod(cage_file.MustOpenFixturePath(t, "merge_dst.mod"), srcMod)
+	require.NoError(t, err)
+	testkit_require.ReadersMatch(t, "golden", goldenFile, "actual", strings.NewReader(merged))
+
+	var changeStrs []string
+	for _, c := range changes {
+		changeStrs = append(changeStrs, c.String())
+	}
+	require.Exactly(
+		t,
+		[]string{
+			"require github.com/pkg/errors: v0.8.0 -> v0.8.1",
+			"require github.com/spf13/pflag: v1.0.2 -> (none)",
+			"require golang.org/x/crypto: v0.0.0-20180904163835-0709b304e793 -> v0.0.0-20190123085648-057139ce5d2b",
+			"require github.com/segmentio/ksuid: v1.0.1 -> v1.0.2",
+			"replace github.com/spf13/pflag: ../pflag -> (none)",
+			"replace golang.org/x/crypto: github.com/fork/crypto v0.0.1 -> github.com/fork/crypto v0.0.2",
+			"require github.com/spf13/cobra: (none) -> v0.0.3",
+			"replace github.com/spf13/cobra: (none) -> github.com/fork/cobra v0.0.4",
+		},
+		changeStrs,
+	)
+}
+
+func TestModRoundTrip(t *testing.T) {
+	data, err := ioutil.ReadAll(cage_file.MustOpenFixturePath(t, "roundtrip.mod"))
+	require.NoError(t, err)
+
+	m, err := cage_mod.NewMod(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	require.Exactly(t, string(data), m.String())
+}
+
+func TestMergeModPreservesDirectives(t *testing.T) {
+	goldenFile := cage_file.MustOpenFixturePath(t, "roundtrip_merge_golden.mod")
+
+	srcMod, err := cage_mod.NewMod(cage_file.MustOpenFixturePath(t, "roundtrip.mod"))
+	require.NoError(t, err)
+	require.NoError(t, srcMod.SetRequire("github.com/pkg/errors", cage_mod.ModRequire{Version: "v0.9.1"}))
+
+	merged, changes, err := cage_mod.MergeMod(cage_file.MustOpenFixturePath(t, "roundtrip.mod"), srcMod)
+	require.NoError(t, err)
+	testkit_require.ReadersMatch(t, "golden", goldenFile, "actual", strings.NewReader(merged))
+
+	require.Len(t, changes, 1)
+	require.Exactly(t, "require github.com/pkg/errors: v0.8.1 -> v0.9.1", changes[0].String())
+}
+
+func TestMergeModEmptyDst(t *testing.T) {
+	srcMod, err := cage_mod.NewMod(cage_file.MustOpenFixturePath(t, "merge_src.mod"))
+	require.NoError(t, err)
+
+	merged, changes, err := cage_mod.MergeMod(strings.NewReader(""), srcMod)
+	require.NoError(t, err)
+	require.Exactly(t, srcMod.String(), merged)
+	require.Len(t, changes, 8)
+	require.Exactly(t, "module domain.com/path/to/dst: (none) -> domain.com/path/to/dst", changes[0].String())
+}
+
+func TestSumParseError(t *testing.T) {
+	_, err := cage_mod.NewSum(strings.NewReader("github.com/pkg/errors v0.8.1\n"))
+	require.Error(t, err)
+}
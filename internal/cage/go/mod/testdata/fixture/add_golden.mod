@@ -0,0 +1,10 @@
+module domain.com/path/to/add
+
+go 1.12
+
+require (
+	github.com/pkg/errors v0.8.1
+	golang.org/x/sync v0.0.0-20190423024810-112230192c58 // indirect
+)
+
+replace domain.com/path/to/add/sub => ./sub
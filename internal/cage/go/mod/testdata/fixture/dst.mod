@@ -3,7 +3,7 @@ module domain.com/path/to/dst
 require (
 	github.com/gorilla/securecookie v1.1.1
 	github.com/inconshreveable/mousetrap v1.0.1 // indirect
-	github.com/julienschmidt/httprouter v2.0.0
+	github.com/julienschmidt/httprouter v1.1.0
 	github.com/segmentio/ksuid v1.0.2
 	github.com/spf13/pflag v1.0.4
 )
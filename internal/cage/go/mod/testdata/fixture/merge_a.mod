@@ -0,0 +1,9 @@
+module domain.com/path/to/a
+
+go 1.18
+
+require (
+	github.com/pkg/errors v0.8.1
+	github.com/spf13/cobra v0.0.3
+	golang.org/x/crypto v0.0.0-20190123085648-057139ce5d2b
+)
@@ -0,0 +1,10 @@
+module domain.com/path/to/b
+
+go 1.18
+
+require (
+	github.com/pkg/errors v0.9.1
+	github.com/spf13/cobra v0.0.3
+	github.com/spf13/pflag v1.0.3
+	golang.org/x/crypto v0.0.0-20180904163835-0709b304e793
+)
@@ -0,0 +1,25 @@
+// Maintained in the public repository.
+module domain.com/path/to/dst
+
+go 1.16
+
+toolchain go1.21.0
+
+require (
+	github.com/pkg/errors v0.8.0 // pinned for the v1 API
+	github.com/spf13/pflag v1.0.2
+	golang.org/x/crypto v0.0.0-20180904163835-0709b304e793
+)
+
+require github.com/segmentio/ksuid v1.0.1
+
+exclude github.com/pkg/errors v0.7.0
+
+replace github.com/spf13/pflag => ../pflag
+
+replace (
+	// Keep the fork until upstream merges the fix.
+	golang.org/x/crypto => github.com/fork/crypto v0.0.1
+)
+
+retract v0.1.0 // published by mistake
@@ -0,0 +1,25 @@
+// Maintained in the public repository.
+module domain.com/path/to/dst
+
+go 1.16
+
+toolchain go1.21.0
+
+require (
+	github.com/pkg/errors v0.8.1 // pinned for the v1 API
+	golang.org/x/crypto v0.0.0-20190123085648-057139ce5d2b
+)
+
+require (
+	github.com/segmentio/ksuid v1.0.2
+	github.com/spf13/cobra v0.0.3
+)
+
+exclude github.com/pkg/errors v0.7.0
+
+// Keep the fork until upstream merges the fix.
+replace golang.org/x/crypto => github.com/fork/crypto v0.0.2
+
+retract v0.1.0 // published by mistake
+
+replace github.com/spf13/cobra => github.com/fork/cobra v0.0.4
@@ -0,0 +1,14 @@
+module domain.com/path/to/dst
+
+go 1.12
+
+require (
+	github.com/pkg/errors v0.8.1
+	github.com/segmentio/ksuid v1.0.2
+	github.com/spf13/cobra v0.0.3
+	golang.org/x/crypto v0.0.0-20190123085648-057139ce5d2b
+)
+
+replace golang.org/x/crypto => github.com/fork/crypto v0.0.2
+
+replace github.com/spf13/cobra => github.com/fork/cobra v0.0.4
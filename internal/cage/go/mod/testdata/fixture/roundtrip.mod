@@ -0,0 +1,36 @@
+// Leading comment.
+module domain.com/path/to/roundtrip
+
+go 1.21
+
+toolchain go1.21.5
+
+godebug (
+	default=go1.21
+	panicnil=1 // keep the old behavior
+)
+
+require (
+	github.com/pkg/errors v0.8.1
+	// Pinned until the v1 API is adopted.
+	github.com/spf13/cobra v0.0.3 // indirect; via viper
+	golang.org/x/sync v0.0.0-20190423024810-112230192c58 // indirect
+)
+
+require github.com/segmentio/ksuid v1.0.2
+
+exclude (
+	// Broken releases.
+	github.com/pkg/errors v0.7.0
+	github.com/pkg/errors v0.7.1
+)
+
+replace github.com/spf13/cobra v0.0.3 => ../cobra
+
+// Keep the fork until upstream merges the fix.
+replace golang.org/x/sync => github.com/fork/sync v0.0.1
+
+retract (
+	v0.1.0 // published by mistake
+	[v0.2.0, v0.2.9]
+)
@@ -2,9 +2,9 @@ module domain.com/path/to/src
 
 go 1.12
 
-replace replace0-old-domain.com/user/proj => replace0-new-domain.com/user/proj
+replace replace0-old-domain.com/user/proj => ../replace0-new
 
-replace replace1-old-domain.com/user/proj => replace1-new-domain.com/user/proj replace1-version
+replace replace1-old-domain.com/user/proj => replace1-new-domain.com/user/proj v1.0.1
 
 require (
 	code.cloudfoundry.org/bytefmt v0.0.0-20180906201452-2aa6f33b730c
@@ -20,6 +20,6 @@ require (
 	golang.org/x/crypto v0.0.0-20190123085648-057139ce5d2b
 )
 
-replace replace2-old-domain.com/user/proj => replace2-new-domain.com/user/proj replace2-version
+replace replace2-old-domain.com/user/proj => replace2-new-domain.com/user/proj v1.0.2
 
-replace replace3-old-domain.com/user/proj => replace3-new-domain.com/user/proj
+replace replace3-old-domain.com/user/proj => ./replace3-new
@@ -0,0 +1,152 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package mod
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/mod/modfile"
+
+	cage_io "github.com/codeactual/transplant/internal/cage/io"
+)
+
+const (
+	// WorkFilename is the name of workspace files.
+	WorkFilename = "go.work"
+
+	// workEnvKey selects the workspace file, or disables workspace mode with the value "off".
+	workEnvKey = "GOWORK"
+)
+
+// Work describes a go.work file.
+type Work struct {
+	// Dir is the absolute path to the directory which contains the go.work.
+	Dir string
+
+	// Go is the `go` directive value.
+	Go string
+
+	// Use holds the `use` directive paths, relative to Dir unless absolute, in the order they were read.
+	Use []string
+}
+
+// NewWorkFromFile parses the named go.work.
+func NewWorkFromFile(name string) (w *Work, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer cage_io.CloseOrStderr(f, name)
+
+	w, err = NewWork(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse [%s]", name)
+	}
+
+	w.Dir, err = filepath.Abs(filepath.Dir(name))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get absolute path of [%s]", name)
+	}
+
+	return w, nil
+}
+
+// NewWork parses go.work content. Work.Dir is not populated.
+func NewWork(r io.Reader) (w *Work, err error) {
+	readBytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	file, err := modfile.ParseWork(WorkFilename, readBytes, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse go.work")
+	}
+
+	w = &Work{}
+	if file.Go != nil {
+		w.Go = file.Go.Version
+	}
+	for _, u := range file.Use {
+		w.Use = append(w.Use, u.Path)
+	}
+
+	return w, nil
+}
+
+// UseDirs returns the absolute paths of the `use` directive paths.
+func (w *Work) UseDirs() (dirs []string) {
+	for _, p := range w.Use {
+		p = filepath.FromSlash(p)
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(w.Dir, p)
+		}
+		dirs = append(dirs, filepath.Clean(p))
+	}
+	return dirs
+}
+
+// ModuleDir returns the absolute path of the workspace module directory which contains the file/directory.
+//
+// If modules are nested, the innermost is selected. It returns an empty string if no module contains the path.
+func (w *Work) ModuleDir(name string) (dir string) {
+	name = filepath.Clean(name)
+	for _, d := range w.UseDirs() {
+		if name != d && !strings.HasPrefix(name, d+string(filepath.Separator)) {
+			continue
+		}
+		if len(d) > len(dir) {
+			dir = d
+		}
+	}
+	return dir
+}
+
+// FindWorkFile returns the absolute path of the go.work which applies to the directory,
+// following the same rules as the go command.
+//
+// If the GOWORK environment variable is "off", or no go.work is found in the directory or
+// its ancestors, an empty string is returned.
+func FindWorkFile(dir string) (name string, err error) {
+	if env := os.Getenv(workEnvKey); env != "" {
+		if env == "off" {
+			return "", nil
+		}
+		if !filepath.IsAbs(env) {
+			return "", errors.Errorf("%s [%s] must be an absolute path", workEnvKey, env)
+		}
+		return env, nil
+	}
+
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get absolute path of [%s]", dir)
+	}
+
+	for {
+		candidate := filepath.Join(dir, WorkFilename)
+
+		fi, statErr := os.Stat(candidate)
+		if statErr == nil && !fi.IsDir() {
+			return candidate, nil
+		}
+		if statErr != nil && !os.IsNotExist(statErr) {
+			return "", errors.Wrapf(statErr, "failed to stat [%s]", candidate)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package mod_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cage_mod "github.com/codeactual/transplant/internal/cage/go/mod"
+	cage_file "github.com/codeactual/transplant/internal/cage/testkit/os/file"
+)
+
+func TestNewWorkFromFile(t *testing.T) {
+	_, workFile := cage_file.FixturePath(t, "work", cage_mod.WorkFilename)
+
+	w, err := cage_mod.NewWorkFromFile(workFile)
+	require.NoError(t, err)
+
+	require.Exactly(t, filepath.Dir(workFile), w.Dir)
+	require.Exactly(t, "1.18", w.Go)
+	require.Exactly(t, []string{"./nested/app", "./nested/cage", "./nested/app/sub"}, w.Use)
+	require.Exactly(
+		t,
+		[]string{
+			filepath.Join(w.Dir, "nested", "app"),
+			filepath.Join(w.Dir, "nested", "cage"),
+			filepath.Join(w.Dir, "nested", "app", "sub"),
+		},
+		w.UseDirs(),
+	)
+}
+
+func TestNewWorkUnterminatedBlock(t *testing.T) {
+	_, err := cage_mod.NewWork(strings.NewReader("go 1.18\n\nuse (\n\t./a\n"))
+	require.Error(t, err)
+}
+
+func TestWorkModuleDir(t *testing.T) {
+	w := &cage_mod.Work{Dir: "/ws", Use: []string{"./nested/app", "./nested/cage", "./nested/app/sub"}}
+
+	require.Exactly(t, "/ws/nested/app", w.ModuleDir("/ws/nested/app"))
+	require.Exactly(t, "/ws/nested/app", w.ModuleDir("/ws/nested/app/local/local.go"))
+	require.Exactly(t, "/ws/nested/app/sub", w.ModuleDir("/ws/nested/app/sub/pkg"))
+	require.Exactly(t, "/ws/nested/cage", w.ModuleDir("/ws/nested/cage/dep1"))
+	require.Exactly(t, "", w.ModuleDir("/ws/nested/application"))
+	require.Exactly(t, "", w.ModuleDir("/ws"))
+}
+
+func TestFindWorkFile(t *testing.T) {
+	_, workFile := cage_file.FixturePath(t, "work", cage_mod.WorkFilename)
+
+	prevEnv, hadEnv := os.LookupEnv("GOWORK")
+	require.NoError(t, os.Unsetenv("GOWORK"))
+	defer func() {
+		if hadEnv {
+			os.Setenv("GOWORK", prevEnv)
+		} else {
+			os.Unsetenv("GOWORK")
+		}
+	}()
+
+	found, err := cage_mod.FindWorkFile(filepath.Join(filepath.Dir(workFile), "nested", "app"))
+	require.NoError(t, err)
+	require.Exactly(t, workFile, found)
+
+	require.NoError(t, os.Setenv("GOWORK", "off"))
+	found, err = cage_mod.FindWorkFile(filepath.Dir(workFile))
+	require.NoError(t, err)
+	require.Exactly(t, "", found)
+
+	require.NoError(t, os.Setenv("GOWORK", "relative/go.work"))
+	_, err = cage_mod.FindWorkFile(filepath.Dir(workFile))
+	require.Error(t, err)
+}
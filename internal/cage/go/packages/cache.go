@@ -113,7 +113,7 @@ func NewCache() *Cache {
 // the package's imported paths without also finding the latter's imported paths and so on.
 // Elements of x/tools/go/packages.Package.Imports only contain the PkgPath field.
 //
-// If the import path selects a standard library, only these fields populated:
+// If the import path selects a standard library, or the cgo pseudo-package "C", only these fields populated:
 // Dir (if go/build provides it), Goroot, ImportPaths (if mode provides them), PkgPath, and Name.
 func (c *Cache) LoadImportPathWithBuild(importPath, srcDir string, mode build.ImportMode) (PkgsByName, error) {
 	pkgs := make(PkgsByName)
@@ -135,6 +135,19 @@ func (c *Cache) LoadImportPathWithBuild(importPath, srcDir string, mode build.Im
 		return pkgs, nil
 	}
 
+	// The cgo pseudo-package has no source, so represent it like a standard library.
+	if importPath == cage_build.CgoImportPath {
+		pkgs[importPath] = &Package{
+			Goroot: true,
+			Package: &std_packages.Package{
+				Name:    importPath,
+				PkgPath: importPath,
+			},
+		}
+
+		return pkgs, nil
+	}
+
 	buildPkg, err := c.buildCache.Import(importPath, srcDir, mode)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -75,6 +75,70 @@ func (i *Inspector) WalkGlobalIdsUsedByGlobal(dir, pkgName, idName GlobalIdName,
 	})
 }
 
+// GlobalIdUsagePosition returns the location of the first identifier in the target global node which refers
+// to the used global, either directly or as a type dependency.
+//
+// If the used global is not found, the returned position is invalid.
+func (i *Inspector) GlobalIdUsagePosition(dir, pkgName, idName GlobalIdName, used GlobalId) (pos token.Position, err error) {
+	node, err := i.GlobalIdNode(dir, pkgName, idName)
+	if err != nil {
+		return token.Position{}, errors.WithStack(err)
+	}
+
+	queue, err := i.FindGlobalInAstNode(node.Ast, idName)
+	if err != nil {
+		return token.Position{}, errors.WithStack(err)
+	}
+
+	isUsed := func(info *IdentInfo) bool {
+		return info.PkgPath == used.PkgPath && info.Name == used.Name && info.Position.Filename == used.Filename
+	}
+
+	for _, item := range queue {
+		ast.Inspect(item.Ast, func(n ast.Node) bool {
+			if pos.IsValid() || err != nil {
+				return false
+			}
+
+			ident, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if _, shadowRecvOrParam := i.FuncDeclShadows[ident]; shadowRecvOrParam {
+				return true
+			}
+
+			identInfo, identInfoErr := NewIdentInfo(i, ident)
+			if identInfoErr != nil {
+				err = errors.Wrapf(identInfoErr, "failed to get type info of node: %s", i.NodeToString(ident))
+				return false
+			}
+			if identInfo == nil || identInfo.GlobalRef == nil {
+				return true
+			}
+
+			if isUsed(identInfo.GlobalRef) {
+				pos = i.FileSet.Position(ident.Pos())
+				return false
+			}
+			for _, usedType := range identInfo.GlobalRef.UniqueTypes() {
+				if isUsed(usedType) {
+					pos = i.FileSet.Position(ident.Pos())
+					return false
+				}
+			}
+
+			return true
+		})
+
+		if pos.IsValid() || err != nil {
+			break
+		}
+	}
+
+	return pos, err
+}
+
 // WalkIdsUsedByGlobal provides walkFn with every identifier used in the target node.
 func (i *Inspector) WalkIdsUsedByGlobal(dir, pkgName, idName GlobalIdName, walkFn IdUsedByNodeWalkFunc) (errs []error) {
 	node, err := i.GlobalIdNode(dir, pkgName, idName)
@@ -186,6 +186,9 @@ func LoadWithConfig(cfg *Config, patterns ...string) (pkgMap PkgsByImportPath, e
 
 	var allPkgsFileToName sync.Map
 
+	// cgoFiles holds the ast.File of each original cgo source parsed in place of a cmd/cgo-generated file.
+	var cgoFiles sync.Map
+
 	if needSyntax {
 		// Adjust the same default function from x/tools/go/package to build the ast.File-to-filename map.
 		cfg.ParseFile = func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
@@ -194,12 +197,25 @@ func LoadWithConfig(cfg *Config, patterns ...string) (pkgMap PkgsByImportPath, e
 				isrc = src
 			}
 
+			// Replace files generated by cmd/cgo, which CompiledGoFiles lists instead of the originals,
+			// so that ASTs and their positions reflect the original source. Other cgo-generated files,
+			// e.g. with the C.* declarations, lack a ".go" extension and are omitted below.
+			cgoFilename := cage_build.CgoSourceFilename(src)
+			if cgoFilename != "" {
+				filename = cgoFilename
+				isrc = nil
+			}
+
 			file, err := parser.ParseFile(fset, filename, isrc, parser.AllErrors|parser.ParseComments)
 
 			if err != nil {
 				return nil, err
 			}
 
+			if cgoFilename != "" {
+				cgoFiles.Store(file, true)
+			}
+
 			// - Skip GOCACHE files by checking for ".go" extension.
 			// - Skip standard library files.
 			if cage_filepath.IsGoFile(filename) && !strings.HasPrefix(filename, cage_build.Goroot()) {
@@ -229,17 +245,34 @@ func LoadWithConfig(cfg *Config, patterns ...string) (pkgMap PkgsByImportPath, e
 			Package:    pkg,
 		}
 
+		var usesCgo bool
+
 		if needSyntax {
+			for _, file := range pkg.Syntax {
+				if _, ok := cgoFiles.Load(file); ok {
+					usesCgo = true
+					break
+				}
+			}
+
+			var syntax []*ast.File
 			for _, file := range pkg.Syntax {
 				filename, ok := allPkgsFileToName.Load(file)
 				if !ok { // e.g. standard library
+					if !usesCgo {
+						syntax = append(syntax, file)
+					}
 					continue
 				}
+				syntax = append(syntax, file)
 				fileToName[file] = filename.(string) //nolint:errcheck
 				importPathToDir[pkg.PkgPath] = filepath.Dir(filename.(string))
 				mapVal.Goroot = strings.HasPrefix(filename.(string), cage_build.Goroot())
 				mapVal.Dir = filepath.Dir(filename.(string))
 			}
+
+			// Omit the cgo-generated files which have no original source.
+			pkg.Syntax = syntax
 		}
 
 		if NeedSatisfied(std_packages.NeedFiles, cfg.Mode) {
@@ -262,6 +295,9 @@ func LoadWithConfig(cfg *Config, patterns ...string) (pkgMap PkgsByImportPath, e
 		pkgMap[pkg.PkgPath] = mapVal
 
 		for _, pkgErr := range pkg.Errors {
+			if usesCgo && isCgoImportErr(pkgErr) {
+				continue
+			}
 			errs = append(errs, errors.Wrapf(pkgErr, "failed to load package [%s]", pkg.PkgPath))
 		}
 	}
@@ -269,6 +305,16 @@ func LoadWithConfig(cfg *Config, patterns ...string) (pkgMap PkgsByImportPath, e
 	return pkgMap, errs
 }
 
+// isCgoImportErr returns true if the error is due to type-checking the original source of a cgo file,
+// which imports the "C" pseudo-package, instead of the file generated by cmd/cgo.
+//
+// The type checker tolerates the failed import by treating "C" as a fake package whose selector
+// expressions are not reported as errors.
+func isCgoImportErr(err std_packages.Error) bool {
+	return err.Kind == std_packages.TypeError &&
+		strings.Contains(err.Msg, "could not import "+cage_build.CgoImportPath+" ")
+}
+
 // TrimVendorPathPrefix trims "path/to/vendor/repo/user/proj" to "repo/user/proj".
 func TrimVendorPathPrefix(importPath string) string {
 	vendorIdx := strings.LastIndex(importPath, "vendor/")
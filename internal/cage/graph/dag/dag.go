@@ -61,6 +61,13 @@ func (g *Graph) HasEdge(start, end interface{}) bool {
 	return g.ag.HasEdge(dag.BasicEdge(start, end))
 }
 
+func (g *Graph) Vertices() (vertices []interface{}) {
+	for _, v := range g.ag.Vertices() {
+		vertices = append(vertices, v)
+	}
+	return vertices
+}
+
 func (g *Graph) VerticesFrom(origin interface{}) (vertices []interface{}) {
 	from := g.ag.EdgesFrom(origin)
 	for _, edge := range from {
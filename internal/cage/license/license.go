@@ -0,0 +1,276 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package license identifies the license files and source file license headers of third-party code.
+package license
+
+import (
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Unknown is the identifier of license text which Identify does not recognize.
+	Unknown = "unknown"
+
+	// Notice is the identifier of NOTICE files, which supplement a license rather than declare one.
+	Notice = "NOTICE"
+)
+
+var (
+	// fileName matches the base names of license and notice files, e.g. "LICENSE", "LICENSE.md",
+	// "LICENSE-MIT", "COPYING.txt", and "NOTICE".
+	fileName *regexp.Regexp
+
+	// noticeFileName matches the subset of fileName matches which are notice files.
+	noticeFileName *regexp.Regexp
+
+	// spdxTag matches SPDX short-form identifiers, e.g. "SPDX-License-Identifier: Apache-2.0 OR MIT".
+	spdxTag *regexp.Regexp
+
+	// gnuVersion3 matches the version declarations of GNU licenses.
+	gnuVersion3 *regexp.Regexp
+)
+
+func init() {
+	fileName = regexp.MustCompile(`(?i)^(licen[cs]e|copying|notice|unlicense)([-_][a-z0-9.-]+)?(\.(md|markdown|txt|rst|html))?$`)
+	noticeFileName = regexp.MustCompile(`(?i)^notice`)
+	spdxTag = regexp.MustCompile(`SPDX-License-Identifier:\s*([^\s*]+(?:\s+(?:OR|AND|WITH)\s+[^\s*]+)*)`)
+	gnuVersion3 = regexp.MustCompile(`(?i)version 3`)
+}
+
+// rule identifies a license by phrases which its text contains.
+type rule struct {
+	id string
+
+	// all holds phrases which must all be present.
+	all []string
+}
+
+// rules are evaluated in order, so licenses whose text contains the phrases of another, e.g. the AGPL
+// contains "GNU General Public License", are evaluated first.
+var rules = []rule{
+	{id: "AGPL", all: []string{"gnu affero general public license"}},
+	{id: "LGPL", all: []string{"gnu lesser general public license"}},
+	{id: "GPL", all: []string{"gnu general public license"}},
+	{id: "MPL-2.0", all: []string{"mozilla public license", "2.0"}},
+	{id: "Apache-2.0", all: []string{"apache license", "version 2.0"}},
+	{id: "MIT", all: []string{"permission is hereby granted, free of charge"}},
+	{id: "ISC", all: []string{"permission to use, copy, modify, and/or distribute"}},
+	{id: "BSD-3-Clause", all: []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{id: "BSD-3-Clause", all: []string{"redistribution and use in source and binary forms", "names of its contributors"}},
+	{id: "BSD-2-Clause", all: []string{"redistribution and use in source and binary forms"}},
+	{id: "BSD-3-Clause", all: []string{"governed by a bsd-style license"}},
+	{id: "Unlicense", all: []string{"free and unencumbered software released into the public domain"}},
+}
+
+// IsFileName returns true if the base name of the file is conventionally used for license or notice text.
+func IsFileName(name string) bool {
+	return fileName.MatchString(filepath.Base(name))
+}
+
+// IsNoticeFileName returns true if the base name of the file is conventionally used for notice text.
+func IsNoticeFileName(name string) bool {
+	base := filepath.Base(name)
+	return fileName.MatchString(base) && noticeFileName.MatchString(base)
+}
+
+// Identify returns the SPDX identifier, e.g. "MIT", of the license declared by the text, or Unknown.
+//
+// An SPDX-License-Identifier tag takes precedence. Otherwise the text is matched against the phrases of
+// common licenses, and GNU licenses are identified by their "-2.0"/"-3.0" versions, e.g. "GPL-3.0".
+func Identify(text []byte) string {
+	if matches := spdxTag.FindSubmatch(text); matches != nil {
+		return string(matches[1])
+	}
+
+	normal := normalize(text)
+
+	for _, r := range rules {
+		found := true
+		for _, phrase := range r.all {
+			if !strings.Contains(normal, phrase) {
+				found = false
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+
+		switch r.id {
+		case "AGPL":
+			return "AGPL-3.0"
+		case "LGPL":
+			if gnuVersion3.MatchString(normal) {
+				return "LGPL-3.0"
+			}
+			return "LGPL-2.1"
+		case "GPL":
+			if gnuVersion3.MatchString(normal) {
+				return "GPL-3.0"
+			}
+			return "GPL-2.0"
+		}
+		return r.id
+	}
+
+	return Unknown
+}
+
+// IdentifyHeader returns the SPDX identifier of the license declared by the comments which precede
+// the package clause of the named Go file, or an empty string if it has no such comments.
+//
+// It returns Unknown if the comments do not declare a recognized license, e.g. a copyright line only.
+func IdentifyHeader(name string) (id string, err error) {
+	f, err := parser.ParseFile(token.NewFileSet(), name, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse file [%s]", name)
+	}
+
+	var b strings.Builder
+	for _, group := range f.Comments {
+		if group.End() >= f.Package {
+			break
+		}
+		b.WriteString(group.Text())
+	}
+	if b.Len() == 0 {
+		return "", nil
+	}
+
+	return Identify([]byte(b.String())), nil
+}
+
+// Match returns true if the identifier matches the pattern, which may use path.Match syntax,
+// e.g. "GPL-*". Matching is case-insensitive.
+//
+// Identifiers which are SPDX expressions are evaluated as described by MatchAny.
+func Match(pattern, id string) bool {
+	return len(MatchAny([]string{pattern}, id)) > 0
+}
+
+// MatchAny returns the patterns, in input order, which match the identifier. It returns nil if the
+// identifier does not match. Patterns use the same syntax as in Match.
+//
+// Identifiers which are SPDX expressions, e.g. "Apache-2.0 OR MIT", match only if each alternative of an
+// OR expression matches, because a licensee may select any of them, and if any term of an AND or WITH
+// expression matches, because all of them apply.
+func MatchAny(patterns []string, id string) (matched []string) {
+	m := expressionMatcher{
+		tokens:   strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(id)),
+		patterns: patterns,
+		matched:  make(map[string]bool),
+	}
+
+	// Malformed expressions, e.g. with an unbalanced ")", are evaluated as if their remainders were AND-ed.
+	var ok bool
+	for len(m.tokens) > 0 {
+		if m.or() {
+			ok = true
+		}
+		m.next(")")
+	}
+	if !ok {
+		return nil
+	}
+
+	for _, p := range patterns {
+		if m.matched[p] {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// expressionMatcher evaluates SPDX expressions against patterns by recursive descent, from the lowest
+// precedence operator, OR, to the highest, WITH.
+type expressionMatcher struct {
+	// tokens holds the unconsumed identifiers, operators, and parentheses.
+	tokens []string
+
+	// patterns holds the path.Match patterns.
+	patterns []string
+
+	// matched holds the patterns which matched any identifier, including those of alternatives which
+	// did not match as a whole.
+	matched map[string]bool
+}
+
+// next consumes the token if it is the next one.
+func (m *expressionMatcher) next(token string) bool {
+	if len(m.tokens) > 0 && m.tokens[0] == token {
+		m.tokens = m.tokens[1:]
+		return true
+	}
+	return false
+}
+
+func (m *expressionMatcher) or() bool {
+	ok := m.and()
+	for m.next("OR") {
+		ok = m.and() && ok
+	}
+	return ok
+}
+
+func (m *expressionMatcher) and() bool {
+	ok := m.with()
+	for m.next("AND") {
+		ok = m.with() || ok
+	}
+	return ok
+}
+
+func (m *expressionMatcher) with() bool {
+	ok := m.term()
+	if m.next("WITH") {
+		ok = m.term() || ok
+	}
+	return ok
+}
+
+func (m *expressionMatcher) term() (ok bool) {
+	if len(m.tokens) == 0 {
+		return false
+	}
+
+	if m.next("(") {
+		ok = m.or()
+		m.next(")")
+		return ok
+	}
+
+	id := strings.ToLower(m.tokens[0])
+	m.tokens = m.tokens[1:]
+	for _, p := range m.patterns {
+		if matched, _ := path.Match(strings.ToLower(p), id); matched {
+			m.matched[p] = true
+			ok = true
+		}
+	}
+	return ok
+}
+
+// normalize returns the lowercase text with comment markers removed and whitespace collapsed
+// so that phrases match regardless of line wrapping.
+func normalize(text []byte) string {
+	var words []string
+	for _, line := range strings.Split(string(text), "\n") {
+		line = strings.TrimSpace(line)
+		for _, marker := range []string{"//", "/*", "*/", "*", "#"} {
+			line = strings.TrimPrefix(line, marker)
+		}
+		words = append(words, strings.Fields(line)...)
+	}
+	return strings.ToLower(strings.Join(words, " "))
+}
@@ -0,0 +1,83 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package license_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cage_license "github.com/codeactual/transplant/internal/cage/license"
+	cage_file "github.com/codeactual/transplant/internal/cage/testkit/os/file"
+)
+
+func TestIsFileName(t *testing.T) {
+	for _, name := range []string{"LICENSE", "/a/b/License.md", "LICENCE", "LICENSE-MIT", "COPYING.txt", "NOTICE", "UNLICENSE"} {
+		require.True(t, cage_license.IsFileName(name), name)
+	}
+	for _, name := range []string{"license.go", "LICENSES/", "licensed.txt", "README.md", "/a/LICENSE/b.txt"} {
+		require.False(t, cage_license.IsFileName(name), name)
+	}
+	require.True(t, cage_license.IsNoticeFileName("/a/NOTICE.txt"))
+	require.False(t, cage_license.IsNoticeFileName("/a/LICENSE"))
+}
+
+func TestIdentify(t *testing.T) {
+	cases := map[string]string{
+		"MIT License\n\nPermission is hereby granted, free of charge, to any person obtaining a copy":               "MIT",
+		"                                 Apache License\n                           Version 2.0, January 2004":     "Apache-2.0",
+		"Redistribution and use in source and binary forms, with or without\nmodification, are permitted":           "BSD-2-Clause",
+		"Redistribution and use in source and binary forms ...\n* Neither the name of Google Inc. nor":              "BSD-3-Clause",
+		"                    GNU AFFERO GENERAL PUBLIC LICENSE\n                       Version 3, 19 November 2007": "AGPL-3.0",
+		"                    GNU GENERAL PUBLIC LICENSE\n                       Version 2, June 1991":               "GPL-2.0",
+		"                   GNU LESSER GENERAL PUBLIC LICENSE\n                       Version 3, 29 June 2007":      "LGPL-3.0",
+		"Mozilla Public License Version 2.0\n==================================":                                    "MPL-2.0",
+		"This is free and unencumbered software released into the public domain.":                                   "Unlicense",
+		"// SPDX-License-Identifier: GPL-2.0-only WITH Classpath-exception-2.0\n":                                   "GPL-2.0-only WITH Classpath-exception-2.0",
+		"All rights reserved.": cage_license.Unknown,
+	}
+	for text, expected := range cases {
+		require.Exactly(t, expected, cage_license.Identify([]byte(text)), text)
+	}
+}
+
+func TestIdentifyHeader(t *testing.T) {
+	cases := map[string]string{
+		"spdx.go":           "Apache-2.0 OR MIT",
+		"bsd_style.go":      "BSD-3-Clause",
+		"copyright_only.go": cage_license.Unknown,
+		"no_header.go":      "",
+	}
+	for name, expected := range cases {
+		_, absPath := cage_file.FixturePath(t, name)
+		id, err := cage_license.IdentifyHeader(absPath)
+		require.NoError(t, err)
+		require.Exactly(t, expected, id, name)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	require.True(t, cage_license.Match("AGPL-*", "AGPL-3.0"))
+	require.True(t, cage_license.Match("agpl-3.0", "AGPL-3.0"))
+	require.True(t, cage_license.Match("unknown", cage_license.Unknown))
+	require.False(t, cage_license.Match("GPL-*", "LGPL-2.1"))
+	require.False(t, cage_license.Match("OR", "Apache-2.0 OR MIT"))
+
+	// OR: only if every alternative matches.
+	require.False(t, cage_license.Match("MIT", "Apache-2.0 OR MIT"))
+	require.False(t, cage_license.Match("GPL-*", "(GPL-2.0 AND MIT) OR Apache-2.0"))
+	require.True(t, cage_license.Match("GPL-*", "GPL-2.0 OR GPL-3.0"))
+	require.Exactly(t, []string{"GPL-*", "MIT"}, cage_license.MatchAny([]string{"GPL-*", "MIT", "BSD-*"}, "GPL-3.0 OR MIT"))
+	require.Nil(t, cage_license.MatchAny([]string{"GPL-*", "BSD-*"}, "GPL-3.0 OR MIT"))
+
+	// AND/WITH: if any term matches.
+	require.True(t, cage_license.Match("MIT", "Apache-2.0 AND MIT"))
+	require.True(t, cage_license.Match("GPL-*", "GPL-2.0 WITH Classpath-exception-2.0"))
+	require.True(t, cage_license.Match("classpath-*", "GPL-2.0 WITH Classpath-exception-2.0"))
+	require.True(t, cage_license.Match("GPL-*", "(GPL-2.0 AND MIT) OR (Apache-2.0 AND GPL-3.0)"))
+	require.False(t, cage_license.Match("LGPL-*", "Apache-2.0 AND MIT"))
+}
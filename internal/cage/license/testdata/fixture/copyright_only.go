@@ -0,0 +1,3 @@
+// Copyright 2019 Example Authors
+
+package fixture
@@ -0,0 +1,4 @@
+// Copyright 2019 Example Authors
+// SPDX-License-Identifier: Apache-2.0 OR MIT
+
+package fixture
@@ -7,6 +7,7 @@
 package stage
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -21,10 +22,14 @@ import (
 	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
 	cage_file_matcher "github.com/codeactual/transplant/internal/cage/os/file/matcher"
 	cage_strings "github.com/codeactual/transplant/internal/cage/strings"
+	cage_diff "github.com/codeactual/transplant/internal/cage/text/diff"
 )
 
 const (
 	newDirMode = 0755
+
+	// DefaultDiffMaxBytes is the CopyConfig.DiffMaxBytes value used if none is selected.
+	DefaultDiffMaxBytes = 1024 * 1024
 )
 
 type Plan struct {
@@ -37,6 +42,11 @@ type Plan struct {
 
 	// Remove holds relative paths of files that do not exist in the stage and but do in the destination.
 	Remove *cage_strings.Set
+
+	// Diff indexes unified diffs by the absolute paths of the added, overwritten, and removed destination files.
+	//
+	// It is only populated if CopyConfig.Diff is selected.
+	Diff map[string]string
 }
 
 type Stage struct {
@@ -67,6 +77,7 @@ func NewPlan() *Plan {
 		Add:       cage_strings.NewSet(),
 		Overwrite: cage_strings.NewSet(),
 		Remove:    cage_strings.NewSet(),
+		Diff:      make(map[string]string),
 	}
 }
 
@@ -105,6 +116,13 @@ type CopyConfig struct {
 	// If it is nil, all destination files may be removed.
 	RemovableFiles *cage_strings.Set
 
+	// Diff selects the generation of Plan.Diff.
+	Diff bool
+
+	// DiffMaxBytes is the size limit of files which Plan.Diff compares line by line. Larger files
+	// are only reported as different. If it is zero, DefaultDiffMaxBytes is used.
+	DiffMaxBytes int64
+
 	DryRun bool
 }
 
@@ -241,6 +259,22 @@ func (s *Stage) Copy(dstPath string, cfgs ...CopyConfig) (plan *Plan, errs []err
 		dstFilesSet.Remove(toFilename)
 		dstDirsSet.Remove(filepath.Dir(toFilename))
 
+		// Compare the files before the destination is overwritten.
+		if config.Diff && !s.overwriteSkips.Contains(toFilename) {
+			oldFilename := ""
+			if exists {
+				oldFilename = toFilename
+			}
+			d, err := diffFile(dstPath, stageRelPath, oldFilename, s.Path(stageRelPath), config.DiffMaxBytes)
+			if err != nil {
+				cage_errors.Append(&errs, errors.WithStack(err))
+				continue
+			}
+			if d != "" {
+				plan.Diff[toFilename] = d
+			}
+		}
+
 		if config.DryRun {
 			continue
 		}
@@ -280,6 +314,22 @@ func (s *Stage) Copy(dstPath string, cfgs ...CopyConfig) (plan *Plan, errs []err
 	for _, dstFilename := range dstFilesSet.SortedSlice() {
 		plan.Remove.Add(dstFilename)
 
+		if config.Diff {
+			relPath, err := filepath.Rel(dstPath, dstFilename)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to get path of [%s] relative to [%s]", dstFilename, dstPath))
+				continue
+			}
+			d, err := diffFile(dstPath, relPath, dstFilename, "", config.DiffMaxBytes)
+			if err != nil {
+				errs = append(errs, errors.WithStack(err))
+				continue
+			}
+			if d != "" {
+				plan.Diff[dstFilename] = d
+			}
+		}
+
 		if config.DryRun {
 			continue
 		}
@@ -319,6 +369,55 @@ func (s *Stage) Copy(dstPath string, cfgs ...CopyConfig) (plan *Plan, errs []err
 	return plan, []error{}
 }
 
+// diffFile returns the unified diff from the old file to the new file, labeled with their path relative
+// to the destination root. An empty name selects an absent file, e.g. the old file of an addition.
+//
+// Binary files, and files larger than maxBytes, are only reported as different.
+func diffFile(dstPath, relPath, oldName, newName string, maxBytes int64) (string, error) {
+	if maxBytes == 0 {
+		maxBytes = DefaultDiffMaxBytes
+	}
+
+	oldLabel, newLabel := "a/"+relPath, "b/"+relPath
+	if oldName == "" {
+		oldLabel = "/dev/null"
+	}
+	if newName == "" {
+		newLabel = "/dev/null"
+	}
+
+	var contents [][]byte
+	for _, name := range []string{oldName, newName} {
+		if name == "" {
+			contents = append(contents, nil)
+			continue
+		}
+
+		fi, err := os.Stat(name)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to stat [%s]", name)
+		}
+		if fi.Size() > maxBytes {
+			return fmt.Sprintf("Files %s and %s differ (larger than %d bytes)\n", oldLabel, newLabel, maxBytes), nil
+		}
+
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read [%s]", name)
+		}
+		contents = append(contents, b)
+	}
+
+	if cage_diff.IsBinary(contents[0]) || cage_diff.IsBinary(contents[1]) {
+		if bytes.Equal(contents[0], contents[1]) {
+			return "", nil
+		}
+		return fmt.Sprintf("Binary files %s and %s differ\n", oldLabel, newLabel), nil
+	}
+
+	return cage_diff.Unified(oldLabel, newLabel, contents[0], contents[1], cage_diff.DefaultContext), nil
+}
+
 // CreateFileAll creates a new stage file and all non-existent ancestor directories.
 func (s *Stage) CreateFileAll(relPath string, fileMode, dirMode os.FileMode) (*os.File, error) {
 	absPath := s.Path(relPath)
@@ -0,0 +1,70 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package diff generates line-based unified diffs.
+package diff
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	// DefaultContext is the number of unchanged lines shown around each change, as in `diff -u`.
+	DefaultContext = 3
+
+	// binarySniffLen is the number of leading bytes searched for a NUL byte by IsBinary, as in git.
+	binarySniffLen = 8000
+
+	noNewline = "\\ No newline at end of file\n"
+)
+
+// IsBinary returns true if the content appears to be binary, i.e. it contains a NUL byte near its start.
+func IsBinary(b []byte) bool {
+	if len(b) > binarySniffLen {
+		b = b[:binarySniffLen]
+	}
+	return bytes.IndexByte(b, 0) != -1
+}
+
+// Unified returns the unified diff, labeled with the names, which changes the old text into the new text.
+//
+// It returns an empty string if the texts are equal. The context selects how many unchanged lines
+// are shown around each change.
+func Unified(oldName, newName string, oldText, newText []byte, context int) string {
+	if bytes.Equal(oldText, newText) {
+		return ""
+	}
+
+	// The error is always nil because the output is written to a strings.Builder.
+	d, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(oldText),
+		B:        splitLines(newText),
+		FromFile: oldName,
+		ToFile:   newName,
+		Context:  context,
+	})
+	return d
+}
+
+// splitLines returns the lines of the text, each with its trailing newline.
+//
+// A last line without a newline is followed by the "\ No newline at end of file" marker, as in `diff -u`,
+// so that it also differs from the same line with a newline.
+func splitLines(text []byte) []string {
+	if len(text) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(text), "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n" + noNewline
+	}
+	return lines
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2019 The CodeActual Go Environment Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package diff_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	cage_diff "github.com/codeactual/transplant/internal/cage/text/diff"
+)
+
+func TestUnified(t *testing.T) {
+	numbered := func(from, to int, replace map[int]string) string {
+		var b strings.Builder
+		for n := from; n <= to; n++ {
+			if line, ok := replace[n]; ok {
+				b.WriteString(line)
+				continue
+			}
+			b.WriteString(strconv.Itoa(n) + "\n")
+		}
+		return b.String()
+	}
+
+	cases := []struct {
+		name     string
+		old, new string
+		expected string
+	}{
+		{
+			name:     "equal",
+			old:      "a\nb\n",
+			new:      "a\nb\n",
+			expected: "",
+		},
+		{
+			name:     "add file",
+			old:      "",
+			new:      "a\nb\n",
+			expected: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:     "remove file",
+			old:      "a\n",
+			new:      "",
+			expected: "--- a\n+++ b\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			name:     "change middle line",
+			old:      "a\nb\nc\n",
+			new:      "a\nB\nc\n",
+			expected: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:     "insert and delete",
+			old:      "a\nb\nc\nd\n",
+			new:      "a\nc\nd\ne\n",
+			expected: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n a\n-b\n c\n d\n+e\n",
+		},
+		{
+			name:     "no newline at end",
+			old:      "a\nb",
+			new:      "a\nb\n",
+			expected: "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name: "separate hunks",
+			old:  numbered(1, 20, nil),
+			new:  numbered(1, 20, map[int]string{2: "two\n", 18: "eighteen\n"}),
+			expected: "--- a\n+++ b\n" +
+				"@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n" +
+				"@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n",
+		},
+		{
+			name: "merged hunks",
+			old:  numbered(1, 12, nil),
+			new:  numbered(1, 12, map[int]string{3: "three\n", 9: "nine\n"}),
+			expected: "--- a\n+++ b\n" +
+				"@@ -1,12 +1,12 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n 7\n 8\n-9\n+nine\n 10\n 11\n 12\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Exactly(t, c.expected, cage_diff.Unified("a", "b", []byte(c.old), []byte(c.new), cage_diff.DefaultContext))
+		})
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	require.False(t, cage_diff.IsBinary([]byte("text\n")))
+	require.True(t, cage_diff.IsBinary([]byte("te\x00xt")))
+	require.False(t, cage_diff.IsBinary(append([]byte(strings.Repeat("a", 8000)), 0)))
+}
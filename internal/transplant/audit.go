@@ -8,11 +8,16 @@ package transplant
 
 import (
 	"fmt"
+	"go/ast"
 	"go/build"
+	"go/parser"
+	"go/token"
 	"io"
 	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,6 +25,7 @@ import (
 	std_packages "golang.org/x/tools/go/packages"
 
 	"github.com/codeactual/transplant/cmd/transplant/why"
+	cage_build "github.com/codeactual/transplant/internal/cage/go/build"
 	cage_pkgs "github.com/codeactual/transplant/internal/cage/go/packages"
 	cage_dag "github.com/codeactual/transplant/internal/cage/graph/dag"
 	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
@@ -75,6 +81,9 @@ type FromFinderInput struct {
 
 	// ReplaceString specs match subsets of Include matches which are targeted for string replacements.
 	ReplaceString ReplaceStringSpec
+
+	// ExcludeBuildTags omits Go files whose build constraints require one of the tags.
+	ExcludeBuildTags []string
 }
 
 // FromFinderOutput holds file/directory absolute paths found via Ops.From.*FilePath/Ops.Dep.From.*FilePath
@@ -89,6 +98,8 @@ type FromFinderOutput struct {
 	InspectIgnoreDirs *cage_strings.Set
 
 	ReplaceStringFiles *ReplaceStringFiles
+
+	ExcludeBuildTagFiles *cage_strings.Set
 }
 
 func NewFromFinderOutput() *FromFinderOutput {
@@ -102,6 +113,8 @@ func NewFromFinderOutput() *FromFinderOutput {
 		InspectIgnoreDirs: cage_strings.NewSet(),
 
 		ReplaceStringFiles: NewReplaceStringFiles(),
+
+		ExcludeBuildTagFiles: cage_strings.NewSet(),
 	}
 }
 
@@ -165,6 +178,22 @@ type Audit struct {
 	// They are filtered based on having an ancestor Go directory during the copy operation.
 	DepGoDescendantFiles *cage_strings.Set
 
+	// LocalEmbedFiles holds absolute paths of files matched by //go:embed directives in LocalGoFiles
+	// and LocalGoTestFiles.
+	LocalEmbedFiles *cage_strings.Set
+
+	// DepEmbedFiles holds absolute paths of files matched by //go:embed directives in UsedDepGoFiles
+	// and DepGoTestFiles.
+	DepEmbedFiles *cage_strings.Set
+
+	// LocalOtherFiles holds absolute paths of non-Go source files, e.g. cgo/assembly/.syso files, of the
+	// packages of LocalGoFiles and LocalGoTestFiles.
+	LocalOtherFiles *cage_strings.Set
+
+	// DepOtherFiles holds absolute paths of non-Go source files, e.g. cgo/assembly/.syso files, of the
+	// packages of UsedDepGoFiles and DepGoTestFiles.
+	DepOtherFiles *cage_strings.Set
+
 	// DirectDepImportsIntoLocal enumerates the Ops.Dep.From packages directly imported into Ops.From packages.
 	DirectDepImportsIntoLocal *cage_pkgs.ImportList
 
@@ -214,17 +243,29 @@ type Audit struct {
 	// DepReplaceStringFiles holds Ops.Dep.From.ReplaceString matches.
 	DepReplaceStringFiles *ReplaceStringFiles
 
+	// ExcludeBuildTagFiles holds absolute paths of Ops.From.FilePath and Ops.Dep.From.FilePath Go files,
+	// and non-Go source files of copied packages, which were omitted because their build constraints require
+	// one of the Ops.ExcludeBuildTags.
+	ExcludeBuildTagFiles *cage_strings.Set
+
+	// SubstitutedImportPaths holds the Ops.Substitute.ImportPath values of packages directly/transitively
+	// imported by LocalGoFiles. Their files are replaced in the copy by the Ops.Substitute.FilePath files.
+	SubstitutedImportPaths *cage_strings.Set
+
 	// Progress receives messages describing analysis steps and runtimes.
 	Progress io.Writer
 
+	// Events if non-nil receives machine-readable step, counter, and unsupported trait events.
+	Events *EventLog
+
 	// WhyLog if non-nil will receive updates which support `{egress,ingress} file` queries.
 	WhyLog why.Log
 
-	// AllImportPathReplacer replaces Ops.From.LocalImportPath and all Ops.Dep.From.ImportPath substrings
-	// with their To counterparts.
+	// AllImportPathReplacer replaces Ops.From.LocalImportPath and all Ops.Dep.From.ImportPath and
+	// Ops.ImportMap.From substrings with their To counterparts.
 	AllImportPathReplacer *cage_strings.ReplaceSet
 
-	// DepImportPathReplacer replaces Ops.Dep.From.ImportPath substrings with its To counterpart.
+	// DepImportPathReplacer replaces Ops.Dep.From.ImportPath and Ops.ImportMap.From substrings with their To counterparts.
 	DepImportPathReplacer *cage_strings.ReplaceSet
 
 	// inspectedDirToDep indexes Dep configs by the directories which they selected for inclusion via
@@ -327,6 +368,12 @@ func (a *Audit) init() {
 	a.DepGoDescendantFiles = cage_strings.NewSet()
 	a.LocalGoDescendantFiles = cage_strings.NewSet()
 
+	a.DepEmbedFiles = cage_strings.NewSet()
+	a.LocalEmbedFiles = cage_strings.NewSet()
+
+	a.DepOtherFiles = cage_strings.NewSet()
+	a.LocalOtherFiles = cage_strings.NewSet()
+
 	a.DepInspectDirs = cage_strings.NewSet()
 	a.LocalInspectDirs = cage_strings.NewSet()
 
@@ -352,6 +399,10 @@ func (a *Audit) init() {
 	a.LocalReplaceStringFiles = NewReplaceStringFiles()
 	a.DepReplaceStringFiles = NewReplaceStringFiles()
 
+	a.ExcludeBuildTagFiles = cage_strings.NewSet()
+
+	a.SubstitutedImportPaths = cage_strings.NewSet()
+
 	a.inspectedDirToDep = make(map[string]*Dep)
 
 	a.inspectIgnoreDirs = cage_strings.NewSet()
@@ -387,8 +438,11 @@ func (a *Audit) Generate() (errs []error) {
 		{title: "validate files", f: a.validateFiles},
 		{title: "group Ops.From files", f: a.groupLocalGoFiles},
 		{title: "collect transitive Ops.Dep global use by Ops.From", f: a.findDepUsage, ingressSkip: true},
+		{title: "validate Ops.Substitute packages used by copied files", f: a.validateSubstitutes, ingressSkip: true},
 		{title: "find Ops.From.GoDescendant files", f: a.findLocalGoDescendantFiles},
 		{title: "find Ops.Dep.From.GoDescendant files", f: a.findDepGoDescendantFiles},
+		{title: "find go:embed files", f: a.findEmbedFiles},
+		{title: "find cgo/assembly files", f: a.findOtherFiles},
 		{title: "find origin files eligible for removal during ingress", f: a.findIngressRemovableFiles, egressSkip: true},
 	}
 
@@ -401,6 +455,7 @@ func (a *Audit) Generate() (errs []error) {
 		}
 
 		fmt.Fprintf(a.Progress, "audit [%s] ... ", steps[n].title)
+		a.Events.StepStart(EventPhaseAudit, steps[n].title)
 
 		start := time.Now()
 		if errs := steps[n].f(); len(errs) > 0 {
@@ -409,11 +464,51 @@ func (a *Audit) Generate() (errs []error) {
 		steps[n].time = time.Since(start)
 
 		fmt.Fprintf(a.Progress, "%s\n", steps[n].time)
+		a.Events.StepEnd(EventPhaseAudit, steps[n].title, steps[n].time)
 	}
 
+	a.emitCounters()
+
 	return []error{}
 }
 
+// emitCounters emits an EventCounter for the size of each result which may help to track changes to the
+// operation's scope over time.
+func (a *Audit) emitCounters() {
+	if a.Events == nil {
+		return
+	}
+
+	// Omit the root and package directory vertices.
+	var usedDepGlobalIds int
+	for _, v := range a.DepGlobalIdUsageDag.Vertices() {
+		if a.graphNodeKind(v.(cage_pkgs.GlobalId)) == GraphNodeGlobal {
+			usedDepGlobalIds++
+		}
+	}
+
+	counters := []struct {
+		name  string
+		value int
+	}{
+		{name: "LocalGoFiles", value: a.LocalGoFiles.Len()},
+		{name: "LocalGoTestFiles", value: a.LocalGoTestFiles.Len()},
+		{name: "AllDepGoFiles", value: a.AllDepGoFiles.Len()},
+		{name: "UsedDepGoFiles", value: a.UsedDepGoFiles.Len()},
+		{name: "DepGoTestFiles", value: a.DepGoTestFiles.Len()},
+		{name: "UsedDepImportPaths", value: a.UsedDepImportPaths.Len()},
+		{name: "UsedDepGlobalIds", value: usedDepGlobalIds},
+		{name: "ExcludeBuildTagFiles", value: a.ExcludeBuildTagFiles.Len()},
+		{name: "IngressRemovableFiles", value: a.IngressRemovableFiles.Len()},
+		{name: "UnconfiguredDirs", value: len(a.UnconfiguredDirs)},
+		{name: "UnsupportedTraits", value: len(a.inspector.UnsupportedTraits)},
+	}
+
+	for n := range counters {
+		a.Events.Emit(Event{Type: EventCounter, Phase: EventPhaseAudit, Name: counters[n].name, Value: &counters[n].value})
+	}
+}
+
 // finalizeConfig performs Config.ReadFile-like checks and finalization that we want to
 // only perform if an operation is actually attempted, rather than forcing the CLI
 // to display all issues across all configured operations at the same time.
@@ -464,6 +559,23 @@ func (a *Audit) finalizeConfig() (errs []error) {
 		}
 	}
 
+	// Substitutes are only copied during egress.
+	if !a.op.Ingress {
+		for _, sub := range a.op.Substitute {
+			if _, _, found := SubstituteDirs(a.op, sub); !found {
+				errs = append(errs, errors.Errorf("Ops[%s].Substitute[%s].ImportPath is not under any Ops.Dep.From.FilePath", a.op.Id, sub.ImportPath))
+			}
+
+			subFilePath := FromAbs(a.op, sub.FilePath)
+			exists, _, existsErr := cage_file.Exists(subFilePath)
+			if existsErr != nil {
+				errs = append(errs, errors.Wrapf(existsErr, "failed to check if Ops[%s].Substitute[%s].FilePath exists", a.op.Id, sub.ImportPath))
+			} else if !exists {
+				errs = append(errs, errors.Errorf("Ops[%s].Substitute[%s].FilePath not found [%s]", a.op.Id, sub.ImportPath, subFilePath))
+			}
+		}
+	}
+
 	// Ops.From / Ops.Dep.From duplicate/overlap checks
 
 	// Disallow Ops.Dep.From at/under Ops.From during egress because it indicates there's no distinction between
@@ -507,11 +619,11 @@ func (a *Audit) finalizeConfig() (errs []error) {
 				depOverToFilePath.Add(subject.To.FilePath)
 			}
 
-			if !(depOverFromFilePath.Contains(subject.From.FilePath) || depOverFromFilePath.Contains(other.From.FilePath)) && strings.HasPrefix(subject.From.FilePath, other.From.FilePath) {
+			if !(depOverFromFilePath.Contains(subject.From.FilePath) || depOverFromFilePath.Contains(other.From.FilePath)) && strings.HasPrefix(subject.From.FilePath, other.From.FilePath+string(filepath.Separator)) {
 				errs = append(errs, errors.Errorf("Ops[%s].Dep.From.FilePath [%s] overlaps with another [%s]", a.op.Id, subject.From.FilePath, other.From.FilePath))
 				depOverFromFilePath.AddSlice([]string{subject.From.FilePath, other.From.FilePath})
 			}
-			if !(depOverToFilePath.Contains(subject.To.FilePath) || depOverToFilePath.Contains(other.To.FilePath)) && strings.HasPrefix(subject.To.FilePath, other.To.FilePath) {
+			if !(depOverToFilePath.Contains(subject.To.FilePath) || depOverToFilePath.Contains(other.To.FilePath)) && strings.HasPrefix(subject.To.FilePath, other.To.FilePath+string(filepath.Separator)) {
 				errs = append(errs, errors.Errorf("Ops[%s].Dep.To.FilePath [%s] overlaps with another [%s]", a.op.Id, subject.To.FilePath, other.To.FilePath))
 				depOverToFilePath.AddSlice([]string{subject.To.FilePath, other.To.FilePath})
 			}
@@ -661,6 +773,8 @@ func (a *Audit) findLocalFiles() (errs []error) {
 		Include:       a.localConfigMatcher,
 		Exclude:       excludes,
 		ReplaceString: a.op.From.ReplaceString,
+
+		ExcludeBuildTags: a.op.ExcludeBuildTags,
 	})
 
 	if len(errs) > 0 {
@@ -677,6 +791,11 @@ func (a *Audit) findLocalFiles() (errs []error) {
 		}
 	}
 
+	for _, f := range paths.ExcludeBuildTagFiles.SortedSlice() {
+		a.logFileActivity(f, fmt.Sprintf("excluded by Op.ExcludeBuildTags %s", a.op.ExcludeBuildTags))
+	}
+	a.ExcludeBuildTagFiles.AddSet(paths.ExcludeBuildTagFiles)
+
 	for _, f := range paths.CopyOnlyFiles.Slice() {
 		a.logFileActivity(f, "matched Op.From.CopyOnlyFilePath")
 		a.LocalIncludeDirs.Add(filepath.Dir(f))
@@ -769,6 +888,147 @@ func (a *Audit) findDepGoDescendantFiles() (errs []error) {
 	return []error{}
 }
 
+// findEmbedFiles collects the files matched by //go:embed directives in copied Go files so that
+// they're copied even if no CopyOnlyFilePath/GoDescendantFilePath pattern selects them.
+//
+// A pattern which matches no files is an error because the copy would fail to compile.
+func (a *Audit) findEmbedFiles() (errs []error) {
+	find := func(goFiles *cage_strings.Set, embedFiles *cage_strings.Set, configType string) {
+		for _, goFile := range goFiles.SortedSlice() {
+			patterns, err := cage_build.ReadEmbedPatterns(goFile)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to read go:embed patterns in %s [%s]", configType, goFile))
+				continue
+			}
+
+			for _, pattern := range patterns {
+				matches, err := cage_build.MatchEmbedPattern(filepath.Dir(goFile), pattern)
+				if err != nil {
+					errs = append(errs, errors.Wrapf(err, "failed to match go:embed pattern [%s] in %s [%s]", pattern, configType, goFile))
+					continue
+				}
+
+				if len(matches) == 0 {
+					errs = append(errs, errors.Errorf("go:embed pattern [%s] in %s [%s] matched no files", pattern, configType, goFile))
+					continue
+				}
+
+				for _, m := range matches {
+					// Go files selected by GoFilePath are already copied (and refactored).
+					if a.isLocalFile(m) || a.UsedDepGoFiles.Contains(m) || a.DepGoTestFiles.Contains(m) {
+						continue
+					}
+
+					embedFiles.Add(m)
+					a.logFileActivity(m, fmt.Sprintf("matched go:embed pattern [%s] in [%s]", pattern, goFile))
+				}
+			}
+		}
+	}
+
+	find(a.LocalGoFiles, a.LocalEmbedFiles, "Ops.From file")
+	find(a.LocalGoTestFiles, a.LocalEmbedFiles, "Ops.From file")
+
+	if !a.op.Ingress {
+		find(a.UsedDepGoFiles, a.DepEmbedFiles, "Ops.Dep file")
+		find(a.DepGoTestFiles, a.DepEmbedFiles, "Ops.Dep file")
+	}
+
+	return errs
+}
+
+// otherFileExts holds the extensions of non-Go source files which go/build accepts in a package directory,
+// e.g. for cgo, assembly, and prebuilt objects.
+var otherFileExts = map[string]bool{
+	".c": true, ".cc": true, ".cpp": true, ".cxx": true, ".m": true,
+	".h": true, ".hh": true, ".hpp": true, ".hxx": true,
+	".f": true, ".F": true, ".for": true, ".f90": true,
+	".s": true, ".S": true, ".sx": true,
+	".swig": true, ".swigcxx": true,
+	".syso": true,
+}
+
+// findOtherFiles collects the non-Go source files of the packages of copied Go files so that the latter
+// compile in the copy without the need for CopyOnlyFilePath patterns.
+//
+// Package.OtherFiles from x/tools/go/packages only lists the files of the current GOOS/GOARCH, so each
+// package directory is also read for files of other platforms, e.g. "foo_arm64.s". Files whose build
+// constraints require one of the Ops.ExcludeBuildTags are omitted, as Go files are.
+func (a *Audit) findOtherFiles() (errs []error) {
+	add := func(f string, otherFiles *cage_strings.Set, msg string) {
+		if otherFiles.Contains(f) {
+			return
+		}
+		otherFiles.Add(f)
+		a.logFileActivity(f, msg)
+	}
+
+	find := func(goFiles []string, otherFiles *cage_strings.Set, configType string) {
+		dirs := cage_strings.NewSet()
+		for _, f := range goFiles {
+			dirs.Add(filepath.Dir(f))
+		}
+
+		for _, dir := range dirs.SortedSlice() {
+			for _, pkg := range a.inspector.Pkgs[dir] {
+				for _, f := range pkg.OtherFiles {
+					if filepath.Dir(f) != dir { // e.g. GOCACHE files
+						continue
+					}
+					add(f, otherFiles, fmt.Sprintf("found as a non-Go source file of %s package [%s]", configType, pkg.PkgPath))
+				}
+			}
+
+			infos, err := ioutil.ReadDir(dir)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to read %s package dir [%s]", configType, dir))
+				continue
+			}
+
+			for _, fi := range infos {
+				if fi.IsDir() || !otherFileExts[filepath.Ext(fi.Name())] {
+					continue
+				}
+
+				f := filepath.Join(dir, fi.Name())
+				if otherFiles.Contains(f) {
+					continue
+				}
+
+				// Prebuilt objects have no build constraint header.
+				if filepath.Ext(f) != ".syso" {
+					requiresTag, tagErr := cage_build.FileRequiresTag(f, a.op.ExcludeBuildTags...)
+					if tagErr != nil {
+						errs = append(errs, errors.Wrapf(tagErr, "failed to evaluate ExcludeBuildTags against [%s]", f))
+						continue
+					}
+					if requiresTag {
+						a.ExcludeBuildTagFiles.Add(f)
+						a.logFileActivity(f, fmt.Sprintf("excluded by Op.ExcludeBuildTags %s", a.op.ExcludeBuildTags))
+						continue
+					}
+				}
+
+				add(f, otherFiles, fmt.Sprintf("found as a non-Go source file, for another platform, of %s package dir [%s]", configType, dir))
+			}
+		}
+	}
+
+	find(cage_strings.NewSet().AddSet(a.LocalGoFiles, a.LocalGoTestFiles).SortedSlice(), a.LocalOtherFiles, "Ops.From")
+
+	if !a.op.Ingress {
+		var depGoFiles []string
+		for _, f := range cage_strings.NewSet().AddSet(a.UsedDepGoFiles, a.DepGoTestFiles).SortedSlice() {
+			if !a.isLocalFile(f) {
+				depGoFiles = append(depGoFiles, f)
+			}
+		}
+		find(depGoFiles, a.DepOtherFiles, "Ops.Dep")
+	}
+
+	return errs
+}
+
 // findIngressRemovableFiles collects the absolute paths of files/dirs in the origin which are
 // eligible for removal when propagating deletions made to the copy.
 //
@@ -784,6 +1044,8 @@ func (a *Audit) findIngressRemovableFiles() (errs []error) {
 	paths, errs := a.findFiles(FromFinderInput{
 		BaseFilePath: FromAbs(a.origOp, a.origOp.From.LocalFilePath),
 		Include:      a.origLocalConfigMatcher,
+
+		ExcludeBuildTags: a.origOp.ExcludeBuildTags,
 	})
 
 	if len(errs) > 0 {
@@ -822,6 +1084,8 @@ func (a *Audit) findDepFiles() (errs []error) {
 			Include:       a.depConfigMatcher[dep.From.FilePath],
 			Exclude:       []*ConfigMatcher{a.localConfigMatcher},
 			ReplaceString: dep.From.ReplaceString,
+
+			ExcludeBuildTags: a.op.ExcludeBuildTags,
 		})
 
 		if len(errs) > 0 {
@@ -838,6 +1102,11 @@ func (a *Audit) findDepFiles() (errs []error) {
 			}
 		}
 
+		for _, f := range paths.ExcludeBuildTagFiles.SortedSlice() {
+			a.logFileActivity(f, fmt.Sprintf("excluded by Op.ExcludeBuildTags %s", a.op.ExcludeBuildTags))
+		}
+		a.ExcludeBuildTagFiles.AddSet(paths.ExcludeBuildTagFiles)
+
 		for _, f := range paths.CopyOnlyFiles.SortedSlice() {
 			a.logFileActivity(f, "matched Dep.From.CopyOnlyFilePath")
 		}
@@ -957,6 +1226,18 @@ func (a *Audit) findFiles(cfg FromFinderInput) (paths *FromFinderOutput, errs []
 			continue
 		}
 
+		if len(cfg.ExcludeBuildTags) > 0 && cage_filepath.IsGoFile(f.AbsPath) {
+			requiresTag, tagErr := cage_build.FileRequiresTag(f.AbsPath, cfg.ExcludeBuildTags...)
+			if tagErr != nil {
+				errs = append(errs, errors.Wrapf(tagErr, "failed to evaluate ExcludeBuildTags against [%s]", f.AbsPath))
+				continue
+			}
+			if requiresTag {
+				paths.ExcludeBuildTagFiles.Add(f.AbsPath)
+				continue
+			}
+		}
+
 		allFiles = append(allFiles, f)
 
 		d := filepath.Dir(f.AbsPath)
@@ -1183,6 +1464,17 @@ func (a *Audit) findUsedDepPkgs() (errs []error) {
 			}
 
 			for _, importedPath := range importedPaths.SortedSlice() {
+				// Ops.ImportMap packages are provided by a published module, like third-party packages.
+				if _, mapped := a.op.MappedImport(importedPath); mapped {
+					continue
+				}
+
+				// Ops.Substitute packages, and their dependencies, are replaced by the substitute's files.
+				if _, substituted := a.op.SubstituteOf(importedPath); substituted {
+					a.SubstitutedImportPaths.Add(importedPath)
+					continue
+				}
+
 				importedPkgs, importedPkgErr := a.pkgCache.LoadImportPathWithBuild(importedPath, dequeuedPkg.Dir, 0)
 				if importedPkgErr != nil {
 					errs = append(errs, errors.Wrapf(
@@ -1251,9 +1543,17 @@ func (a *Audit) inspectGoFiles() (errs []error) {
 		}
 	}
 
+	// Files which require an Ops.ExcludeBuildTags tag are already omitted from the directory lists,
+	// but prevent GOFLAGS from enabling the tags so that the loader also omits them.
+	var inspectEnv []string
+	if len(a.op.ExcludeBuildTags) > 0 {
+		inspectEnv = excludeGoflagsTags(os.Environ(), a.op.ExcludeBuildTags)
+	}
+
 	a.inspector = cage_pkgs.NewInspector(
 		cage_pkgs.NewConfig(&std_packages.Config{
 			Dir:   FromAbs(a.op, a.op.From.LocalFilePath),
+			Env:   inspectEnv,
 			Mode:  cage_pkgs.LoadSyntax,
 			Tests: inspectTests,
 		}),
@@ -1274,6 +1574,8 @@ func (a *Audit) inspectGoFiles() (errs []error) {
 
 func (a *Audit) validateFiles() (errs []error) {
 	for _, t := range a.inspector.UnsupportedTraits {
+		a.Events.emitTrait(t)
+
 		// Currently all avoided traits are related to their complications for pruning. Tolerate them
 		// when we can in order to support a wider variety of codebases.
 		if a.isLocalFile(t.FileOrDir) || a.LocalIncludeDirs.Contains(t.FileOrDir) {
@@ -1420,6 +1722,163 @@ func (a *Audit) collectDirectUsageOfDepGlobals() (errs []error) {
 	return errs
 }
 
+// validateSubstitutes verifies that each Ops.Substitute.FilePath package, which substitutes a package imported by
+// LocalGoFiles, has the same name as the substituted package and declares every global which the copied files,
+// i.e. LocalGoFiles, LocalGoTestFiles, UsedDepGoFiles, and DepGoTestFiles, use from the latter.
+//
+// It also omits the substituted package's Ops.Dep.From.CopyOnlyFilePath matches from the copy.
+func (a *Audit) validateSubstitutes() (errs []error) {
+	// Files of substituted packages are not copied, so their uses do not need to be satisfied.
+	substitutedDirs := cage_strings.NewSet()
+	for _, importPath := range a.SubstitutedImportPaths.SortedSlice() {
+		sub, _ := a.op.SubstituteOf(importPath)
+		fromDir, _, _ := SubstituteDirs(a.op, sub)
+		substitutedDirs.Add(fromDir)
+	}
+
+	var copiedFiles []string
+	for _, f := range cage_strings.NewSet().AddSet(a.LocalGoFiles, a.LocalGoTestFiles, a.UsedDepGoFiles, a.DepGoTestFiles).SortedSlice() {
+		if !substitutedDirs.Contains(filepath.Dir(f)) {
+			copiedFiles = append(copiedFiles, f)
+		}
+	}
+
+	for _, importPath := range a.SubstitutedImportPaths.SortedSlice() {
+		sub, _ := a.op.SubstituteOf(importPath)
+		fromDir, _, _ := SubstituteDirs(a.op, sub)
+		subDir := FromAbs(a.op, sub.FilePath)
+
+		pkgName, _, err := declaredGlobals(fromDir)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to inspect Ops[%s].Substitute[%s] package", a.op.Id, importPath))
+			continue
+		}
+		subPkgName, subGlobals, err := declaredGlobals(subDir)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to inspect Ops[%s].Substitute[%s].FilePath package", a.op.Id, importPath))
+			continue
+		}
+		if subPkgName != pkgName {
+			errs = append(errs, errors.Errorf(
+				"Ops[%s].Substitute[%s].FilePath [%s] declares package [%s], expected [%s]",
+				a.op.Id, importPath, subDir, subPkgName, pkgName,
+			))
+			continue
+		}
+
+		for _, copiedFile := range copiedFiles {
+			used, usedErr := usedImportGlobals(copiedFile, importPath, pkgName)
+			if usedErr != nil {
+				errs = append(errs, errors.WithStack(usedErr))
+				continue
+			}
+			for _, name := range used.SortedSlice() {
+				if !subGlobals.Contains(name) {
+					errs = append(errs, errors.Errorf(
+						"Ops[%s].Substitute[%s].FilePath [%s] does not declare [%s] which is used by [%s]",
+						a.op.Id, importPath, subDir, name, copiedFile,
+					))
+				}
+			}
+		}
+
+		for _, f := range a.DepCopyOnlyFiles.SortedSlice() {
+			if filepath.Dir(f) == fromDir {
+				a.DepCopyOnlyFiles.Remove(f)
+				a.logFileActivity(f, fmt.Sprintf("omitted because its package is replaced by Ops.Substitute [%s]", sub.FilePath))
+			}
+		}
+	}
+
+	return errs
+}
+
+// declaredGlobals returns the package name, and names of the package-level functions, types, variables, and
+// constants, declared in the directory's non-test Go files.
+func declaredGlobals(dir string) (pkgName string, globals *cage_strings.Set, err error) {
+	globals = cage_strings.NewSet()
+
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to parse Go files in [%s]", dir)
+	}
+	if len(pkgs) != 1 {
+		return "", nil, errors.Errorf("expected one package in [%s], found %d", dir, len(pkgs))
+	}
+
+	for name, pkg := range pkgs {
+		pkgName = name
+		for _, f := range pkg.Files {
+			for _, decl := range f.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					if d.Recv == nil {
+						globals.Add(d.Name.Name)
+					}
+				case *ast.GenDecl:
+					for _, spec := range d.Specs {
+						switch sp := spec.(type) {
+						case *ast.TypeSpec:
+							globals.Add(sp.Name.Name)
+						case *ast.ValueSpec:
+							for _, id := range sp.Names {
+								globals.Add(id.Name)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return pkgName, globals, nil
+}
+
+// usedImportGlobals returns the names of the imported package's globals which are selected in the file,
+// e.g. "Record" in "telemetry.Record()".
+//
+// Dot and blank imports are not inspected.
+func usedImportGlobals(name, importPath, pkgName string) (used *cage_strings.Set, err error) {
+	used = cage_strings.NewSet()
+
+	f, err := parser.ParseFile(token.NewFileSet(), name, nil, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse [%s]", name)
+	}
+
+	var localName string
+	for _, spec := range f.Imports {
+		specPath, unquoteErr := strconv.Unquote(spec.Path.Value)
+		if unquoteErr != nil || specPath != importPath {
+			continue
+		}
+		localName = pkgName
+		if spec.Name != nil {
+			localName = spec.Name.Name
+		}
+	}
+	if localName == "" || localName == "." || localName == "_" {
+		return used, nil
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		// Unresolved identifiers, i.e. without an Obj, refer to imported packages rather than
+		// local variables which shadow the package name.
+		if id, ok := sel.X.(*ast.Ident); ok && id.Name == localName && id.Obj == nil {
+			used.Add(sel.Sel.Name)
+		}
+		return true
+	})
+
+	return used, nil
+}
+
 // addUsedDepGoFile adds a path to UsedDepGoFiles only if it was not already added to another list.
 //
 // It returns true if the path was added.
@@ -1564,6 +2023,18 @@ func (a *Audit) findDepUsage() (errs []error) {
 		return errs
 	}
 
+	// Ensure cgo-exported functions (and their direct/transitive dependencies) are not pruned because
+	// they may be called from C code in the package.
+
+	searchRootNodes = a.getDepCgoExportGlobalIds()
+	inputGlobalsType = "function with a cgo //export directive"
+	if dagErrs := a.findUsedDepGlobals(searchRootNodes, inputGlobalsType); len(dagErrs) > 0 {
+		for _, dagErr := range dagErrs {
+			errs = append(errs, errors.WithStack(dagErr))
+		}
+		return errs
+	}
+
 	// Ensure dependencies of blank identifiers are not pruned.
 
 	for _, dir := range a.inspector.GlobalIdNodes.SortedDirs() {
@@ -2201,6 +2672,38 @@ func (a *Audit) getDepIotaConstGlobalIds() (ids []cage_pkgs.GlobalId) {
 	return ids
 }
 
+// getDepCgoExportGlobalIds returns all Ops.Dep global identifiers which are functions marked by
+// cgo //export directives.
+func (a *Audit) getDepCgoExportGlobalIds() (ids []cage_pkgs.GlobalId) {
+	for _, dir := range a.inspector.GlobalIdNodes.SortedDirs() {
+		if !a.AllDepDirs.Contains(dir) {
+			continue
+		}
+
+		dirNodes := a.inspector.GlobalIdNodes[dir]
+
+		for _, pkgName := range dirNodes.SortedPkgNames() {
+			pkgNodes := dirNodes[pkgName]
+			for _, idName := range pkgNodes.SortedIds() {
+				node := pkgNodes[idName]
+				if fn, ok := node.Ast.(*ast.FuncDecl); ok && cage_build.IsCgoExport(fn) {
+					ids = append(
+						ids,
+						cage_pkgs.NewGlobalId(
+							node.InspectInfo.PkgPath,
+							pkgName,
+							node.InspectInfo.Filename,
+							idName,
+						),
+					)
+				}
+			}
+		}
+	}
+
+	return ids
+}
+
 // getMethodIds returns a GlobalId of every method in the subject identifier if the latter
 // is a struct type.
 //
@@ -2265,3 +2768,44 @@ func (a *Audit) PrintUnconfiguredDirs(w io.Writer) {
 		}
 	}
 }
+
+// excludeGoflagsTags returns a copy of the environment whose GOFLAGS "-tags" values omit the excluded tags.
+func excludeGoflagsTags(env []string, excluded []string) (filtered []string) {
+	excludedSet := cage_strings.NewSet().AddSlice(excluded)
+
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, "GOFLAGS=") {
+			filtered = append(filtered, kv)
+			continue
+		}
+
+		var flags []string
+		for _, flag := range strings.Fields(strings.TrimPrefix(kv, "GOFLAGS=")) {
+			var prefix string
+			for _, p := range []string{"-tags=", "--tags="} {
+				if strings.HasPrefix(flag, p) {
+					prefix = p
+					break
+				}
+			}
+			if prefix == "" {
+				flags = append(flags, flag)
+				continue
+			}
+
+			var tags []string
+			for _, tag := range strings.Split(strings.TrimPrefix(flag, prefix), ",") {
+				if tag != "" && !excludedSet.Contains(tag) {
+					tags = append(tags, tag)
+				}
+			}
+			if len(tags) > 0 {
+				flags = append(flags, prefix+strings.Join(tags, ","))
+			}
+		}
+
+		filtered = append(filtered, "GOFLAGS="+strings.Join(flags, " "))
+	}
+
+	return filtered
+}
@@ -84,8 +84,10 @@ func (s *ConfigSuite) TestExpandEnv() {
 		Dep: []transplant.Dep{
 			{
 				From: transplant.DepFrom{
-					FilePath:   s.Env["inline_edit"] + "_dep1",
-					ImportPath: "origin.tld/user/proj/" + s.Env["inline_edit"] + "_dep1",
+					FilePath:         s.Env["inline_edit"] + "_dep1",
+					ImportPath:       "origin.tld/user/proj/" + s.Env["inline_edit"] + "_dep1",
+					ModuleFilePath:   s.FixturePath("config", opId, "origin"),
+					ModuleImportPath: "origin.tld/user/proj",
 					GoFilePath: transplant.FilePathQuery{
 						Include: []string{
 							"**/*",
@@ -160,8 +162,10 @@ func (s *ConfigSuite) TestIngressFinalizer() {
 		Dep: []transplant.Dep{
 			{
 				From: transplant.DepFrom{
-					FilePath:   filepath.Join("internal", "dep1"),
-					ImportPath: "copy.tld/user/proj/internal/dep1",
+					FilePath:         filepath.Join("internal", "dep1"),
+					ImportPath:       "copy.tld/user/proj/internal/dep1",
+					ModuleFilePath:   s.FixturePath("config", opId, "copy"),
+					ModuleImportPath: "copy.tld/user/proj",
 					GoFilePath: transplant.FilePathQuery{
 						Include: []string{
 							"**/*",
@@ -200,6 +204,17 @@ func (s *ConfigSuite) TestIngressFinalizer() {
 	require.Exactly(t, expectedIngress, fixture.Audit.Op())
 }
 
+func (s *ConfigSuite) TestImportMapInvalid() {
+	t := s.T()
+
+	var config transplant.Config
+	errs := config.ReadFile(s.FixturePath("config", "transplant.yml"), "import_map_invalid")
+	require.Len(t, errs, 3)
+	require.Contains(t, errs[0].Error(), "Ops[import_map_invalid].ImportMap[origin.tld/user/proj/errors].To [github.com/pkg/errors] must be in <module path>@<version> format")
+	require.Contains(t, errs[1].Error(), "Ops[import_map_invalid].ImportMap[origin.tld/user/proj/errors1].To [github.com/pkg/errors@v0.8.x] is not a valid module version")
+	require.Contains(t, errs[2].Error(), "Ops[import_map_invalid].ImportMap[origin.tld/user/proj/errors2].To [github.com/pkg/errors/v2@v1.0.0] is not a valid module version")
+}
+
 func (s *ConfigSuite) TestJson() {
 	t := s.T()
 
@@ -252,8 +267,10 @@ func (s *ConfigSuite) TestJson() {
 		Dep: []transplant.Dep{
 			{
 				From: transplant.DepFrom{
-					FilePath:   "dep1",
-					ImportPath: "origin.tld/user/proj/dep1",
+					FilePath:         "dep1",
+					ImportPath:       "origin.tld/user/proj/dep1",
+					ModuleFilePath:   s.FixturePath("config", opId, "origin"),
+					ModuleImportPath: "origin.tld/user/proj",
 					GoFilePath: transplant.FilePathQuery{
 						Include: []string{
 							"**/*",
@@ -336,8 +353,10 @@ func (s *ConfigSuite) TestToml() {
 		Dep: []transplant.Dep{
 			{
 				From: transplant.DepFrom{
-					FilePath:   "dep1",
-					ImportPath: "origin.tld/user/proj/dep1",
+					FilePath:         "dep1",
+					ImportPath:       "origin.tld/user/proj/dep1",
+					ModuleFilePath:   s.FixturePath("config", opId, "origin"),
+					ModuleImportPath: "origin.tld/user/proj",
 					GoFilePath: transplant.FilePathQuery{
 						Include: []string{
 							"**/*",
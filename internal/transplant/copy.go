@@ -12,12 +12,15 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"go/format"
+	"go/parser"
+	"go/token"
 	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,15 +30,13 @@ import (
 	"github.com/codeactual/transplant/cmd/transplant/why"
 	cage_crypto "github.com/codeactual/transplant/internal/cage/crypto"
 	cage_errors "github.com/codeactual/transplant/internal/cage/errors"
+	cage_build "github.com/codeactual/transplant/internal/cage/go/build"
 	cage_go_list "github.com/codeactual/transplant/internal/cage/go/list"
 	cage_mod "github.com/codeactual/transplant/internal/cage/go/mod"
-	cage_io "github.com/codeactual/transplant/internal/cage/io"
-	cage_exec "github.com/codeactual/transplant/internal/cage/os/exec"
 	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
 	cage_file_matcher "github.com/codeactual/transplant/internal/cage/os/file/matcher"
 	cage_file_stage "github.com/codeactual/transplant/internal/cage/os/file/stage"
 	cage_filepath "github.com/codeactual/transplant/internal/cage/path/filepath"
-	cage_runtime "github.com/codeactual/transplant/internal/cage/runtime"
 	cage_strings "github.com/codeactual/transplant/internal/cage/strings"
 )
 
@@ -44,6 +45,10 @@ const (
 	newFileMode         = 0644
 	newDirMode          = 0755
 	skipOverwriteLogMsg = "omitted from final copy operation (content unchanged)"
+
+	// stageModuleVersion is the pseudo-version used to require another module of the copy,
+	// which is resolved by a `replace` directive instead.
+	stageModuleVersion = "v0.0.0-00010101000000-000000000000"
 )
 
 // Copier performs both egress and ingress copy operations.
@@ -66,6 +71,26 @@ type Copier struct {
 	// and receives requirements from the origin module.
 	ModuleRequire bool
 
+	// ModuleSumWarn is true if go.sum verification failures, i.e. stage hashes which differ from the origin's
+	// or are absent from it, should be printed to Stderr instead of causing the operation to fail.
+	// Either way they are added to CopyPlan.ModuleSumMismatch.
+	ModuleSumWarn bool
+
+	// GoVersionWarn is true if stage Go files which use language features or standard library APIs newer than
+	// their module's `go` directive should be printed to Stderr instead of causing the operation to fail.
+	// Either way they are added to CopyPlan.GoVersionRequirement.
+	GoVersionWarn bool
+
+	// Diff is true if CopyPlan.Diff should be populated.
+	Diff bool
+
+	// Provenance is true if CopyPlan.Provenance should be populated.
+	Provenance bool
+
+	// Report is true if CopyPlan.Report should be populated. It is also populated if Ops.To.ReportFilePath
+	// is selected.
+	Report bool
+
 	// Plan enumerates the copy actions which would run, to support dry-run mode.
 	Plan CopyPlan
 
@@ -75,6 +100,9 @@ type Copier struct {
 	// ProgressModule receives stdout messages from module-related Go commands.
 	ProgressModule io.Writer
 
+	// Events if non-nil receives machine-readable step and Go toolchain command events.
+	Events *EventLog
+
 	// Stderr receives messages about errors which are printed but considered serious enough to be
 	// collected and cause the operation to exit early.
 	Stderr io.Writer
@@ -85,6 +113,18 @@ type Copier struct {
 
 	// WhyLog if non-nil will receive updates which support `{egress,ingress} file` queries.
 	WhyLog why.Log
+
+	// provenance indexes the FileProvenance of stage files by their destination absolute paths.
+	// It is populated if Provenance is true.
+	provenance map[string]FileProvenance
+
+	// reportFiles indexes the figures of Ops.Dep non-test Go files copied to the stage by their origin absolute paths.
+	// It is populated if Report is true or Ops.To.ReportFilePath is selected.
+	reportFiles map[string]reportFile
+
+	// requires holds the versions of the third-party modules required by the stage go.mod files,
+	// indexed by module path. It is populated by moduleRequirements.
+	requires map[string]string
 }
 
 // NewCopier returns an initialized instance.
@@ -96,6 +136,8 @@ func NewCopier(ctx context.Context, audit *Audit) (c *Copier, err error) {
 		ProgressCore:   ioutil.Discard,
 		ProgressModule: ioutil.Discard,
 		Stderr:         os.Stderr,
+		provenance:     make(map[string]FileProvenance),
+		reportFiles:    make(map[string]reportFile),
 	}
 
 	// Account for the files which were pruned because they were not directly/transitively imported by audit.LocalGoFiles.
@@ -108,6 +150,12 @@ func NewCopier(ctx context.Context, audit *Audit) (c *Copier, err error) {
 		}
 	}
 
+	c.Plan.ExcludeBuildTagFiles = c.Audit.ExcludeBuildTagFiles.SortedSlice()
+
+	if !c.Op.Ingress {
+		c.Plan.OriginRev = c.Op.From.Rev
+	}
+
 	c.Stage, err = cage_file_stage.NewTempDirStage(stagePathPrefix)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -134,8 +182,11 @@ func (c *Copier) Run() (_ CopyPlan, errs []error) {
 		{title: "copy Ops.Dep implementation files to stage", f: c.usedDepGoFiles},
 		{title: "copy Ops.Dep test files to stage", f: c.depGoTestFiles},
 		{title: "copy Ops.Dep.CopyOnlyFilePath files to stage", f: c.depCopyOnlyFiles},
+		{title: "copy Ops.Substitute files to stage", f: c.substituteFiles},
 		{title: "output stage", f: c.outputStage},
 		{title: "copy module requirements to stage", f: c.moduleRequirements},
+		{title: "collect third-party licenses", f: c.licenses},
+		{title: "generate export report", f: c.report},
 		{title: "copy stage to Ops.To", f: c.copyStage},
 	}
 
@@ -147,6 +198,7 @@ func (c *Copier) Run() (_ CopyPlan, errs []error) {
 		if printProgressCore {
 			fmt.Fprintf(c.ProgressCore, "copy [%s] ... ", steps[n].title)
 		}
+		c.Events.StepStart(EventPhaseCopy, steps[n].title)
 
 		start := time.Now()
 		if errs := steps[n].f(); len(errs) > 0 {
@@ -157,6 +209,7 @@ func (c *Copier) Run() (_ CopyPlan, errs []error) {
 		if printProgressCore {
 			fmt.Fprintf(c.ProgressCore, "%s\n", steps[n].time)
 		}
+		c.Events.StepEnd(EventPhaseCopy, steps[n].title, steps[n].time)
 	}
 
 	c.Plan.Env = make(map[string]string)
@@ -247,6 +300,9 @@ func (c *Copier) localGoFiles() (errs []error) {
 
 		file.RenameIfGoFileNamedAfterRootPackage(c.Op.From.LocalImportPath, c.Op.To.LocalImportPath, c.Audit.LocalGoFiles)
 
+		var rewrites fileRewrites
+		rewrites.add(renameRewrite(filename, file.AbsPath))
+
 		updatedNode := file.Apply(func(cursor *astutil.Cursor) bool {
 			err := localFilePreApply(c.Audit, file, c.Op, cursor)
 			return !cage_errors.Append(&errs, errors.WithStack(err))
@@ -257,9 +313,13 @@ func (c *Copier) localGoFiles() (errs []error) {
 			continue
 		}
 
-		stageFileBytes = file.RenamePackageClause(fromLocalFilePath, c.Op.From.LocalImportPath, c.Op.To.LocalImportPath, stageFileBytes)
+		stageFileBytes = rewrites.step(
+			RewritePackageClause,
+			stageFileBytes,
+			file.RenamePackageClause(fromLocalFilePath, c.Op.From.LocalImportPath, c.Op.To.LocalImportPath, stageFileBytes),
+		)
 
-		stageFileBytes = c.rewriteLocalFileText(filename, stageFileBytes)
+		stageFileBytes = rewrites.step(RewriteReplaceString, stageFileBytes, c.rewriteLocalFileText(filename, stageFileBytes))
 
 		// Reformat in case import strings need to be re-sorted.
 		if formatted, err := format.Source(stageFileBytes); err == nil {
@@ -270,6 +330,8 @@ func (c *Copier) localGoFiles() (errs []error) {
 		}
 
 		toAbsPath, toRelPath := file.LocalDestPaths(c.Op)
+		rewrites.imports(filename, stageFileBytes)
+		c.addFileProvenance(toAbsPath, filename, rewrites)
 		if skip, err := c.skipWrite(toAbsPath, bytes.NewReader(stageFileBytes)); err == nil {
 			c.logFileActivity(toAbsPath, "added to stage as a local implementation file")
 			if skip {
@@ -303,6 +365,9 @@ func (c *Copier) localGoTestFiles() (errs []error) {
 
 		file.RenameIfGoFileNamedAfterRootPackage(c.Op.From.LocalImportPath, c.Op.To.LocalImportPath, c.Audit.LocalGoTestFiles)
 
+		var rewrites fileRewrites
+		rewrites.add(renameRewrite(filename, file.AbsPath))
+
 		updatedNode := file.Apply(func(cursor *astutil.Cursor) bool {
 			err := localFilePreApply(c.Audit, file, c.Op, cursor)
 			return !cage_errors.Append(&errs, errors.WithStack(err))
@@ -313,9 +378,13 @@ func (c *Copier) localGoTestFiles() (errs []error) {
 			continue
 		}
 
-		stageFileBytes = file.RenamePackageClause(fromLocalFilePath, c.Op.From.LocalImportPath, c.Op.To.LocalImportPath, stageFileBytes)
+		stageFileBytes = rewrites.step(
+			RewritePackageClause,
+			stageFileBytes,
+			file.RenamePackageClause(fromLocalFilePath, c.Op.From.LocalImportPath, c.Op.To.LocalImportPath, stageFileBytes),
+		)
 
-		stageFileBytes = c.rewriteLocalFileText(filename, stageFileBytes)
+		stageFileBytes = rewrites.step(RewriteReplaceString, stageFileBytes, c.rewriteLocalFileText(filename, stageFileBytes))
 
 		// Reformat in case import strings need to be re-sorted.
 		if formatted, err := format.Source(stageFileBytes); err == nil {
@@ -326,6 +395,8 @@ func (c *Copier) localGoTestFiles() (errs []error) {
 		}
 
 		toAbsPath, toRelPath := file.LocalDestPaths(c.Op)
+		rewrites.imports(filename, stageFileBytes)
+		c.addFileProvenance(toAbsPath, filename, rewrites)
 		if skip, err := c.skipWrite(toAbsPath, bytes.NewReader(stageFileBytes)); err == nil {
 			c.logFileActivity(toAbsPath, "added to stage as a local test file")
 			if skip {
@@ -348,8 +419,8 @@ func (c *Copier) localGoTestFiles() (errs []error) {
 
 // localCopyOnlyFiles adds Op.From.CopyOnlyFilePath Go/non-Go files to stage.
 func (c *Copier) localCopyOnlyFiles() (errs []error) {
-	// Reuse CopyOnlyFilePath logic to copy GoDescendantFilePath matches
-	c.Audit.LocalCopyOnlyFiles.AddSet(c.Audit.LocalGoDescendantFiles)
+	// Reuse CopyOnlyFilePath logic to copy GoDescendantFilePath, go:embed, and cgo/assembly matches
+	c.Audit.LocalCopyOnlyFiles.AddSet(c.Audit.LocalGoDescendantFiles, c.Audit.LocalEmbedFiles, c.Audit.LocalOtherFiles)
 
 	for _, filename := range c.Audit.LocalCopyOnlyFiles.SortedSlice() {
 		file, err := NewFile(c.Audit, filename)
@@ -363,13 +434,16 @@ func (c *Copier) localCopyOnlyFiles() (errs []error) {
 			c.Audit.LocalCopyOnlyFiles,
 		)
 
+		var rewrites fileRewrites
+		rewrites.add(renameRewrite(filename, file.AbsPath))
+
 		stageFileBytes, err := ioutil.ReadFile(filename)
 		if err != nil {
 			errs = append(errs, errors.Wrapf(err, "failed to read file [%s] for updating", filename))
 			continue
 		}
 
-		stageFileBytes = c.rewriteLocalFileText(filename, stageFileBytes)
+		stageFileBytes = rewrites.step(RewriteReplaceString, stageFileBytes, c.rewriteLocalFileText(filename, stageFileBytes))
 
 		// Reformat in case import strings need to be re-sorted.
 		if cage_filepath.IsGoFile(filename) {
@@ -382,9 +456,15 @@ func (c *Copier) localCopyOnlyFiles() (errs []error) {
 		}
 
 		toAbsPath, toRelPath := file.LocalDestPaths(c.Op)
+		rewrites.imports(filename, stageFileBytes)
+		c.addFileProvenance(toAbsPath, filename, rewrites)
 		if skip, err := c.skipWrite(toAbsPath, bytes.NewReader(stageFileBytes)); err == nil {
 			if c.Audit.LocalGoDescendantFiles.Contains(filename) {
 				c.logFileActivity(toAbsPath, "added to stage as a local GoDescendantFilePath file")
+			} else if c.Audit.LocalEmbedFiles.Contains(filename) {
+				c.logFileActivity(toAbsPath, "added to stage as a local go:embed file")
+			} else if c.Audit.LocalOtherFiles.Contains(filename) {
+				c.logFileActivity(toAbsPath, "added to stage as a local non-Go source file")
 			} else {
 				c.logFileActivity(toAbsPath, "added to stage as a local CopyOnlyFilePath file")
 			}
@@ -426,7 +506,8 @@ func (c *Copier) usedDepGoFiles() (errs []error) {
 		var dep Dep
 
 		for _, d := range c.Op.Dep {
-			if strings.HasPrefix(filename, filepath.Join(c.Op.From.ModuleFilePath, d.From.FilePath)) {
+			depPath := filepath.Join(c.Op.From.ModuleFilePath, d.From.FilePath)
+			if strings.HasPrefix(filename, depPath+string(filepath.Separator)) {
 				dep = d
 				break
 			}
@@ -444,6 +525,9 @@ func (c *Copier) usedDepGoFiles() (errs []error) {
 
 		file.RenameIfGoFileNamedAfterRootPackage(dep.From.ImportPath, dep.To.ImportPath, c.Audit.UsedDepGoFiles)
 
+		var rewrites fileRewrites
+		rewrites.add(renameRewrite(filename, file.AbsPath))
+
 		prunedGlobalIds, astErrs := file.UpdateDepAst(c.Audit, c.Op)
 		if len(astErrs) > 0 {
 			for n := range astErrs {
@@ -452,14 +536,19 @@ func (c *Copier) usedDepGoFiles() (errs []error) {
 			continue
 		}
 		c.Plan.PruneGlobalIds = append(c.Plan.PruneGlobalIds, prunedGlobalIds.Slice()...)
+		rewrites.add(pruneRewrite(prunedGlobalIds.Len()))
 
 		stageFileBytes, err := file.GetNodeBytes(file.DecoratedFile)
 		if cage_errors.Append(&errs, errors.WithStack(err)) {
 			continue
 		}
 
-		stageFileBytes = file.RenamePackageClause(FromAbs(c.Op, dep.From.FilePath), dep.From.ImportPath, dep.To.ImportPath, stageFileBytes)
-		stageFileBytes = c.rewriteDepFileText(filename, stageFileBytes)
+		stageFileBytes = rewrites.step(
+			RewritePackageClause,
+			stageFileBytes,
+			file.RenamePackageClause(FromAbs(c.Op, dep.From.FilePath), dep.From.ImportPath, dep.To.ImportPath, stageFileBytes),
+		)
+		stageFileBytes = rewrites.step(RewriteReplaceString, stageFileBytes, c.rewriteDepFileText(filename, stageFileBytes))
 
 		// Reformat in case import strings need to be re-sorted.
 		if formatted, err := format.Source(stageFileBytes); err == nil {
@@ -470,6 +559,9 @@ func (c *Copier) usedDepGoFiles() (errs []error) {
 		}
 
 		toAbsPath, toRelPath := file.DepDestPaths(c.Op, dep)
+		rewrites.imports(filename, stageFileBytes)
+		c.addFileProvenance(toAbsPath, filename, rewrites)
+		c.addReportFile(filename, stageFileBytes, prunedGlobalIds.Len())
 		if skip, err := c.skipWrite(toAbsPath, bytes.NewReader(stageFileBytes)); err == nil {
 			c.logFileActivity(toAbsPath, "added to stage as an Ops.Dep implementation file")
 			if skip {
@@ -506,7 +598,8 @@ func (c *Copier) depGoTestFiles() (errs []error) {
 		var dep Dep
 
 		for _, d := range c.Op.Dep {
-			if strings.HasPrefix(filename, filepath.Join(c.Op.From.ModuleFilePath, d.From.FilePath)) {
+			depPath := filepath.Join(c.Op.From.ModuleFilePath, d.From.FilePath)
+			if strings.HasPrefix(filename, depPath+string(filepath.Separator)) {
 				dep = d
 				break
 			}
@@ -524,6 +617,9 @@ func (c *Copier) depGoTestFiles() (errs []error) {
 
 		file.RenameIfGoFileNamedAfterRootPackage(dep.From.ImportPath, dep.To.ImportPath, c.Audit.DepGoTestFiles)
 
+		var rewrites fileRewrites
+		rewrites.add(renameRewrite(filename, file.AbsPath))
+
 		updatedNode := file.Apply(func(cursor *astutil.Cursor) bool {
 			if err := file.RewriteImportsInNode(c.Audit, c.Op, cursor, false); err != nil {
 				errs = append(errs, errors.WithStack(err))
@@ -538,8 +634,12 @@ func (c *Copier) depGoTestFiles() (errs []error) {
 			continue
 		}
 
-		stageFileBytes = file.RenamePackageClause(FromAbs(c.Op, dep.From.FilePath), dep.From.ImportPath, dep.To.ImportPath, stageFileBytes)
-		stageFileBytes = c.rewriteDepFileText(filename, stageFileBytes)
+		stageFileBytes = rewrites.step(
+			RewritePackageClause,
+			stageFileBytes,
+			file.RenamePackageClause(FromAbs(c.Op, dep.From.FilePath), dep.From.ImportPath, dep.To.ImportPath, stageFileBytes),
+		)
+		stageFileBytes = rewrites.step(RewriteReplaceString, stageFileBytes, c.rewriteDepFileText(filename, stageFileBytes))
 
 		// Reformat in case import strings need to be re-sorted.
 		if formatted, err := format.Source(stageFileBytes); err == nil {
@@ -550,6 +650,8 @@ func (c *Copier) depGoTestFiles() (errs []error) {
 		}
 
 		toAbsPath, toRelPath := file.DepDestPaths(c.Op, dep)
+		rewrites.imports(filename, stageFileBytes)
+		c.addFileProvenance(toAbsPath, filename, rewrites)
 		if skip, err := c.skipWrite(toAbsPath, bytes.NewReader(stageFileBytes)); err == nil {
 			c.logFileActivity(toAbsPath, "added to stage as an Ops.Dep test file")
 			if skip {
@@ -577,14 +679,15 @@ func (c *Copier) depCopyOnlyFiles() (errs []error) {
 		return []error{}
 	}
 
-	// Reuse CopyOnlyFilePath logic to copy GoDescendantFilePath matches
-	c.Audit.DepCopyOnlyFiles.AddSet(c.Audit.DepGoDescendantFiles)
+	// Reuse CopyOnlyFilePath logic to copy GoDescendantFilePath, go:embed, and cgo/assembly matches
+	c.Audit.DepCopyOnlyFiles.AddSet(c.Audit.DepGoDescendantFiles, c.Audit.DepEmbedFiles, c.Audit.DepOtherFiles)
 
 	for _, filename := range c.Audit.DepCopyOnlyFiles.SortedSlice() {
 		var dep Dep
 
 		for _, d := range c.Op.Dep {
-			if strings.HasPrefix(filename, filepath.Join(c.Op.From.ModuleFilePath, d.From.FilePath)) {
+			depPath := filepath.Join(c.Op.From.ModuleFilePath, d.From.FilePath)
+			if strings.HasPrefix(filename, depPath+string(filepath.Separator)) {
 				dep = d
 				break
 			}
@@ -602,13 +705,16 @@ func (c *Copier) depCopyOnlyFiles() (errs []error) {
 
 		file.RenameIfGoFileNamedAfterRootPackage(dep.From.ImportPath, dep.To.ImportPath, c.Audit.DepCopyOnlyFiles)
 
+		var rewrites fileRewrites
+		rewrites.add(renameRewrite(filename, file.AbsPath))
+
 		stageFileBytes, err := ioutil.ReadFile(filename)
 		if err != nil {
 			errs = append(errs, errors.Wrapf(err, "failed to read file [%s] for updating", filename))
 			continue
 		}
 
-		stageFileBytes = c.rewriteDepFileText(filename, stageFileBytes)
+		stageFileBytes = rewrites.step(RewriteReplaceString, stageFileBytes, c.rewriteDepFileText(filename, stageFileBytes))
 
 		// Reformat in case import strings need to be re-sorted.
 		if cage_filepath.IsGoFile(filename) {
@@ -621,9 +727,15 @@ func (c *Copier) depCopyOnlyFiles() (errs []error) {
 		}
 
 		toAbsPath, toRelPath := file.DepDestPaths(c.Op, dep)
+		rewrites.imports(filename, stageFileBytes)
+		c.addFileProvenance(toAbsPath, filename, rewrites)
 		if skip, err := c.skipWrite(toAbsPath, bytes.NewReader(stageFileBytes)); err == nil {
 			if c.Audit.DepGoDescendantFiles.Contains(filename) {
 				c.logFileActivity(toAbsPath, "added to stage as an Ops.Dep GoDescendantFilePath file")
+			} else if c.Audit.DepEmbedFiles.Contains(filename) {
+				c.logFileActivity(toAbsPath, "added to stage as an Ops.Dep go:embed file")
+			} else if c.Audit.DepOtherFiles.Contains(filename) {
+				c.logFileActivity(toAbsPath, "added to stage as an Ops.Dep non-Go source file")
 			} else {
 				c.logFileActivity(toAbsPath, "added to stage as an Ops.Dep CopyOnlyFilePath file")
 			}
@@ -651,6 +763,95 @@ func (c *Copier) depCopyOnlyFiles() (errs []error) {
 	return errs
 }
 
+// substituteFiles copies the Ops.Substitute.FilePath files, of each substitute used by Ops.From, to the
+// destination of the substituted package.
+//
+// Test files are only copied if the substituted package's Ops.Dep.From.Tests is true.
+func (c *Copier) substituteFiles() (errs []error) {
+	// Ingress does not attempt to update the origin's Ops.Dep packages by design, which also prevents the
+	// substitute files from overwriting the substituted package. See README.md for the rationale.
+	if c.Op.Ingress {
+		return []error{}
+	}
+
+	for _, importPath := range c.Audit.SubstitutedImportPaths.SortedSlice() {
+		sub, _ := c.Op.SubstituteOf(importPath)
+		_, toRelDir, _ := SubstituteDirs(c.Op, sub)
+		dep, _ := SubstituteDep(c.Op, sub)
+
+		var section string
+		for n := range c.Op.Substitute {
+			if c.Op.Substitute[n].ImportPath == importPath {
+				section = fmt.Sprintf("Ops.Substitute[%d]", n)
+			}
+		}
+		subDir := FromAbs(c.Op, sub.FilePath)
+
+		infos, err := ioutil.ReadDir(subDir)
+		if cage_errors.Append(&errs, errors.Wrapf(err, "failed to read Ops.Substitute.FilePath [%s]", subDir)) {
+			continue
+		}
+
+		for _, info := range infos {
+			if info.IsDir() {
+				continue
+			}
+
+			filename := filepath.Join(subDir, info.Name())
+
+			if cage_filepath.IsGoTestFile(filename) && !dep.From.Tests {
+				c.logFileActivity(filename, fmt.Sprintf("omitted because Ops.Dep.From.Tests is false for Ops.Substitute [%s]", importPath))
+				continue
+			}
+
+			stageFileBytes, err := ioutil.ReadFile(filename)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to read file [%s] for updating", filename))
+				continue
+			}
+
+			var rewrites fileRewrites
+			stageFileBytes = rewrites.step(RewriteReplaceString, stageFileBytes, c.rewriteDepFileText(filename, stageFileBytes))
+
+			// Reformat in case import strings need to be re-sorted.
+			if cage_filepath.IsGoFile(filename) {
+				if formatted, err := format.Source(stageFileBytes); err == nil {
+					stageFileBytes = formatted
+				} else {
+					c.Plan.GoFormatErr = append(c.Plan.GoFormatErr, CopyFileError{Name: filename, Err: err.Error()})
+				}
+			}
+
+			toRelPath := filepath.Join(toRelDir, info.Name())
+			toAbsPath := ToAbs(c.Op, toRelPath)
+			rewrites.imports(filename, stageFileBytes)
+			c.addProvenance(FileProvenance{Name: toAbsPath, Origin: filename, Section: section, Rewrite: rewrites})
+			if skip, err := c.skipWrite(toAbsPath, bytes.NewReader(stageFileBytes)); err == nil {
+				c.logFileActivity(toAbsPath, fmt.Sprintf("added to stage as an Ops.Substitute file for [%s]", importPath))
+				if skip {
+					c.logFileActivity(toAbsPath, skipOverwriteLogMsg)
+				}
+			} else {
+				cage_errors.Append(&errs, errors.WithStack(err))
+			}
+
+			fd, err := c.Stage.CreateFileAll(toRelPath, os.FileMode(newFileMode), os.FileMode(newDirMode))
+			if cage_errors.Append(&errs, errors.Wrapf(err, "failed to create stage file [%s]", filename)) {
+				continue
+			}
+
+			err = fd.Chmod(info.Mode())
+			if cage_errors.Append(&errs, errors.Wrapf(err, "failed to set mode of stage file [%s]", filename)) {
+				continue
+			}
+
+			_, err = fd.Write(stageFileBytes)
+			cage_errors.Append(&errs, errors.Wrapf(err, "failed to write to stage file [%s]", filename))
+		}
+	}
+	return errs
+}
+
 func (c *Copier) outputStage() (errs []error) {
 	if outErrs := c.Stage.Output(); len(outErrs) > 0 {
 		for n := range outErrs {
@@ -660,7 +861,167 @@ func (c *Copier) outputStage() (errs []error) {
 	return errs
 }
 
+// stageModule describes a module created in the stage: the Ops.To module itself, or an Ops.Dep tree
+// selected by Ops.Dep.To.Module.
+type stageModule struct {
+	// Dir is the module's root directory relative to the stage. It is empty for the Ops.To module.
+	Dir string
+
+	// Path is the `module` directive value.
+	Path string
+
+	// DestDir is the directory, relative to Ops.To.ModuleFilePath, from which the destination's existing
+	// go.mod/go.sum are removed before the stage is copied.
+	DestDir string
+}
+
+// stageModules returns the Ops.Dep modules, in config order, followed by the Ops.To module.
+func (c *Copier) stageModules() (mods []stageModule) {
+	for _, dep := range c.Op.Dep {
+		if dep.To.Module {
+			mods = append(mods, stageModule{Dir: dep.To.FilePath, Path: dep.To.ImportPath, DestDir: dep.To.FilePath})
+		}
+	}
+	return append(mods, stageModule{Path: c.Op.To.ModuleImportPath, DestDir: c.Op.To.LocalFilePath})
+}
+
+// stageModuleImports returns the import paths found in the Go files of the module's tree in the stage.
+func (c *Copier) stageModuleImports(mod stageModule, mods []stageModule) (imports *cage_strings.Set, err error) {
+	imports = cage_strings.NewSet()
+
+	names, err := c.stageModuleGoFiles(mod, mods)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	fset := token.NewFileSet()
+
+	for _, name := range names {
+		// Tolerate syntax errors, e.g. in CopyOnlyFilePath fixtures, by using the partial result.
+		f, _ := parser.ParseFile(fset, name, nil, parser.ImportsOnly)
+		if f == nil {
+			continue
+		}
+
+		for _, imp := range f.Imports {
+			importPath, unquoteErr := strconv.Unquote(imp.Path.Value)
+			if unquoteErr != nil {
+				return nil, errors.Wrapf(unquoteErr, "failed to unquote import path [%s] in [%s]", imp.Path.Value, name)
+			}
+			imports.Add(importPath)
+		}
+	}
+
+	return imports, nil
+}
+
+// stageModuleGoFiles returns the absolute paths of the Go files in the module's tree in the stage.
+//
+// Dirs which the go command ignores, "testdata" and those prefixed with "." or "_", are skipped.
+func (c *Copier) stageModuleGoFiles(mod stageModule, mods []stageModule) (names []string, err error) {
+	modDir := c.Stage.Path(mod.Dir)
+
+	// Other modules nested in this module's tree, including filesystem replacements copied into
+	// Ops.To.ReplaceFilePath, own the files under them.
+	nestedDirs := cage_strings.NewSet()
+	for _, other := range mods {
+		if other.Dir != mod.Dir {
+			nestedDirs.Add(c.Stage.Path(other.Dir))
+		}
+	}
+
+	walkErr := filepath.Walk(modDir, func(name string, fi os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if fi.IsDir() {
+			if name == modDir {
+				return nil
+			}
+			if nestedDirs.Contains(name) {
+				return filepath.SkipDir
+			}
+			if base := fi.Name(); base == "testdata" || strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_") {
+				return filepath.SkipDir
+			}
+			if _, statErr := os.Stat(filepath.Join(name, "go.mod")); statErr == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(name) == ".go" {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return nil, errors.Wrapf(walkErr, "failed to collect Go files of stage module [%s]", mod.Path)
+	}
+
+	return names, nil
+}
+
+// modulePathOwner returns the longest module path which contains the import path.
+//
+// It returns an empty string if none of the module paths contain the import path.
+func modulePathOwner(importPath string, modPaths []string) (owner string) {
+	for _, p := range modPaths {
+		if importPath != p && !strings.HasPrefix(importPath, p+"/") {
+			continue
+		}
+		if len(p) > len(owner) {
+			owner = p
+		}
+	}
+	return owner
+}
+
+// moduleEnv returns the current environment except that "-mod=vendor" in GOFLAGS is replaced with "-mod=mod".
+//
+// It allows module-aware "go" commands, e.g. "go list -m all", to use the module cache when the
+// origin uses vendoring.
+func moduleEnv() (env []string) {
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "GOFLAGS=") {
+			kv = strings.Replace(kv, "-mod=vendor", "-mod=mod", 1)
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// moduleSource holds the origin details from which the go.mod and go.sum of each stage module are synthesized.
+type moduleSource struct {
+	// buildList holds the "go list -m all" output of Ops.From.ModuleFilePath.
+	buildList *cage_go_list.ModuleSet
+
+	// buildPaths holds the paths of the buildList modules.
+	buildPaths []string
+
+	// directPaths holds the module paths found in the `require` directives of the origin/workspace go.mod files.
+	directPaths *cage_strings.Set
+
+	// goVersion is the Ops.To.GoVersion value, or the origin go.mod `go` directive value if the former is empty.
+	goVersion string
+
+	// replaces holds the origin/workspace `replace` directives which apply to the copy.
+	replaces []cage_mod.ModReplace
+
+	// replaceDirs indexes the directories, relative to the stage, into which filesystem replacements were
+	// copied. Keys are replaced module paths.
+	replaceDirs map[string]string
+
+	// sum holds the lines of the origin/workspace go.sum files.
+	sum *cage_mod.Sum
+}
+
 // moduleRequirements adds go.mod, go.sum, and vendor/ to the stage.
+//
+// The go.mod/go.sum content is synthesized from the origin's build list and go.sum, rather than by "go mod tidy",
+// so that the copy selects the same versions as the origin and the step does not require network access.
+//
+// If Ops.Dep.To.Module is enabled for any dependency, its tree is staged as a separate module, and the
+// requirements of each stage module are collected separately.
 func (c *Copier) moduleRequirements() (errs []error) {
 	if os.Getenv("TRANSPLANT_PPROF") == "1" {
 		return []error{}
@@ -679,222 +1040,491 @@ func (c *Copier) moduleRequirements() (errs []error) {
 		return []error{}
 	}
 
-	executor := cage_exec.CommonExecutor{}
-	stageGomodPath := c.Stage.Path("go.mod")
-	stageGosumPath := c.Stage.Path("go.sum")
-	originVendorPath := FromAbs(c.Op, "vendor")
+	src := moduleSource{
+		directPaths: cage_strings.NewSet(),
+		replaceDirs: make(map[string]string),
+		sum:         &cage_mod.Sum{},
+	}
 
-	// In go1.12, "go get" can fail due to "go get: disabled by -mod=vendor".
-	goGetEnv := os.Environ()
-	for n := range goGetEnv {
-		if strings.HasPrefix(goGetEnv[n], "GOFLAGS=") && strings.Contains(goGetEnv[n], "-mod=vendor") {
-			goGetEnv[n] = strings.Replace(goGetEnv[n], "-mod=vendor", "", 1)
-			break
-		}
+	// Collect the origin's build list, i.e. the versions which the origin's packages are built with.
+
+	executor := newExecutor(c.Events)
+	buildList, err := cage_go_list.NewQuery(executor, c.Op.From.ModuleFilePath).AllModules().Env(moduleEnv()...).Run(c.Ctx)
+	if err != nil {
+		return []error{errors.Wrap(err, "failed to collect the origin's build list")}
 	}
+	src.buildList = buildList
+	src.buildPaths = buildList.GetPaths().SortedSlice()
 
-	// Create the stage go.mod.
+	// Collect the origin module's requirements.
 
-	cmd := exec.CommandContext(c.Ctx, "go", "mod", "init", c.Op.To.ModuleImportPath)
-	cmd.Dir = c.Stage.Path()
-	_, err := executor.Standard(c.Ctx, c.ProgressModule, c.ProgressModule, nil, cmd)
+	originGomodPath := FromAbs(c.Op, "go.mod")
+	originGomod, err := cage_mod.NewModFromFile(originGomodPath)
 	if err != nil {
-		return []error{errors.WithStack(err)}
+		return []error{errors.Wrapf(err, "failed to parse the origin's go.mod [%s]", originGomodPath)}
+	}
+	src.goVersion = originGomod.Go
+	if c.Op.To.GoVersion != "" {
+		src.goVersion = c.Op.To.GoVersion
 	}
 
-	// Ensure go.mod "require (...)" is populated.
+	// Collect the requirements of other workspace modules which provide Ops.Dep packages.
 	//
-	// It's unclear how "go mod init" decides whether to populate it or not.
+	// The build list already reflects the highest version required by any workspace module,
+	// but disagreements are reported for review.
+
+	contribGomods := []*cage_mod.Mod{originGomod}
+	contribDirs := []string{c.Op.From.ModuleFilePath}
+	contribDirSet := cage_strings.NewSet()
+	contribDirSet.Add(c.Op.From.ModuleFilePath)
+	for _, dep := range c.Op.Dep {
+		if dep.From.ModuleFilePath == "" || !contribDirSet.Add(dep.From.ModuleFilePath) {
+			continue
+		}
 
-	cmd = exec.CommandContext(c.Ctx, "go", "mod", "tidy", "-v")
-	cmd.Dir = c.Stage.Path()
-	_, err = executor.Standard(c.Ctx, c.ProgressModule, c.ProgressModule, nil, cmd)
-	if err != nil {
-		return []error{errors.WithStack(err)}
+		depGomodPath := filepath.Join(dep.From.ModuleFilePath, "go.mod")
+		depGomod, depErr := cage_mod.NewModFromFile(depGomodPath)
+		if depErr != nil {
+			return []error{errors.Wrapf(depErr, "failed to parse the workspace module's go.mod [%s]", depGomodPath)}
+		}
+		contribGomods = append(contribGomods, depGomod)
+		contribDirs = append(contribDirs, dep.From.ModuleFilePath)
 	}
 
-	if err := c.Stage.AddFileByName("go.mod"); err != nil {
-		return []error{errors.WithStack(err)}
+	_, contribConflicts, err := cage_mod.MergeRequires(contribGomods...)
+	if err != nil {
+		return []error{errors.Wrap(err, "failed to merge the requirements of workspace modules")}
 	}
 
-	// Sync the stage's module dependencies with the origin's by comparing their go.mod versions.
-	// For each misaligned dependency (e.g. the origin is using an older version than found during
-	// the initial "go mod tidy"), use "go get" to update it.
-	//
-	// If this step was skipped, some dependencies would get updated to their newest releases every time
-	// the egress copy is refreshed.
+	// Collect the `replace` directives from the origin go.mod. Directives from other workspace modules
+	// are included unless the origin's go.mod replaces the same path.
 	//
-	// This allows dependency upgrades to propagate from Op.From.ModuleFilePath, increasing the reproducibility
-	// of the copy operation itself by reducing "magical" upgrades.
+	// Only directives which target any module in the origin's build list are included because they
+	// may also apply to the copy's transitive dependencies.
 
-	// Collect the origin module's requirements.
+	replacedPaths := cage_strings.NewSet()
+	for n, contribGomod := range contribGomods {
+		for _, r := range contribGomod.Requires() {
+			src.directPaths.Add(r.Path)
+		}
+		for _, replace := range contribGomod.Replaces() {
+			if !replacedPaths.Add(replace.Old) || src.buildList.GetByPath(replace.Old) == nil {
+				continue
+			}
 
-	originGomodPath := FromAbs(c.Op, "go.mod")
-	originGomod, err := cage_mod.NewModFromFile(originGomodPath)
-	if err != nil {
-		return []error{errors.Wrapf(err, "failed to parse the origin's go.mod [%s]", originGomodPath)}
+			stageDir, keep, replaceErr := c.stageReplace(replace, contribDirs[n])
+			if replaceErr != nil {
+				return []error{replaceErr}
+			}
+			if !keep {
+				continue
+			}
+			if stageDir != "" {
+				src.replaceDirs[replace.Old] = stageDir
+			}
+			src.replaces = append(src.replaces, replace)
+		}
 	}
 
-	// Collect the staged module's requirements.
+	// Collect the go.sum lines of the origin and other workspace modules.
 
-	stageGomod, err := cage_mod.NewModFromFile(stageGomodPath)
-	if err != nil {
-		return []error{errors.Wrapf(err, "failed to parse the stage's go.mod [%s]", stageGomodPath)}
+	sumPaths := []string{}
+	for _, dir := range contribDirs {
+		sumPaths = append(sumPaths, filepath.Join(dir, "go.sum"))
+	}
+	if c.Op.From.WorkFilePath != "" {
+		sumPaths = append(sumPaths, c.Op.From.WorkFilePath+".sum")
 	}
+	for _, sumPath := range sumPaths {
+		exists, _, existsErr := cage_file.Exists(sumPath)
+		if existsErr != nil {
+			return []error{errors.Wrapf(existsErr, "failed to check if [%s] exists", sumPath)}
+		}
+		if !exists {
+			continue
+		}
 
-	stageGomodRequires := stageGomod.Requires()
+		sum, sumErr := cage_mod.NewSumFromFile(sumPath)
+		if sumErr != nil {
+			return []error{errors.Wrapf(sumErr, "failed to parse [%s]", sumPath)}
+		}
+		for _, line := range sum.GetLines() {
+			src.sum.AddLine(line)
+		}
+	}
 
-	if len(stageGomodRequires) > 0 {
-		// Reset the stage go.mod/go.sum to avoid running "go get" with potentially newer requirements
-		// in those files than the origin's so that the command's behavior is not affected by them.
-		//
-		// As of go1.12.9, if we have two modules which are identical except that one has a go.mod
-		// with the latest available versions (via `go mod init`) and another has all older versions,
-		// simply running "go get <path>@<older version>" in the former module will not lead to a
-		// go.mod/go.sum identical to those in the module that always had older versions.
-		//
-		// In this example, all requirements are out-of-date except for golang.org/x/sync.
-		//
-		// require (
-		//         github.com/mitchellh/mapstructure v1.1.2
-		//         github.com/onsi/gomega v1.6.0
-		//         github.com/pkg/errors v0.8.1
-		//         github.com/stretchr/testify v1.4.0
-		//         golang.org/x/sync v0.0.0-20190423024810-112230192c58
-		// )
-		//
-		// After running "go get <path>@<older version>" on the first four dependencies, selecting
-		// the versions seen below, "gopkg.in/yaml.v2 v2.2.2" is added as an indirect. According
-		// to "go mod graph", it is present due to "origin gopkg.in/yaml.v2@v2.2.2". If the indirect
-		// is to be added at all, it seems like it should be due to "github.com/onsi/gomega@v1.4.3 gopkg.in/yaml.v2@v2.2.1"
-		// also found in the graph. "go mod tidy" does not remove it.
-		//
-		// require (
-		//         github.com/mitchellh/mapstructure v1.1.0
-		//         github.com/onsi/gomega v1.4.3
-		//         github.com/pkg/errors v0.8.1
-		//         github.com/stretchr/testify v1.3.0
-		//         golang.org/x/sync v0.0.0-20190423024810-112230192c58
-		//         gopkg.in/yaml.v2 v2.2.2 // indirect
-		// )
-
-		if err = cage_file.RemoveSafer(stageGomodPath); err != nil {
-			cage_errors.Append(&errs, errors.Wrapf(err, "failed to reset stage go.mod [%s]", stageGomodPath))
-			return errs
+	// Create all go.mod files before vendoring any module so that "go" commands can resolve
+	// the `replace` directives between stage modules.
+
+	mods := c.stageModules()
+	stageRequires := make(map[string]string)
+	for _, mod := range mods {
+		if modErrs := c.stageModuleRequirements(mod, mods, src, stageRequires); len(modErrs) > 0 {
+			return modErrs
 		}
-		stageSumExists, _, stageSumExistsErr := cage_file.Exists(stageGosumPath)
-		if stageSumExistsErr != nil {
-			return []error{errors.Wrapf(stageSumExistsErr, "failed to check if [%s] exists", stageGosumPath)}
+	}
+
+	// Check the language features and standard library APIs of each module's Go files before any
+	// "go" commands attempt to build them.
+
+	for _, mod := range mods {
+		if versionErrs := c.verifyStageGoVersion(mod, mods); len(versionErrs) > 0 {
+			return versionErrs
 		}
-		if stageSumExists {
-			if err = cage_file.RemoveSafer(stageGosumPath); err != nil {
-				cage_errors.Append(&errs, errors.Wrapf(err, "failed to reset stage go.sum [%s]", stageGosumPath))
-				return errs
+	}
+
+	c.requires = stageRequires
+
+	for _, conflict := range contribConflicts {
+		if _, found := stageRequires[conflict.Path]; !found {
+			continue
+		}
+		c.Plan.ModuleRequireConflict = append(c.Plan.ModuleRequireConflict, conflict.String())
+		fmt.Fprintf(c.ProgressModule, "workspace modules require different versions of %s\n", conflict.String())
+	}
+
+	for _, mod := range mods {
+		if modErrs := c.stageModuleVendor(mod, src); len(modErrs) > 0 {
+			return modErrs
+		}
+	}
+
+	return errs
+}
+
+// stageReplace classifies a `replace` directive declared by the go.mod in the dir directory.
+//
+// Module replacements are kept as-is. A filesystem replacement is dropped if an Ops.Dep copies packages
+// from it, because their import paths are rewritten to the copy's. Otherwise it is copied into
+// Ops.To.ReplaceFilePath, and stageDir is its location relative to the stage.
+//
+// An error is returned for filesystem replacements which would not resolve in the copy.
+func (c *Copier) stageReplace(replace cage_mod.ModReplace, dir string) (stageDir string, keep bool, err error) {
+	if !replace.IsLocal() {
+		return "", true, nil
+	}
+
+	target := filepath.FromSlash(replace.New)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+
+	for _, dep := range c.Op.Dep {
+		if dep.From.ModuleFilePath == target {
+			fmt.Fprintf(c.ProgressModule, "replacement of %s with [%s] dropped: its packages are copied by Ops.Dep [%s]\n", replace.Old, replace.New, dep.From.FilePath)
+			return "", false, nil
+		}
+	}
+
+	targetExists, _, err := cage_file.Exists(target)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to check if [%s] exists", target)
+	}
+	if !targetExists {
+		return "", false, errors.Errorf(
+			"Ops[%s] go.mod in [%s] replaces [%s] with [%s] which does not exist",
+			c.Op.Id, dir, replace.Old, replace.New,
+		)
+	}
+	if c.Op.To.ReplaceFilePath == "" {
+		return "", false, errors.Errorf(
+			"Ops[%s] go.mod in [%s] replaces [%s] with [%s] which will not exist in the copy: "+
+				"add an Ops.Dep which copies its packages, or select an Ops.To.ReplaceFilePath to copy it into",
+			c.Op.Id, dir, replace.Old, replace.New,
+		)
+	}
+
+	stageDir = filepath.Join(c.Op.To.ReplaceFilePath, filepath.FromSlash(replace.Old))
+
+	walkErrs := cage_filepath.WalkAbs(target, func(absPath string, fi os.FileInfo, walkErr error) []error {
+		if walkErr != nil {
+			return []error{errors.WithStack(walkErr)}
+		}
+		if fi.IsDir() {
+			if fi.Name() == ".git" {
+				return []error{filepath.SkipDir}
 			}
+			return nil
 		}
 
-		cmd = exec.CommandContext(c.Ctx, "go", "mod", "init", c.Op.To.ModuleImportPath)
-		cmd.Dir = c.Stage.Path()
-		_, err := executor.Standard(c.Ctx, c.ProgressModule, c.ProgressModule, nil, cmd)
-		if err != nil {
-			return []error{errors.WithStack(err)}
+		relPath, relErr := filepath.Rel(target, absPath)
+		if relErr != nil {
+			return []error{errors.Wrapf(relErr, "failed to get path of [%s] relative to [%s]", absPath, target)}
 		}
 
-		var ranGoGet bool
+		fd, copyErr := c.Stage.CopyFileAll(absPath, filepath.Join(stageDir, relPath), fi.Mode(), os.FileMode(newDirMode))
+		if copyErr != nil {
+			return []error{errors.Wrapf(copyErr, "failed to copy replacement file [%s] to stage", absPath)}
+		}
+		if chmodErr := fd.Chmod(fi.Mode()); chmodErr != nil {
+			return []error{errors.Wrapf(chmodErr, "failed to set mode of stage file [%s]", absPath)}
+		}
 
-		// Use "go get" to update the stage go.sum for each dependency in the stage which is at
-		// a version which differs from Op.From.ModuleFilePath (because a newer version was found).
-		for _, stageRequire := range stageGomodRequires {
-			originRequire, found := originGomod.GetRequire(stageRequire.Path)
+		c.logFileActivity(ToAbs(c.Op, stageDir, relPath), "added to stage as a copy of a filesystem `replace` target")
+		c.addProvenance(FileProvenance{Name: ToAbs(c.Op, stageDir, relPath), Origin: absPath, Section: "Ops.To.ReplaceFilePath"})
+		return nil
+	})
+	if len(walkErrs) > 0 {
+		return "", false, walkErrs[0]
+	}
 
-			if !found || originRequire.Version == stageRequire.Version {
+	fmt.Fprintf(c.ProgressModule, "replacement of %s with [%s] copied into [%s]\n", replace.Old, replace.New, stageDir)
+
+	return stageDir, true, nil
+}
+
+// stageModuleRequirements writes the module's go.mod, and go.sum if the origin provides any relevant hashes,
+// to the stage.
+//
+// If the destination already has a go.mod, only its `require` and `replace` directives are updated.
+//
+// The go.mod requires each origin build list module, at its selected version, and each Ops.ImportMap module,
+// at its configured version, which provides a package imported by the stage module. Imported stage modules
+// are required through `replace` directives with relative paths. If the `go` version is 1.17 or newer,
+// the build list modules which provide packages transitively imported through the former are also required,
+// as indirect, at their selected versions.
+//
+// The versions of the module's requirements, except for other stage modules, are added to the requires map
+// indexed by module path.
+func (c *Copier) stageModuleRequirements(mod stageModule, mods []stageModule, src moduleSource, requires map[string]string) (errs []error) {
+	imports, err := c.stageModuleImports(mod, mods)
+	if err != nil {
+		return []error{errors.WithStack(err)}
+	}
+
+	var stageModPaths []string
+	for _, other := range mods {
+		stageModPaths = append(stageModPaths, other.Path)
+	}
+
+	stageGomod := &cage_mod.Mod{Path: mod.Path, Go: src.goVersion}
+
+	// Ops.ImportMap module versions indexed by module path.
+	mappedVersions := make(map[string]string)
+	var mappedPaths []string
+	for _, m := range c.Op.ImportMap {
+		mappedVersions[m.ToImportPath] = m.ToVersion
+		mappedPaths = append(mappedPaths, m.ToImportPath)
+	}
+
+	var localReplaces []cage_mod.ModReplace
+	required := cage_strings.NewSet()
+
+	// Imports provided by the origin's build list modules.
+	buildImports := cage_strings.NewSet()
+
+	for _, importPath := range imports.SortedSlice() {
+		if owner := modulePathOwner(importPath, stageModPaths); owner != "" {
+			if owner == mod.Path || !required.Add(owner) {
 				continue
 			}
 
-			// -m/-d: To avoid "build constraints exclude all Go files" errors
-			//     https://github.com/golang/go/issues/33526
-			//     https://github.com/golang/go/issues/29268
-			goGetWorkaroundFlag := "-m"
-			atLeastGo113, err := cage_runtime.VersionAtLeast("1.13")
-			if err != nil {
-				return []error{errors.WithStack(err)}
-			}
-			if atLeastGo113 {
-				goGetWorkaroundFlag = "-d" // -m was removed in 1.13
+			for _, other := range mods {
+				if other.Path != owner {
+					continue
+				}
+				rel, relErr := filepath.Rel(c.Stage.Path(mod.Dir), c.Stage.Path(other.Dir))
+				if relErr != nil {
+					return []error{errors.Wrapf(relErr, "failed to get path of stage module [%s] relative to [%s]", other.Path, mod.Path)}
+				}
+				rel = filepath.ToSlash(rel)
+				if !strings.HasPrefix(rel, "../") {
+					rel = "./" + rel
+				}
+				localReplaces = append(localReplaces, cage_mod.ModReplace{Old: other.Path, New: rel})
 			}
+			continue
+		}
 
-			getCmd := exec.CommandContext(c.Ctx, "go", "get", goGetWorkaroundFlag, "-v", stageRequire.Path+"@"+originRequire.Version)
-			getCmd.Env = goGetEnv
-			ranGoGet = true
+		if owner := modulePathOwner(importPath, mappedPaths); owner != "" {
+			required.Add(owner)
+			continue
+		}
 
-			getCmd.Dir = c.Stage.Path()
-			_, err = executor.Standard(c.Ctx, c.ProgressModule, c.ProgressModule, nil, getCmd)
-			if err != nil {
-				return []error{errors.WithStack(err)}
+		// Standard library packages and packages of the origin's main/workspace modules are not found.
+		if owner := modulePathOwner(importPath, src.buildPaths); owner != "" {
+			required.Add(owner)
+			buildImports.Add(importPath)
+		}
+	}
+
+	// Go 1.17+ modules must also require each module which provides a package transitively imported by
+	// their own packages, because "go" commands only load the go.mod files of those requirements.
+	// Add the origin's build list modules which provide them as indirect requirements, at the origin's versions.
+	//
+	// Packages of the other stage modules which it imports, directly or through each other, are included.
+	indirect := cage_strings.NewSet()
+	if cage_build.CompareGoVersion(src.goVersion, "1.17") >= 0 {
+		visited := cage_strings.NewSet()
+		visited.Add(mod.Path)
+		queue := required.SortedSlice()
+		for len(queue) > 0 {
+			owner := queue[0]
+			queue = queue[1:]
+			if !visited.Add(owner) {
+				continue
+			}
+			for _, other := range mods {
+				if other.Path != owner {
+					continue
+				}
+				otherImports, otherErr := c.stageModuleImports(other, mods)
+				if otherErr != nil {
+					return []error{errors.WithStack(otherErr)}
+				}
+				for _, importPath := range otherImports.SortedSlice() {
+					if stageOwner := modulePathOwner(importPath, stageModPaths); stageOwner != "" {
+						queue = append(queue, stageOwner)
+					} else if modulePathOwner(importPath, src.buildPaths) != "" {
+						buildImports.Add(importPath)
+					}
+				}
 			}
 		}
 
-		// Clean up unused versions from go.sum that are no longer needed after the "go get" operations.
-		if ranGoGet {
-			cmd = exec.CommandContext(c.Ctx, "go", "mod", "tidy", "-v")
-			cmd.Dir = c.Stage.Path()
-			_, err = executor.Standard(c.Ctx, c.ProgressModule, c.ProgressModule, nil, cmd)
-			if err != nil {
-				return []error{errors.WithStack(err)}
+		deps, depsErr := cage_go_list.PackageModules(c.Ctx, newExecutor(c.Events), c.Op.From.ModuleFilePath, moduleEnv(), buildImports.SortedSlice()...)
+		if depsErr != nil {
+			return []error{errors.Wrapf(depsErr, "failed to collect the modules transitively imported by stage module [%s]", mod.Path)}
+		}
+		for _, p := range deps.GetPaths().SortedSlice() {
+			if required.Contains(p) || src.buildList.GetByPath(p) == nil || modulePathOwner(p, stageModPaths) != "" {
+				continue
 			}
+			indirect.Add(p)
 		}
 	}
 
-	// Append any `replace` directives, from the origin go.mod, which target dependencies found in the stage's
-	// full dependency list.
+	for _, p := range required.SortedSlice() {
+		version := stageModuleVersion
+		if v, found := mappedVersions[p]; found {
+			version = v
+		} else if m := src.buildList.GetByPath(p); m != nil {
+			version = m.Version
+		}
+		if err = stageGomod.AddRequire(cage_mod.ModRequire{Path: p, Version: version}); err != nil {
+			return []error{errors.WithStack(err)}
+		}
+		if modulePathOwner(p, stageModPaths) != p {
+			requires[p] = version
+		}
+	}
 
-	originReplaces := originGomod.Replaces()
+	for _, p := range indirect.SortedSlice() {
+		version := src.buildList.GetByPath(p).Version
+		if err = stageGomod.AddRequire(cage_mod.ModRequire{Path: p, Version: version, Comment: " // indirect"}); err != nil {
+			return []error{errors.WithStack(err)}
+		}
+		requires[p] = version
+	}
 
-	if len(originReplaces) > 0 {
-		// Collect 'go list -m all' from the staged copy.
-		stageGolist, err := cage_go_list.NewQuery(executor, c.Stage.Path()).AllModules().Run(c.Ctx)
-		if err != nil {
-			return []error{errors.Wrap(err, "failed to collect stage's full dependency list")}
+	for _, replace := range localReplaces {
+		if err = stageGomod.AddReplace(replace); err != nil {
+			return []error{errors.WithStack(err)}
 		}
+	}
 
-		var replaceStr strings.Builder
+	for _, replace := range src.replaces {
+		if stageDir, found := src.replaceDirs[replace.Old]; found {
+			rel, relErr := filepath.Rel(c.Stage.Path(mod.Dir), c.Stage.Path(stageDir))
+			if relErr != nil {
+				return []error{errors.Wrapf(relErr, "failed to get path of replacement [%s] relative to [%s]", stageDir, mod.Path)}
+			}
+			rel = filepath.ToSlash(rel)
+			if !strings.HasPrefix(rel, "../") {
+				rel = "./" + rel
+			}
+			replace.New = rel
+		}
+		if err = stageGomod.AddReplace(replace); err != nil {
+			return []error{errors.WithStack(err)}
+		}
+	}
 
-		// The line spacing doesn't need to be precise because it will be fixed by a later "go mod tidy".
-		for _, replace := range originReplaces {
-			if stageGolist.GetByPath(replace.Old) != nil {
-				replaceStr.WriteString("\n")
-				replaceStr.WriteString(replace.String())
+	// Include the go.sum lines of modules in the origin's build list, except for those of the origin's own
+	// requirements which the copy does not require, and those of the Ops.ImportMap versions if the origin has them.
+	stageGosum := &cage_mod.Sum{}
+	for _, line := range src.sum.GetLines() {
+		if v, found := mappedVersions[line.Path]; found {
+			if required.Contains(line.Path) && strings.TrimSuffix(line.Version, "/go.mod") == v {
+				stageGosum.AddLine(line)
 			}
+			continue
 		}
+		if src.buildList.GetByPath(line.Path) == nil {
+			continue
+		}
+		if src.directPaths.Contains(line.Path) && !required.Contains(line.Path) && !indirect.Contains(line.Path) {
+			continue
+		}
+		stageGosum.AddLine(line)
+	}
 
-		stageGomodFile, err := os.OpenFile(stageGomodPath, os.O_APPEND|os.O_WRONLY, 0644)
-		if err != nil {
-			return []error{errors.Wrapf(err, "failed to open stage's go.mod [%s]", stageGomodPath)}
+	// Merge the `require` and `replace` directives into the destination's existing go.mod, if any,
+	// to preserve the directives and comments which the destination owns.
+
+	destGomodPath := ToAbs(c.Op, mod.Dir, "go.mod")
+	destGomodExists, _, err := cage_file.Exists(destGomodPath)
+	if err != nil {
+		return []error{errors.Wrapf(err, "failed to check if destination file [%s] exists", destGomodPath)}
+	}
+	destGomod := []byte{}
+	if destGomodExists {
+		if destGomod, err = ioutil.ReadFile(destGomodPath); err != nil {
+			return []error{errors.Wrapf(err, "failed to read destination go.mod [%s]", destGomodPath)}
 		}
+	}
+
+	mergedGomod, changes, err := cage_mod.MergeMod(bytes.NewReader(destGomod), stageGomod)
+	if err != nil {
+		return []error{errors.Wrapf(err, "failed to merge directives into destination go.mod [%s]", destGomodPath)}
+	}
 
-		if _, err = stageGomodFile.WriteString(replaceStr.String()); err != nil {
-			return []error{errors.Wrapf(err, "failed to append 'replace' directives to stage go.mod [%s]", stageGomodPath)}
+	// MergeMod preserves the destination's `go` directive, so apply the override separately.
+	if c.Op.To.GoVersion != "" && destGomodExists {
+		mergedMod, parseErr := cage_mod.NewMod(strings.NewReader(mergedGomod))
+		if parseErr != nil {
+			return []error{errors.Wrapf(parseErr, "failed to parse merged go.mod of [%s]", destGomodPath)}
+		}
+		if oldVersion := mergedMod.Go; oldVersion != c.Op.To.GoVersion {
+			changes = append(changes, cage_mod.ModChange{Directive: "go", Old: oldVersion, New: c.Op.To.GoVersion})
+			mergedMod.Go = c.Op.To.GoVersion
+			mergedGomod = mergedMod.String()
 		}
+	}
+	for _, change := range changes {
+		c.Plan.ModuleDirective = append(c.Plan.ModuleDirective, filepath.Join(mod.Dir, "go.mod")+": "+change.String())
+	}
 
-		if err = cage_io.SyncClose(stageGomodFile, stageGomodPath); err != nil {
-			return []error{errors.WithStack(err)}
+	stageGomodPath := c.Stage.Path(mod.Dir, "go.mod")
+	if err = ioutil.WriteFile(stageGomodPath, []byte(mergedGomod), newFileMode); err != nil {
+		return []error{errors.Wrapf(err, "failed to write stage go.mod [%s]", stageGomodPath)}
+	}
+
+	if len(stageGosum.GetLines()) > 0 {
+		stageGosumPath := c.Stage.Path(mod.Dir, "go.sum")
+		if err = ioutil.WriteFile(stageGosumPath, []byte(stageGosum.String()), newFileMode); err != nil {
+			return []error{errors.Wrapf(err, "failed to write stage go.sum [%s]", stageGosumPath)}
 		}
 	}
 
-	// Use the synchronized go.mod to vendor the dependencies.
+	return errs
+}
 
-	stageVendorPath := c.Stage.Path("vendor")
+// stageModuleVendor adds the module's go.mod, go.sum, and vendor/ to the stage, after running "go mod vendor"
+// if the origin uses vendoring.
+func (c *Copier) stageModuleVendor(mod stageModule, src moduleSource) (errs []error) {
+	executor := newExecutor(c.Events)
+	stageGosumPath := c.Stage.Path(mod.Dir, "go.sum")
+	originVendorPath := FromAbs(c.Op, "vendor")
+	stageVendorPath := c.Stage.Path(mod.Dir, "vendor")
 
 	if c.Op.From.Vendor {
-		if len(errs) > 0 {
-			return errs
-		}
-
 		c.Plan.GoModVendor = true
 
-		cmd = exec.CommandContext(c.Ctx, "go", "mod", "vendor", "-v")
-		cmd.Dir = c.Stage.Path()
-		_, err = executor.Standard(c.Ctx, c.ProgressModule, c.ProgressModule, nil, cmd)
+		cmd := exec.CommandContext(c.Ctx, "go", "mod", "vendor", "-v")
+		cmd.Dir = c.Stage.Path(mod.Dir)
+		cmd.Env = moduleEnv()
+		_, err := executor.Standard(c.Ctx, c.ProgressModule, c.ProgressModule, nil, cmd)
 		if err != nil {
 			return []error{errors.WithStack(err)}
 		}
@@ -919,10 +1549,12 @@ func (c *Copier) moduleRequirements() (errs []error) {
 			)
 		if matches, matchesErr := finder.GetFilenameMatches(); matchesErr == nil {
 			for _, vendorPath := range matches.SortedSlice() {
-				if addErr := c.Stage.AddFileByName(strings.TrimPrefix(vendorPath, c.Stage.Path()+string(string(filepath.Separator)))); addErr != nil {
+				vendorRelPath := strings.TrimPrefix(vendorPath, c.Stage.Path()+string(string(filepath.Separator)))
+				if addErr := c.Stage.AddFileByName(vendorRelPath); addErr != nil {
 					cage_errors.Append(&errs, errors.Wrapf(addErr, "failed to add vendor file to stage [%s]", vendorPath))
 					return errs
 				}
+				c.addProvenance(FileProvenance{Name: ToAbs(c.Op, vendorRelPath), Section: "vendor"})
 			}
 		} else {
 			cage_errors.Append(&errs, errors.Wrapf(matchesErr, "failed to collect vendor filenames [%s]", stageVendorPath))
@@ -930,51 +1562,51 @@ func (c *Copier) moduleRequirements() (errs []error) {
 		}
 	}
 
-	// Clean up unused versions from go.sum that may have been created by the initial tidy
-	// and derived from a pre-sync go.mod.
-
-	cmd = exec.CommandContext(c.Ctx, "go", "mod", "tidy", "-v")
-	cmd.Dir = c.Stage.Path()
-	_, err = executor.Standard(c.Ctx, c.ProgressModule, c.ProgressModule, nil, cmd)
-	if err != nil {
+	if err := c.Stage.AddFileByName(filepath.Join(mod.Dir, "go.mod")); err != nil {
 		return []error{errors.WithStack(err)}
 	}
+	c.addProvenance(FileProvenance{Name: ToAbs(c.Op, mod.Dir, "go.mod"), Section: "go.mod"})
 
-	// Schedule the go.sum to be copied if the above "go mod tidy" usage has created it.
+	// Schedule the go.sum to be copied if the origin provided any relevant hashes.
 
 	stageSumExists, _, stageSumExistsErr := cage_file.Exists(stageGosumPath)
 	if stageSumExistsErr != nil {
 		return []error{errors.Wrapf(stageSumExistsErr, "failed to check if [%s] exists", stageGosumPath)}
 	}
 	if stageSumExists {
-		if err = c.Stage.AddFileByName("go.sum"); err != nil {
+		if sumErrs := c.verifyStageSum(mod, src.sum); len(sumErrs) > 0 {
+			return sumErrs
+		}
+
+		if err := c.Stage.AddFileByName(filepath.Join(mod.Dir, "go.sum")); err != nil {
 			return []error{errors.WithStack(err)}
 		}
+		c.addProvenance(FileProvenance{Name: ToAbs(c.Op, mod.Dir, "go.sum"), Section: "go.sum"})
 	}
 
 	// Now that replacements are ready in the stage, we can remove the old ones.
 
 	if !c.Op.DryRun {
-		existsPath := ToAbs(c.Op, c.Op.To.LocalFilePath, "go.mod")
+		existsPath := ToAbs(c.Op, mod.DestDir, "go.mod")
 		exists, _, existsErr := cage_file.Exists(existsPath)
 		if cage_errors.Append(&errs, errors.Wrapf(existsErr, "failed to check if destination file [%s] exists", existsPath)) {
 			return errs
 		}
 		if exists {
-			if err = cage_file.RemoveSafer(existsPath); err != nil {
+			if err := cage_file.RemoveSafer(existsPath); err != nil {
 				cage_errors.Append(&errs, errors.Wrapf(err, "failed to remove destination file [%s]", existsPath))
 				return errs
 			}
 		}
 
-		existsPath = ToAbs(c.Op, c.Op.To.LocalFilePath, "go.sum")
+		existsPath = ToAbs(c.Op, mod.DestDir, "go.sum")
 		if stageSumExists {
 			exists, _, existsErr := cage_file.Exists(existsPath)
 			if cage_errors.Append(&errs, errors.Wrapf(existsErr, "failed to check if destination file [%s] exists", existsPath)) {
 				return errs
 			}
 			if exists {
-				if err = cage_file.RemoveSafer(ToAbs(c.Op, c.Op.To.LocalFilePath, "go.sum")); err != nil {
+				if err := cage_file.RemoveSafer(existsPath); err != nil {
 					cage_errors.Append(&errs, errors.Wrapf(err, "failed to remove destination file [%s]", existsPath))
 					return errs
 				}
@@ -982,13 +1614,13 @@ func (c *Copier) moduleRequirements() (errs []error) {
 		}
 
 		if c.Op.From.Vendor {
-			existsPath = ToAbs(c.Op, "vendor")
+			existsPath = ToAbs(c.Op, mod.Dir, "vendor")
 			exists, _, existsErr := cage_file.Exists(existsPath)
 			if cage_errors.Append(&errs, errors.Wrapf(existsErr, "failed to check if destination file [%s] exists", existsPath)) {
 				return errs
 			}
 			if exists {
-				if err = cage_file.RemoveAllSafer(existsPath); err != nil {
+				if err := cage_file.RemoveAllSafer(existsPath); err != nil {
 					cage_errors.Append(&errs, errors.Wrapf(err, "failed to remove destination file [%s]", existsPath))
 					return errs
 				}
@@ -999,8 +1631,84 @@ func (c *Copier) moduleRequirements() (errs []error) {
 	return errs
 }
 
+// verifyStageSum compares the module's stage go.sum with the origin/workspace go.sum lines.
+//
+// A hash which differs from the origin's, or a module version which the origin does not provide a hash for,
+// is added to CopyPlan.ModuleSumMismatch. Unless ModuleSumWarn is true, each is also returned as an error
+// so the copy never includes unvetted dependency content.
+func (c *Copier) verifyStageSum(mod stageModule, originSum *cage_mod.Sum) (errs []error) {
+	stageGosumPath := c.Stage.Path(mod.Dir, "go.sum")
+	stageGosum, err := cage_mod.NewSumFromFile(stageGosumPath)
+	if err != nil {
+		return []error{errors.Wrapf(err, "failed to parse the stage's go.sum [%s]", stageGosumPath)}
+	}
+
+	var mismatches []string
+	for _, mismatchErr := range cage_mod.CompareSum(originSum, stageGosum) {
+		mismatches = append(mismatches, mismatchErr.Error())
+	}
+	for _, line := range cage_mod.MissingSum(originSum, stageGosum) {
+		mismatches = append(mismatches, fmt.Sprintf("expected hash for [%s] version [%s] to be in the origin's go.sum, found [%s]", line.Path, line.Version, line.Hash))
+	}
+
+	for _, m := range mismatches {
+		m = fmt.Sprintf("module [%s]: %s", mod.Path, m)
+		c.Plan.ModuleSumMismatch = append(c.Plan.ModuleSumMismatch, m)
+		if c.ModuleSumWarn {
+			fmt.Fprintf(c.Stderr, "warning: %s\n", m)
+		} else {
+			errs = append(errs, errors.New(m))
+		}
+	}
+
+	return errs
+}
+
+// verifyStageGoVersion checks that the module's Go files in the stage do not use language features or standard
+// library APIs which are newer than the stage go.mod's `go` directive.
+//
+// Each use is added to CopyPlan.GoVersionRequirement. Unless GoVersionWarn is true, each is also returned
+// as an error so users of the copy do not encounter build errors which the origin did not.
+func (c *Copier) verifyStageGoVersion(mod stageModule, mods []stageModule) (errs []error) {
+	stageGomodPath := c.Stage.Path(mod.Dir, "go.mod")
+	stageGomod, err := cage_mod.NewModFromFile(stageGomodPath)
+	if err != nil {
+		return []error{errors.Wrapf(err, "failed to parse the stage's go.mod [%s]", stageGomodPath)}
+	}
+
+	if stageGomod.Go == "" {
+		return []error{}
+	}
+
+	names, err := c.stageModuleGoFiles(mod, mods)
+	if err != nil {
+		return []error{errors.WithStack(err)}
+	}
+
+	for _, name := range names {
+		reqs, reqsErr := cage_build.ReadVersionRequirements(name, stageGomod.Go)
+		if reqsErr != nil {
+			// Syntax errors are tolerated for the same reason as in stageModuleImports.
+			continue
+		}
+
+		for _, req := range reqs {
+			req.Pos.Filename = strings.TrimPrefix(req.Pos.Filename, c.Stage.Path()+string(filepath.Separator))
+			m := fmt.Sprintf("module [%s] declares go %s: %s", mod.Path, stageGomod.Go, req)
+			c.Plan.GoVersionRequirement = append(c.Plan.GoVersionRequirement, m)
+			if c.GoVersionWarn {
+				fmt.Fprintf(c.Stderr, "warning: %s\n", m)
+			} else {
+				errs = append(errs, errors.New(m))
+			}
+		}
+	}
+
+	return errs
+}
+
 func (c *Copier) copyStage() (errs []error) {
-	copyCfg := cage_file_stage.CopyConfig{DryRun: c.Op.DryRun}
+	copyCfg := cage_file_stage.CopyConfig{Diff: c.Diff, DryRun: c.Op.DryRun}
 
 	if c.Op.Ingress {
 		copyCfg.RemovableDirs = c.Audit.IngressRemovableDirs
@@ -1019,6 +1727,17 @@ func (c *Copier) copyStage() (errs []error) {
 	c.Plan.Overwrite = stagePlan.Overwrite.SortedSlice()
 	c.Plan.Remove = stagePlan.Remove.SortedSlice()
 
+	diffNames := make([]string, 0, len(stagePlan.Diff))
+	for name := range stagePlan.Diff {
+		diffNames = append(diffNames, name)
+	}
+	cage_strings.SortStable(diffNames)
+	for _, name := range diffNames {
+		c.Plan.Diff = append(c.Plan.Diff, stagePlan.Diff[name])
+	}
+
+	c.finalizeProvenance()
+
 	cage_strings.SortStable(c.Plan.OverwriteSkip)
 	cage_strings.SortStable(c.Plan.PruneGlobalIds)
 	cage_strings.SortStable(c.Plan.PruneGoFiles)
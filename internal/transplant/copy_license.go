@@ -0,0 +1,309 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transplant
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	cage_go_list "github.com/codeactual/transplant/internal/cage/go/list"
+	cage_license "github.com/codeactual/transplant/internal/cage/license"
+	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
+	cage_strings "github.com/codeactual/transplant/internal/cage/strings"
+)
+
+const (
+	// noticeFileRule separates the sections of the Ops.License.NoticeFilePath file.
+	noticeFileRule = "================================================================================"
+
+	// noticeFileIntro is the first paragraph of the Ops.License.NoticeFilePath file.
+	noticeFileIntro = "This file is generated by transplant. It contains the license and notice files of the third-party\n" +
+		"code which this module requires or includes."
+)
+
+// licenseEntry describes a license or notice file, or the license headers of source files, found in
+// third-party code of the copy.
+type licenseEntry struct {
+	// Source identifies the code, e.g. "github.com/pkg/errors v0.8.1" or "Ops.Dep[internal/third_party]".
+	Source string
+
+	// Id is an SPDX license identifier, cage_license.Unknown, or cage_license.Notice.
+	Id string
+
+	// Name is the file's path relative to the code's root directory, or a description if the license
+	// was not found in a license or notice file.
+	Name string
+
+	// Text is the content of the file. It is empty if the license was not found in a license or notice file.
+	Text []byte
+}
+
+func (e licenseEntry) String() string {
+	return fmt.Sprintf("%s: %s [%s]", e.Source, e.Id, e.Name)
+}
+
+// licenses collects the licenses of the modules required by the stage go.mod files and of the Ops.Dep trees,
+// adds a summary to CopyPlan.License, checks them against Ops.License.Deny, and writes Ops.License.NoticeFilePath
+// to the stage.
+func (c *Copier) licenses() (errs []error) {
+	// Ingress does not attempt to update the origin's module or Ops.Dep trees by design.
+	if c.Op.Ingress {
+		return []error{}
+	}
+
+	if c.Op.License.NoticeFilePath == "" && len(c.Op.License.Deny) == 0 {
+		return []error{}
+	}
+
+	entries, err := c.moduleLicenses()
+	if err != nil {
+		return []error{errors.WithStack(err)}
+	}
+
+	depEntries, err := c.depLicenses()
+	if err != nil {
+		return []error{errors.WithStack(err)}
+	}
+	entries = append(entries, depEntries...)
+
+	for _, entry := range entries {
+		c.Plan.License = append(c.Plan.License, entry.String())
+
+		if entry.Id == cage_license.Notice {
+			continue
+		}
+		if denied := cage_license.MatchAny(c.Op.License.Deny, entry.Id); len(denied) > 0 {
+			errs = append(errs, errors.Errorf(
+				"Ops[%s].License.Deny [%s] matches license [%s] of [%s] found in [%s]",
+				c.Op.Id, strings.Join(denied, ", "), entry.Id, entry.Source, entry.Name,
+			))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if c.Op.License.NoticeFilePath == "" {
+		return []error{}
+	}
+
+	var b strings.Builder
+	b.WriteString(noticeFileIntro + "\n")
+	for _, entry := range entries {
+		if len(entry.Text) == 0 {
+			continue
+		}
+		b.WriteString("\n" + noticeFileRule + "\n")
+		b.WriteString(entry.Source + "\n")
+		b.WriteString(entry.Name + " (" + entry.Id + ")\n")
+		b.WriteString(noticeFileRule + "\n\n")
+		b.WriteString(strings.TrimRight(string(entry.Text), "\n") + "\n")
+	}
+
+	stageNoticePath := c.Stage.Path(c.Op.License.NoticeFilePath)
+	if err = os.MkdirAll(filepath.Dir(stageNoticePath), newDirMode); err != nil {
+		return []error{errors.Wrapf(err, "failed to create stage dir [%s]", filepath.Dir(stageNoticePath))}
+	}
+	if err = ioutil.WriteFile(stageNoticePath, []byte(b.String()), newFileMode); err != nil {
+		return []error{errors.Wrapf(err, "failed to write stage file [%s]", stageNoticePath)}
+	}
+	if err = c.Stage.AddFileByName(c.Op.License.NoticeFilePath); err != nil {
+		return []error{errors.WithStack(err)}
+	}
+
+	c.logFileActivity(ToAbs(c.Op, c.Op.License.NoticeFilePath), "added to stage as the Ops.License.NoticeFilePath")
+	c.addProvenance(FileProvenance{Name: ToAbs(c.Op, c.Op.License.NoticeFilePath), Section: "Ops.License.NoticeFilePath"})
+
+	return []error{}
+}
+
+// moduleLicenses returns the license and notice files found in the root directories of the modules
+// required by the stage go.mod files, in module path order.
+//
+// If the origin uses vendoring, the stage vendor/ directory is searched before the module cache.
+// A module without a license file, or which is not in the module cache, has a cage_license.Unknown entry.
+func (c *Copier) moduleLicenses() (entries []licenseEntry, err error) {
+	if len(c.requires) == 0 {
+		return nil, nil
+	}
+
+	mappedPaths := cage_strings.NewSet()
+	for _, m := range c.Op.ImportMap {
+		mappedPaths.Add(m.ToImportPath)
+	}
+
+	var paths, queries []string
+	for p := range c.requires {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		// Ops.ImportMap modules are not in the origin's build list.
+		if mappedPaths.Contains(p) {
+			queries = append(queries, p+"@"+c.requires[p])
+		} else {
+			queries = append(queries, p)
+		}
+	}
+
+	dirs, err := cage_go_list.ModuleDirs(c.Ctx, newExecutor(c.Events), c.Op.From.ModuleFilePath, moduleEnv(), queries...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to locate required modules")
+	}
+
+	for _, p := range paths {
+		source := p + " " + c.requires[p]
+
+		var found []licenseEntry
+
+		if c.Op.From.Vendor {
+			if found, err = readLicenseFiles(source, c.Stage.Path("vendor", filepath.FromSlash(p))); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+
+		if len(found) == 0 {
+			dir, ok := dirs[p]
+			if !ok {
+				entries = append(entries, licenseEntry{Source: source, Id: cage_license.Unknown, Name: "module not in module cache"})
+				continue
+			}
+			if found, err = readLicenseFiles(source, dir); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+
+		if len(found) == 0 {
+			entries = append(entries, licenseEntry{Source: source, Id: cage_license.Unknown, Name: "no license file"})
+			continue
+		}
+
+		entries = append(entries, found...)
+	}
+
+	return entries, nil
+}
+
+// depLicenses returns the license and notice files, and the license headers of copied Go files, found
+// in each Ops.Dep tree.
+//
+// License and notice files are collected from the directories of copied Go files, and their ancestors up
+// to Ops.Dep.From.FilePath, e.g. to include internal/third_party/<name>/LICENSE if the <name> package is copied.
+// License headers which declare a recognized license are summarized per directory.
+func (c *Copier) depLicenses() (entries []licenseEntry, err error) {
+	for _, dep := range c.Op.Dep {
+		root := FromAbs(c.Op, dep.From.FilePath)
+		source := "Ops.Dep[" + dep.From.FilePath + "]"
+
+		dirs := cage_strings.NewSet()
+		headers := make(map[string]*cage_strings.Set) // dir relative to root -> license IDs
+
+		for _, name := range c.Audit.UsedDepGoFiles.SortedSlice() {
+			if !strings.HasPrefix(name, root+string(filepath.Separator)) {
+				continue
+			}
+
+			for dir := filepath.Dir(name); dir == root || strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+				dirs.Add(dir)
+			}
+
+			id, headerErr := cage_license.IdentifyHeader(name)
+			if headerErr != nil {
+				return nil, errors.WithStack(headerErr)
+			}
+			if id == "" || id == cage_license.Unknown {
+				continue
+			}
+
+			relDir, relErr := filepath.Rel(root, filepath.Dir(name))
+			if relErr != nil {
+				return nil, errors.Wrapf(relErr, "failed to get path of [%s] relative to [%s]", name, root)
+			}
+			if headers[relDir] == nil {
+				headers[relDir] = cage_strings.NewSet()
+			}
+			headers[relDir].Add(id)
+		}
+
+		for _, dir := range dirs.SortedSlice() {
+			found, readErr := readLicenseFiles(source, dir)
+			if readErr != nil {
+				return nil, errors.WithStack(readErr)
+			}
+			for n := range found {
+				relDir, relErr := filepath.Rel(root, dir)
+				if relErr != nil {
+					return nil, errors.Wrapf(relErr, "failed to get path of [%s] relative to [%s]", dir, root)
+				}
+				found[n].Name = filepath.ToSlash(filepath.Join(relDir, found[n].Name))
+			}
+			entries = append(entries, found...)
+		}
+
+		var headerDirs []string
+		for relDir := range headers {
+			headerDirs = append(headerDirs, relDir)
+		}
+		sort.Strings(headerDirs)
+		for _, relDir := range headerDirs {
+			for _, id := range headers[relDir].SortedSlice() {
+				entries = append(entries, licenseEntry{
+					Source: source,
+					Id:     id,
+					Name:   filepath.ToSlash(filepath.Join(relDir, "*.go")) + " headers",
+				})
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// readLicenseFiles returns an entry for each license and notice file in the directory, in name order.
+//
+// It returns no entries if the directory does not exist.
+func readLicenseFiles(source, dir string) (entries []licenseEntry, err error) {
+	exists, _, err := cage_file.Exists(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if [%s] exists", dir)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read dir [%s]", dir)
+	}
+
+	for _, info := range infos {
+		if info.IsDir() || !cage_license.IsFileName(info.Name()) {
+			continue
+		}
+
+		name := filepath.Join(dir, info.Name())
+		text, readErr := ioutil.ReadFile(name)
+		if readErr != nil {
+			return nil, errors.Wrapf(readErr, "failed to read license file [%s]", name)
+		}
+
+		id := cage_license.Notice
+		if !cage_license.IsNoticeFileName(name) {
+			id = cage_license.Identify(text)
+		}
+
+		entries = append(entries, licenseEntry{Source: source, Id: id, Name: info.Name(), Text: text})
+	}
+
+	return entries, nil
+}
@@ -32,6 +32,9 @@ type CopyFileError struct {
 
 // CopyPlan is written to a file selected by the --plan CLI flag.
 type CopyPlan struct {
+	// OriginRev is the full SHA of the origin commit which was copied, or empty if the origin's working tree was copied.
+	OriginRev string `json:",omitempty" toml:",omitempty" yaml:"OriginRev,omitempty"`
+
 	// Add holds the absolute paths of all files to be added to Ops.To.FilePath.
 	Add []string `json:",omitempty" toml:",omitempty" yaml:"Add,omitempty" `
 
@@ -52,6 +55,10 @@ type CopyPlan struct {
 	// direct/transitive dependency of packages under Ops.From.FilePath.
 	PruneGoFiles []string `json:",omitempty" toml:",omitempty" yaml:"PruneGoFiles,omitempty"`
 
+	// ExcludeBuildTagFiles holds the absolute paths of Go files, and cgo/assembly files, which were omitted
+	// from the copy because their build constraints require one of the Ops.ExcludeBuildTags.
+	ExcludeBuildTagFiles []string `json:",omitempty" toml:",omitempty" yaml:"ExcludeBuildTagFiles,omitempty"`
+
 	// GoFormatErr describes Ops.From.CopyOnlyFilePath Go files which could not be automatically
 	// formatted by go/format.Source, e.g. due to a syntax error.
 	//
@@ -66,6 +73,45 @@ type CopyPlan struct {
 	// target file is not always present.
 	RenameNotFound []string `json:",omitempty" toml:",omitempty" yaml:"RenameNotFound,omitempty"`
 
+	// ModuleRequireConflict describes modules which the go.mod files of multiple contributing workspace
+	// modules (Ops.From.ModuleFilePath and Ops.Dep.From.ModuleFilePath) require at different versions,
+	// and which version was selected for the copy's go.mod.
+	//
+	// Only modules required by the copy are included.
+	ModuleRequireConflict []string `json:",omitempty" toml:",omitempty" yaml:"ModuleRequireConflict,omitempty"`
+
+	// ModuleDirective describes each go.mod directive which was added, updated, or removed,
+	// relative to the destination's existing go.mod if any.
+	ModuleDirective []string `json:",omitempty" toml:",omitempty" yaml:"ModuleDirective,omitempty"`
+
+	// ModuleSumMismatch describes stage go.sum hashes which differ from the origin's, or which are absent
+	// from the origin's go.sum.
+	ModuleSumMismatch []string `json:",omitempty" toml:",omitempty" yaml:"ModuleSumMismatch,omitempty"`
+
+	// GoVersionRequirement describes stage Go files which use language features or standard library APIs
+	// newer than the `go` directive of their module's go.mod.
+	GoVersionRequirement []string `json:",omitempty" toml:",omitempty" yaml:"GoVersionRequirement,omitempty"`
+
+	// License summarizes the licenses of the copy's third-party code, i.e. required modules and Ops.Dep trees,
+	// if Ops.License selects their collection.
+	//
+	// Format: <module path and version, or Ops.Dep>: <SPDX identifier, "unknown", or "NOTICE"> [<source file>]
+	License []string `json:",omitempty" toml:",omitempty" yaml:"License,omitempty"`
+
+	// Diff holds a unified diff, ordered by destination path, for each added, overwritten, and removed file
+	// if Copier.Diff is selected.
+	//
+	// Binary files, and files larger than stage.DefaultDiffMaxBytes, are only reported as different.
+	Diff []string `json:",omitempty" toml:",omitempty" yaml:"Diff,omitempty"`
+
+	// Provenance describes, ordered by destination path, how each added and overwritten file was selected
+	// and which rewrites were applied to it, if Copier.Provenance is selected.
+	Provenance []FileProvenance `json:",omitempty" toml:",omitempty" yaml:"Provenance,omitempty"`
+
+	// Report describes the size and pruning impact of the export on each Ops.Dep and its packages
+	// if Copier.Report or Ops.To.ReportFilePath is selected.
+	Report *Report `json:",omitempty" toml:",omitempty" yaml:"Report,omitempty"`
+
 	// GoModVendor is true if `go mod vendor` is called.
 	GoModVendor bool `yaml:"GoModVendor"`
 
@@ -98,10 +144,50 @@ func (p *CopyPlan) String() string {
 		}
 	}
 
+	if p.OriginRev != "" {
+		_, _ = b.WriteString("---\nOriginRev: " + p.OriginRev + "\n")
+	}
+
 	writeSection("Add", "added", p.Add)
 	writeSection("Overwrite", "overwritten", p.Overwrite)
 	writeSection("Remove", "removed", p.Remove)
 	writeSection("PruneGlobalIds", "pruned", p.PruneGlobalIds)
+	writeSection("ExcludeBuildTagFiles", "excluded by build tag", p.ExcludeBuildTagFiles)
+
+	if len(p.ModuleRequireConflict) > 0 {
+		_, _ = b.WriteString("---\nModuleRequireConflict:\n")
+		for _, v := range p.ModuleRequireConflict {
+			_, _ = b.WriteString("\t" + v + "\n")
+		}
+	}
+
+	if len(p.ModuleDirective) > 0 {
+		_, _ = b.WriteString("---\nModuleDirective:\n")
+		for _, v := range p.ModuleDirective {
+			_, _ = b.WriteString("\t" + v + "\n")
+		}
+	}
+
+	if len(p.ModuleSumMismatch) > 0 {
+		_, _ = b.WriteString("---\nModuleSumMismatch:\n")
+		for _, v := range p.ModuleSumMismatch {
+			_, _ = b.WriteString("\t" + v + "\n")
+		}
+	}
+
+	if len(p.GoVersionRequirement) > 0 {
+		_, _ = b.WriteString("---\nGoVersionRequirement:\n")
+		for _, v := range p.GoVersionRequirement {
+			_, _ = b.WriteString("\t" + v + "\n")
+		}
+	}
+
+	if len(p.License) > 0 {
+		_, _ = b.WriteString("---\nLicense:\n")
+		for _, v := range p.License {
+			_, _ = b.WriteString("\t" + v + "\n")
+		}
+	}
 
 	if unusedActions.Len() > 0 {
 		_, _ = b.WriteString("---\n")
@@ -121,6 +207,15 @@ func (p *CopyPlan) WriteFile(name string, optFields *cage_strings.Set) (err erro
 	if optFields == nil || !optFields.Contains("PruneGoFiles") {
 		source.PruneGoFiles = nil
 	}
+	if optFields == nil || !optFields.Contains("Diff") {
+		source.Diff = nil
+	}
+	if optFields == nil || !optFields.Contains("Provenance") {
+		source.Provenance = nil
+	}
+	if optFields == nil || !optFields.Contains("Report") {
+		source.Report = nil
+	}
 
 	var fileBytes []byte
 
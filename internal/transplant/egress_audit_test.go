@@ -7,8 +7,10 @@
 package transplant_test
 
 import (
+	"bytes"
 	"path"
 	"path/filepath"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -17,6 +19,7 @@ import (
 	cage_pkgs "github.com/codeactual/transplant/internal/cage/go/packages"
 	cage_dag "github.com/codeactual/transplant/internal/cage/graph/dag"
 	testkit_require "github.com/codeactual/transplant/internal/cage/testkit/testify/require"
+	"github.com/codeactual/transplant/internal/transplant"
 )
 
 // Per-case comments may refer to configuration file sections such as Ops.From and Ops.Dep.
@@ -50,6 +53,21 @@ func (s *EgressAuditSuite) TestDuplicateImports() {
 	)
 }
 
+// TestEmbedNoMatch asserts that a //go:embed pattern which matches no files in the origin fails the audit.
+func (s *EgressAuditSuite) TestEmbedNoMatch() {
+	t := s.T()
+
+	_, errs := s.LoadFixture("egress", "egress", "EgressAuditSuite", "yml", "embed_no_match")
+	require.Len(t, errs, 1)
+	testkit_require.MatchRegexp(
+		t,
+		errs[0].Error(),
+		`go:embed pattern \[missing/\*\]`,
+		`local\.go`,
+		"matched no files",
+	)
+}
+
 // TestUnconfiguredLocalDirs asserts the content Audit.UnconfiguredLocalDirs when findUsedDepPkgs encounters
 // dependencies which were excluded by Ops.From.GoFilePath.Exclude or simply not covered by any inclusion
 // pattern in the first place.
@@ -523,3 +541,141 @@ func (s *EgressAuditSuite) verticesFrom(dag cage_dag.Graph, origin cage_pkgs.Glo
 	}
 	return list.SortedSlice()
 }
+
+// TestGraph asserts the nodes and edges of Audit.Graph, which exports Audit.DepGlobalIdUsageDag, including
+// the pruned globals, the package-level collapse, and the paths to a target global.
+func (s *EgressAuditSuite) TestGraph() {
+	t := s.T()
+
+	fixture := s.MustLoadFixture("egress", "egress", "EgressAuditSuite", "yml", "graph_baseline")
+
+	root := "LocalGoFilesDagRoot.LocalGoFilesDagRoot.LocalGoFilesDagRoot"
+	dep1Dir := filepath.Join(fixture.Path, "origin", "dep1")
+	dep2Dir := filepath.Join(fixture.Path, "origin", "dep2")
+	dirId := func(dir string) string { return dir + ".<empty package name>.<empty global ID>" }
+	used := filepath.Join(dep1Dir, "dep1.go") + ".dep1.Used"
+	unused := filepath.Join(dep1Dir, "dep1.go") + ".dep1.Unused"
+	name := filepath.Join(dep2Dir, "dep2.go") + ".dep2.Name"
+	other := filepath.Join(dep2Dir, "dep2.go") + ".dep2.Other"
+
+	g, err := fixture.Audit.Graph(transplant.GraphConfig{Pruned: true})
+	require.NoError(t, err)
+	require.Exactly(
+		t,
+		[]transplant.GraphNode{
+			{Id: dirId(dep1Dir), Label: "dep1", Kind: transplant.GraphNodeDir},
+			{Id: unused, Label: "origin.tld/user/proj/dep1.Unused", Kind: transplant.GraphNodeGlobal, Pruned: true},
+			{Id: used, Label: "origin.tld/user/proj/dep1.Used", Kind: transplant.GraphNodeGlobal},
+			{Id: dirId(dep2Dir), Label: "dep2", Kind: transplant.GraphNodeDir},
+			{Id: name, Label: "origin.tld/user/proj/dep2.Name", Kind: transplant.GraphNodeGlobal},
+			{Id: other, Label: "origin.tld/user/proj/dep2.Other", Kind: transplant.GraphNodeGlobal, Pruned: true},
+			{Id: root, Label: "Ops.From", Kind: transplant.GraphNodeRoot},
+		},
+		g.Nodes,
+	)
+	require.Contains(t, g.Edges, transplant.GraphEdge{From: used, To: name})
+
+	var b bytes.Buffer
+	require.NoError(t, g.WriteDot(&b))
+	require.Contains(t, b.String(), strconv.Quote(used)+" -> "+strconv.Quote(name)+";")
+	require.Contains(t, b.String(), strconv.Quote(unused)+` [label="origin.tld/user/proj/dep1.Unused", style=dashed, color=red, fontcolor=red];`)
+
+	// Collapse to package-level edges.
+	g, err = fixture.Audit.Graph(transplant.GraphConfig{Package: true})
+	require.NoError(t, err)
+	require.Exactly(
+		t,
+		[]transplant.GraphEdge{{From: dep1Dir, To: dep2Dir}, {From: root, To: dep1Dir}, {From: root, To: dep2Dir}},
+		g.Edges,
+	)
+	require.Exactly(t, "origin.tld/user/proj/dep1", g.Nodes[0].Label)
+
+	// Only include paths to the target.
+	g, err = fixture.Audit.Graph(transplant.GraphConfig{Target: "origin.tld/user/proj/dep1.Used", Pruned: true})
+	require.NoError(t, err)
+	require.Exactly(
+		t,
+		[]transplant.GraphEdge{{From: dirId(dep1Dir), To: used}, {From: root, To: dirId(dep1Dir)}},
+		g.Edges,
+	)
+	require.Len(t, g.Nodes, 3)
+
+	g, err = fixture.Audit.Graph(transplant.GraphConfig{Target: "origin.tld/user/proj/dep2.Other"})
+	require.NoError(t, err)
+	require.Exactly(t, []transplant.GraphNode{{Id: other, Label: "origin.tld/user/proj/dep2.Other", Kind: transplant.GraphNodeGlobal, Pruned: true}}, g.Nodes)
+	require.Empty(t, g.Edges)
+
+	_, err = fixture.Audit.Graph(transplant.GraphConfig{Target: "origin.tld/user/proj/dep2.Missing"})
+	require.EqualError(t, err, "global [origin.tld/user/proj/dep2.Missing] was not found in Ops.Dep packages")
+}
+
+// TestWhyGlobal asserts the chains of usages returned by Audit.WhyGlobal for kept globals, including a method
+// kept along with its type, and the result for a pruned global.
+func (s *EgressAuditSuite) TestWhyGlobal() {
+	t := s.T()
+
+	fixture := s.MustLoadFixture("egress", "egress", "EgressAuditSuite", "yml", "why_global_baseline")
+
+	localFile := filepath.Join(fixture.Path, "origin", "local", "local.go")
+	dep1File := filepath.Join(fixture.Path, "origin", "dep1", "dep1.go")
+	dep2File := filepath.Join(fixture.Path, "origin", "dep2", "dep2.go")
+
+	// requireChain asserts the From/To/Reason and "file:line" of each usage.
+	requireChain := func(expected []transplant.GlobalUsage, actual []transplant.GlobalUsage) {
+		require.Len(t, actual, len(expected))
+		for n := range expected {
+			require.Exactly(t, expected[n].From, actual[n].From)
+			require.Exactly(t, expected[n].To, actual[n].To)
+			require.Exactly(t, expected[n].Reason, actual[n].Reason)
+			require.Exactly(t, expected[n].Position.Filename, actual[n].Position.Filename)
+			require.Exactly(t, expected[n].Position.Line, actual[n].Position.Line)
+		}
+	}
+
+	res, err := fixture.Audit.WhyGlobal("origin.tld/user/proj/dep2.Name")
+	require.NoError(t, err)
+	require.False(t, res.Pruned)
+	localUsage := transplant.GlobalUsage{
+		From: "origin.tld/user/proj/local.Local", To: "origin.tld/user/proj/dep1.Used",
+	}
+	localUsage.Position.Filename, localUsage.Position.Line = localFile, 5
+	nameUsage := transplant.GlobalUsage{
+		From: "origin.tld/user/proj/dep1.Used", To: "origin.tld/user/proj/dep2.Name",
+	}
+	nameUsage.Position.Filename, nameUsage.Position.Line = dep1File, 7
+	requireChain([]transplant.GlobalUsage{localUsage, nameUsage}, res.Chain)
+
+	var b bytes.Buffer
+	res.Print(&b)
+	require.Exactly(
+		t,
+		"origin.tld/user/proj/dep2.Name is kept because of this chain of usages:\n"+
+			"\t * origin.tld/user/proj/local.Local -> origin.tld/user/proj/dep1.Used ["+localFile+":5]\n"+
+			"\t * origin.tld/user/proj/dep1.Used -> origin.tld/user/proj/dep2.Name ["+dep1File+":7]\n",
+		b.String(),
+	)
+
+	// Methods of used types are kept even if they are not called.
+	res, err = fixture.Audit.WhyGlobal("origin.tld/user/proj/dep2.T.M")
+	require.NoError(t, err)
+	typeUsage := transplant.GlobalUsage{
+		From: "origin.tld/user/proj/dep1.Used", To: "origin.tld/user/proj/dep2.T",
+	}
+	typeUsage.Position.Filename, typeUsage.Position.Line = dep1File, 6
+	methodUsage := transplant.GlobalUsage{
+		From: "origin.tld/user/proj/dep2.T", To: "origin.tld/user/proj/dep2.T.M", Reason: "method of used type",
+	}
+	methodUsage.Position.Filename, methodUsage.Position.Line = dep2File, 9
+	requireChain([]transplant.GlobalUsage{localUsage, typeUsage, methodUsage}, res.Chain)
+
+	res, err = fixture.Audit.WhyGlobal("origin.tld/user/proj/dep2.Other")
+	require.NoError(t, err)
+	require.True(t, res.Pruned)
+	require.Empty(t, res.Chain)
+	b.Reset()
+	res.Print(&b)
+	require.Exactly(t, "origin.tld/user/proj/dep2.Other will be pruned: no path exists from Ops.From files to it\n", b.String())
+
+	_, err = fixture.Audit.WhyGlobal("origin.tld/user/proj/dep2.Missing")
+	require.EqualError(t, err, "global [origin.tld/user/proj/dep2.Missing] was not found in Ops.Dep packages")
+}
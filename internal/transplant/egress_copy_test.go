@@ -7,15 +7,24 @@
 package transplant_test
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	cage_mod "github.com/codeactual/transplant/internal/cage/go/mod"
 	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
+	cage_strings "github.com/codeactual/transplant/internal/cage/strings"
+	cage_testkit "github.com/codeactual/transplant/internal/cage/testkit"
 	testkit_require "github.com/codeactual/transplant/internal/cage/testkit/testify/require"
+	"github.com/codeactual/transplant/internal/transplant"
 )
 
 // Per-case comments may refer to configuration file sections such as Ops.From and Ops.Dep.
@@ -132,6 +141,394 @@ func (s *EgressCopySuite) TestCopyPlanDepCompletelyPruned() {
 	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
 }
 
+// TestExcludeBuildTags asserts that Ops.From and Ops.Dep.From files which require an Ops.ExcludeBuildTags
+// tag are omitted from the copy, even if GOFLAGS enables the tag, and that Ops.Dep globals used only
+// by those files are pruned.
+func (s *EgressCopySuite) TestExcludeBuildTags() {
+	t := s.T()
+
+	origGoflags := os.Getenv("GOFLAGS")
+	require.NoError(t, os.Setenv("GOFLAGS", strings.TrimSpace(origGoflags+" -tags=internal,corp")))
+	defer func() {
+		require.NoError(t, os.Setenv("GOFLAGS", origGoflags))
+	}()
+
+	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "exclude_build_tags")
+	if fixture.Plan.StagePath != "" {
+		defer func() {
+			require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+		}()
+	}
+
+	testkit_require.StringSliceExactly(
+		t,
+		[]string{
+			filepath.Join(fixture.Path, "origin", "dep1", "dep1_corp.go"),
+			filepath.Join(fixture.Path, "origin", "local", "local_internal.go"),
+		},
+		fixture.Plan.ExcludeBuildTagFiles,
+	)
+	testkit_require.StringSliceExactly(
+		t,
+		[]string{
+			filepath.Join(fixture.Path, "origin", "dep1", "dep1.go") + ".dep1.ExportedFunc2",
+		},
+		fixture.Plan.PruneGlobalIds,
+	)
+
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
+}
+
+// TestEmbedBaseline asserts that files matched by //go:embed directives in Ops.From and Ops.Dep files
+// are copied without CopyOnlyFilePath/GoDescendantFilePath patterns, and that ReplaceString applies to them.
+func (s *EgressCopySuite) TestEmbedBaseline() {
+	t := s.T()
+
+	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "embed_baseline")
+	if fixture.Plan.StagePath != "" {
+		defer func() {
+			require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+		}()
+	}
+
+	testkit_require.StringSliceExactly(
+		t,
+		[]string{
+			filepath.Join(fixture.Path, "origin", "local", "templates", "page.tmpl"),
+		},
+		fixture.Audit.LocalEmbedFiles.SortedSlice(),
+	)
+	testkit_require.StringSliceExactly(
+		t,
+		[]string{
+			filepath.Join(fixture.Path, "origin", "dep1", "static", "a.txt"),
+		},
+		fixture.Audit.DepEmbedFiles.SortedSlice(),
+	)
+
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
+}
+
+// TestCgoBaseline asserts that the non-Go source files of used Ops.Dep packages, e.g. cgo/assembly files,
+// are copied without CopyOnlyFilePath patterns, including those of other platforms but not those which
+// require an Ops.ExcludeBuildTags tag, and that cgo-exported functions are not pruned.
+func (s *EgressCopySuite) TestCgoBaseline() {
+	t := s.T()
+
+	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "cgo_baseline")
+	if fixture.Plan.StagePath != "" {
+		defer func() {
+			require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+		}()
+	}
+
+	testkit_require.StringSliceExactly(
+		t,
+		[]string{
+			filepath.Join(fixture.Path, "origin", "dep1", "dep1.c"),
+			filepath.Join(fixture.Path, "origin", "dep1", "dep1.h"),
+			filepath.Join(fixture.Path, "origin", "dep1", "dep1_windows.c"),
+			filepath.Join(fixture.Path, "origin", "dep2", "sub.s"),
+			filepath.Join(fixture.Path, "origin", "dep2", "sub_arm64.s"),
+		},
+		fixture.Audit.DepOtherFiles.SortedSlice(),
+	)
+	testkit_require.StringSliceExactly(
+		t,
+		[]string{filepath.Join(fixture.Path, "origin", "dep2", "trace.s")},
+		fixture.Plan.ExcludeBuildTagFiles,
+	)
+	testkit_require.StringSliceExactly(
+		t,
+		[]string{
+			filepath.Join(fixture.Path, "origin", "dep1", "dep1.go") + ".dep1.Unused",
+			filepath.Join(fixture.Path, "origin", "dep2", "dep2.go") + ".dep2.Unused",
+		},
+		fixture.Plan.PruneGlobalIds,
+	)
+
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
+}
+
+// TestWorkspaceBaseline asserts that Ops.Dep packages can be copied from another module of the origin's go.work.
+func (s *EgressCopySuite) TestWorkspaceBaseline() {
+	t := s.T()
+
+	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "workspace_baseline")
+	if fixture.Plan.StagePath != "" {
+		defer func() {
+			require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+		}()
+	}
+
+	require.Exactly(t, filepath.Join(fixture.Path, "origin", "go.work"), fixture.Audit.Op().From.WorkFilePath)
+
+	dep := fixture.Audit.Op().Dep[0]
+	require.Exactly(t, filepath.Join(fixture.Path, "origin", "cage"), dep.From.ModuleFilePath)
+	require.Exactly(t, "origin.tld/user/cage", dep.From.ModuleImportPath)
+	require.Exactly(t, "origin.tld/user/cage/dep1", dep.From.ImportPath)
+
+	testkit_require.StringSliceExactly(
+		t,
+		[]string{filepath.Join(fixture.Path, "origin", "cage", "dep1", "dep1.go") + ".dep1.Unused"},
+		fixture.Plan.PruneGlobalIds,
+	)
+
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
+}
+
+// TestDepModuleBaseline asserts that an Ops.Dep tree can be copied as a separate module which is
+// resolved by the other modules via `replace` directives.
+func (s *EgressCopySuite) TestDepModuleBaseline() {
+	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "dep_module_baseline")
+	if fixture.Plan.StagePath != "" {
+		defer func() {
+			require.NoError(s.T(), cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+		}()
+	}
+
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
+}
+
+// TestGomodMergeBaseline asserts that an existing destination go.mod retains the directives and comments
+// which transplant does not manage, and that its `require` and `replace` directives are updated.
+func (s *EgressCopySuite) TestGomodMergeBaseline() {
+	t := s.T()
+
+	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "gomod_merge_baseline")
+	if fixture.Plan.StagePath != "" {
+		defer func() {
+			require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+		}()
+	}
+
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
+
+	require.Exactly(
+		t,
+		[]string{
+			"go.mod: require github.com/pkg/errors: v0.8.0 -> v0.8.1",
+			"go.mod: require github.com/spf13/pflag: v1.0.2 -> (none)",
+			"go.mod: replace github.com/spf13/pflag: ../pflag -> (none)",
+		},
+		fixture.Plan.ModuleDirective,
+	)
+}
+
+// TestReplaceFsBaseline asserts that the target of an origin `replace` directive with a filesystem path
+// is copied into Ops.To.ReplaceFilePath, and that module replacements are kept as-is.
+func (s *EgressCopySuite) TestReplaceFsBaseline() {
+	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "replace_fs_baseline")
+	if fixture.Plan.StagePath != "" {
+		defer func() {
+			require.NoError(s.T(), cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+		}()
+	}
+
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
+}
+
+// TestReplaceFsUnresolved asserts that an origin `replace` directive with a filesystem path is an error
+// if neither an Ops.Dep nor Ops.To.ReplaceFilePath copies its target.
+func (s *EgressCopySuite) TestReplaceFsUnresolved() {
+	t := s.T()
+
+	_, errs := s.CopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "replace_fs_unresolved")
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Error(), "replaces [example.com/fork] with [../fork] which will not exist in the copy")
+}
+
+// TestImportMapBaseline asserts that imports of Ops.ImportMap packages are rewritten to the published module,
+// which the copy's go.mod requires, instead of the packages being copied.
+func (s *EgressCopySuite) TestImportMapBaseline() {
+	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "import_map_baseline")
+	if fixture.Plan.StagePath != "" {
+		defer func() {
+			require.NoError(s.T(), cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+		}()
+	}
+
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
+}
+
+// TestSubstituteBaseline asserts that an Ops.Substitute package's files replace the substituted Ops.Dep
+// package's files in the copy, and that the latter's dependencies are not copied. The substitute's test
+// files are not copied because Ops.Dep.From.Tests is false.
+func (s *EgressCopySuite) TestSubstituteBaseline() {
+	t := s.T()
+
+	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "substitute_baseline")
+	if fixture.Plan.StagePath != "" {
+		defer func() {
+			require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+		}()
+	}
+
+	testkit_require.StringSliceExactly(
+		t,
+		[]string{"origin.tld/user/proj/dep/telemetry"},
+		fixture.Audit.SubstitutedImportPaths.SortedSlice(),
+	)
+
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
+}
+
+// TestSubstituteMissingGlobal asserts that an Ops.Substitute package must declare every global which
+// Ops.From packages use from the substituted package.
+func (s *EgressCopySuite) TestSubstituteMissingGlobal() {
+	t := s.T()
+
+	_, errs := s.CopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "substitute_missing_global")
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Error(), "does not declare [Enabled] which is used by")
+}
+
+// TestSubstituteDepMissingGlobal asserts that the copy fails if the Ops.Substitute package does not declare
+// a global which a copied Ops.Dep package uses from the substituted package.
+func (s *EgressCopySuite) TestSubstituteDepMissingGlobal() {
+	t := s.T()
+
+	_, errs := s.CopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "substitute_dep_missing_global")
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Error(), "does not declare [Flush] which is used by")
+	require.Contains(t, errs[0].Error(), filepath.Join("dep", "report", "report.go"))
+}
+
+// TestGoVersionBaseline asserts that Ops.To.GoVersion overrides the origin's `go` directive in the copy's
+// go.mod, and that the copy's Go files are checked against the former.
+func (s *EgressCopySuite) TestGoVersionBaseline() {
+	t := s.T()
+
+	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "go_version_baseline")
+	if fixture.Plan.StagePath != "" {
+		defer func() {
+			require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+		}()
+	}
+
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
+
+	gomod, err := cage_mod.NewModFromFile(filepath.Join(fixture.OutputPath, "go.mod"))
+	require.NoError(t, err)
+	require.Exactly(t, "1.18", gomod.Go)
+	require.Empty(t, fixture.Plan.GoVersionRequirement)
+}
+
+// TestGoVersionFeature asserts that the copy fails if its Go files use features newer than the copy's
+// `go` directive, e.g. one lowered by Ops.To.GoVersion.
+func (s *EgressCopySuite) TestGoVersionFeature() {
+	t := s.T()
+
+	_, errs := s.CopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "go_version_feature")
+	require.Len(t, errs, 3)
+	require.Contains(t, errs[0].Error(), "module [copy.tld/user/proj] declares go 1.13: proj.go:7:11: type parameters requires go 1.18")
+	require.Contains(t, errs[1].Error(), "proj.go:7:14: predeclared comparable requires go 1.18")
+	require.Contains(t, errs[2].Error(), "proj.go:12:15: strings.Cut requires go 1.18")
+}
+
+// TestGoVersionConstraint asserts that the copy's Go files are checked against the Go version required by
+// their build constraints, if newer than the copy's `go` directive, and that dirs ignored by the go command,
+// e.g. "testdata", are not checked.
+func (s *EgressCopySuite) TestGoVersionConstraint() {
+	t := s.T()
+
+	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "go_version_constraint")
+	if fixture.Plan.StagePath != "" {
+		defer func() {
+			require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+		}()
+	}
+
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
+
+	require.Empty(t, fixture.Plan.GoVersionRequirement)
+}
+
+// TestSumMismatch asserts that the copy fails if its go.sum has hashes which differ from, or are absent in,
+// the origin's go.sum, and that ModuleSumWarn prints and collects them into CopyPlan.ModuleSumMismatch instead.
+//
+// The copy requires no modules, so its go.sum is the one copied from origin/local, which has a tampered hash
+// and an unvetted module.
+func (s *EgressCopySuite) TestSumMismatch() {
+	t := s.T()
+
+	mismatches := []string{
+		"module [copy.tld/user/proj]: expected hash for [github.com/pkg/errors] version [v0.8.1] to be [h1:iURUrRGxPUNPdy5/HRSm+Yj6okJ6UtLINN0Q9M4+h3I=], found [h1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=]",
+		"module [copy.tld/user/proj]: expected hash for [origin.tld/user/unvetted] version [v1.0.0/go.mod] to be in the origin's go.sum, found [h1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=]",
+	}
+
+	_, errs := s.CopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "sum_mismatch")
+	require.Len(t, errs, 2)
+	require.Exactly(t, mismatches[0], errs[0].Error())
+	require.Exactly(t, mismatches[1], errs[1].Error())
+
+	// With --sum-warn, the mismatches are printed and collected instead.
+
+	fixture, errs := s.NewCopier("egress", "egress", "EgressCopySuite", "yml", "sum_mismatch")
+	cage_testkit.RequireNoErrors(t, errs)
+
+	var stderr bytes.Buffer
+	fixture.Copier.ModuleRequire = true
+	fixture.Copier.ModuleSumWarn = true
+	fixture.Copier.Stderr = &stderr
+	fixture.Plan, errs = fixture.Copier.Run()
+	cage_testkit.RequireNoErrors(t, errs)
+	defer func() {
+		require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+	}()
+
+	require.Exactly(t, mismatches, fixture.Plan.ModuleSumMismatch)
+	require.Exactly(t, "warning: "+mismatches[0]+"\nwarning: "+mismatches[1]+"\n", stderr.String())
+}
+
+// TestLicenseBaseline asserts that the license and notice files of required modules and Ops.Dep trees,
+// and the license headers of copied Ops.Dep files, are collected into the plan and Ops.License.NoticeFilePath.
+func (s *EgressCopySuite) TestLicenseBaseline() {
+	t := s.T()
+
+	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "license_baseline")
+	if fixture.Plan.StagePath != "" {
+		defer func() {
+			require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+		}()
+	}
+
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
+
+	require.Exactly(
+		t,
+		[]string{
+			"github.com/pkg/errors v0.8.1: BSD-2-Clause [LICENSE]",
+			"Ops.Dep[dep]: NOTICE [NOTICE]",
+			"Ops.Dep[dep]: MIT [third_party/stack/LICENSE]",
+			"Ops.Dep[dep]: Apache-2.0 [util/*.go headers]",
+		},
+		fixture.Plan.License,
+	)
+}
+
+// TestLicenseDeny asserts that the copy fails if a collected license matches Ops.License.Deny.
+func (s *EgressCopySuite) TestLicenseDeny() {
+	t := s.T()
+
+	_, errs := s.CopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "license_deny")
+	require.Len(t, errs, 2)
+	require.Contains(t, errs[0].Error(), "Ops[license_deny].License.Deny [BSD-*] matches license [BSD-2-Clause] of [github.com/pkg/errors v0.8.1] found in [LICENSE]")
+	require.Contains(t, errs[1].Error(), "Ops[license_deny].License.Deny [Apache-2.0] matches license [Apache-2.0] of [Ops.Dep[dep]] found in [util/*.go headers]")
+}
+
 // TestRemoveBaseline asserts that files which are absent in the origin are removed in the destination.
 func (s *EgressCopySuite) TestRemoveBaseline() {
 	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "remove_baseline")
@@ -827,3 +1224,234 @@ func (s *EgressCopySuite) TestDotImportSupport() {
 	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
 	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
 }
+
+// TestCopyPlanProvenance asserts that CopyPlan.Provenance identifies the config section of each added file,
+// including the Ops.Dep index of "dep10" files which must not be attributed to the sibling "dep1".
+func (s *EgressCopySuite) TestCopyPlanProvenance() {
+	t := s.T()
+
+	fixture, errs := s.NewCopier("egress", "egress", "EgressCopySuite", "yml", "provenance_baseline")
+	cage_testkit.RequireNoErrors(t, errs)
+
+	fixture.Copier.ModuleRequire = true
+	fixture.Copier.Provenance = true
+	fixture.Plan, errs = fixture.Copier.Run()
+	cage_testkit.RequireNoErrors(t, errs)
+	defer func() {
+		require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+	}()
+
+	originPath := filepath.Join(fixture.Path, "origin")
+
+	require.Exactly(
+		t,
+		[]transplant.FileProvenance{
+			{
+				Name:    filepath.Join(fixture.OutputPath, "go.mod"),
+				Section: "go.mod",
+			},
+			{
+				Name:    filepath.Join(fixture.OutputPath, "internal", "dep1", "dep1.go"),
+				Origin:  filepath.Join(originPath, "dep1", "dep1.go"),
+				Section: "Ops.Dep[0].From.GoFilePath",
+				Rewrite: []string{"pruned 1 global identifier(s)"},
+			},
+			{
+				Name:    filepath.Join(fixture.OutputPath, "internal", "dep10", "dep10.go"),
+				Origin:  filepath.Join(originPath, "dep10", "dep10.go"),
+				Section: "Ops.Dep[1].From.GoFilePath",
+			},
+			{
+				Name:    filepath.Join(fixture.OutputPath, "proj.go"),
+				Origin:  filepath.Join(originPath, "local", "local.go"),
+				Section: "Ops.From.GoFilePath",
+				Rewrite: []string{transplant.RewriteImportPath, "renamed from local.go", transplant.RewritePackageClause},
+			},
+		},
+		fixture.Plan.Provenance,
+	)
+}
+
+// TestCopyPlanDiff asserts that CopyPlan.Diff holds a unified diff of each added or overwritten file,
+// and that it is only written to the plan file if selected.
+func (s *EgressCopySuite) TestCopyPlanDiff() {
+	t := s.T()
+
+	fixture, errs := s.NewCopier("egress", "egress", "EgressCopySuite", "yml", "diff_baseline")
+	cage_testkit.RequireNoErrors(t, errs)
+
+	fixture.Copier.ModuleRequire = true
+	fixture.Copier.OverwriteMin = true
+	fixture.Copier.Diff = true
+	fixture.Plan, errs = fixture.Copier.Run()
+	cage_testkit.RequireNoErrors(t, errs)
+	defer func() {
+		require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+	}()
+
+	require.Exactly(
+		t,
+		[]string{
+			"--- /dev/null\n+++ b/go.mod\n@@ -0,0 +1,3 @@\n+module copy.tld/user/proj\n+\n+go 1.12\n",
+			"--- a/internal/dep1/dep1.go\n+++ b/internal/dep1/dep1.go\n@@ -1,3 +1,3 @@\n package dep1\n \n-const Version = \"v1\"\n+const Version = \"v2\"\n",
+			"--- /dev/null\n+++ b/internal/dep2/dep2.go\n@@ -0,0 +1,3 @@\n+package dep2\n+\n+const Suffix = \"-dev\"\n",
+		},
+		fixture.Plan.Diff,
+	)
+
+	// Diff is omitted from the plan file unless selected.
+	planFile := filepath.Join(fixture.Plan.StagePath, "plan.yml")
+	require.NoError(t, fixture.Plan.WriteFile(planFile, nil))
+	planBytes, err := ioutil.ReadFile(planFile)
+	require.NoError(t, err)
+	require.NotContains(t, string(planBytes), "Diff:")
+
+	require.NoError(t, fixture.Plan.WriteFile(planFile, cage_strings.NewSet().AddSlice([]string{"Diff"})))
+	planBytes, err = ioutil.ReadFile(planFile)
+	require.NoError(t, err)
+	require.Contains(t, string(planBytes), "Diff:")
+	require.Contains(t, string(planBytes), "+const Version = \\\"v2\\\"")
+}
+
+// TestCopyPlanReport asserts that CopyPlan.Report holds the per-Ops.Dep figures, and is compared with
+// the report written by the previous export.
+func (s *EgressCopySuite) TestCopyPlanReport() {
+	t := s.T()
+
+	fixture := s.MustCopyFixtureWithGomod("egress", "egress", "EgressCopySuite", "yml", "report_baseline")
+	defer func() {
+		require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+	}()
+
+	dep1Figures := transplant.ReportFigures{
+		FilesAvailable: 1,
+		FilesCopied:    1,
+		LinesBefore:    5,
+		LinesAfter:     3,
+		BytesBefore:    67,
+		BytesAfter:     35,
+		GlobalsKept:    1,
+		GlobalsPruned:  1,
+	}
+	dep2Figures := transplant.ReportFigures{
+		FilesAvailable: 1,
+		LinesBefore:    3,
+		BytesBefore:    31,
+		GlobalsPruned:  1,
+	}
+
+	// ./copy/.transplant/report.json was written by an export which only selected dep1.
+	previous := dep1Figures
+
+	require.Exactly(
+		t,
+		&transplant.Report{
+			Dep: []transplant.ReportDep{
+				{FilePath: "dep1", Figures: dep1Figures, Packages: []transplant.ReportPackage{{Dir: "dep1", Figures: dep1Figures}}},
+				{FilePath: "dep2", Figures: dep2Figures, Packages: []transplant.ReportPackage{{Dir: "dep2", Figures: dep2Figures}}},
+			},
+			Total: transplant.ReportFigures{
+				FilesAvailable: 2,
+				FilesCopied:    1,
+				LinesBefore:    8,
+				LinesAfter:     3,
+				BytesBefore:    98,
+				BytesAfter:     35,
+				GlobalsKept:    1,
+				GlobalsPruned:  2,
+			},
+			Previous: &previous,
+		},
+		fixture.Plan.Report,
+	)
+	testkit_require.StringSliceExactly(
+		t,
+		[]string{filepath.Join(fixture.OutputPath, ".transplant", "report.json")},
+		fixture.Plan.Overwrite,
+	)
+
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
+
+	// Report is omitted from the plan file unless selected.
+	planFile := filepath.Join(fixture.Plan.StagePath, "plan.yml")
+	require.NoError(t, fixture.Plan.WriteFile(planFile, nil))
+	planBytes, err := ioutil.ReadFile(planFile)
+	require.NoError(t, err)
+	require.NotContains(t, string(planBytes), "Report:")
+
+	require.NoError(t, fixture.Plan.WriteFile(planFile, cage_strings.NewSet().AddSlice([]string{"Report"})))
+	planBytes, err = ioutil.ReadFile(planFile)
+	require.NoError(t, err)
+	require.Contains(t, string(planBytes), "Report:")
+	require.Contains(t, string(planBytes), "Previous:")
+}
+
+// TestEventLog asserts the step, counter, and command events emitted by an Audit and Copier which share
+// an EventLog.
+func (s *EgressCopySuite) TestEventLog() {
+	t := s.T()
+
+	s.SeedOutputTree("egress", "egress", "event_baseline")
+	op := s.Op("egress", "egress", "event_baseline", "yml", "event_baseline")
+	op.DryRun = true
+
+	var b bytes.Buffer
+	events := transplant.NewEventLog(&b)
+
+	// Select the log before Audit.Generate, which s.NewCopier would call, to also capture the audit's events.
+	audit := transplant.NewEgressAudit(op)
+	audit.Events = events
+	cage_testkit.RequireNoErrors(t, audit.Generate())
+	copier, err := transplant.NewCopier(context.Background(), audit)
+	require.NoError(t, err)
+	copier.Events = events
+	copier.ModuleRequire = true
+	plan, errs := copier.Run()
+	cage_testkit.RequireNoErrors(t, errs)
+	require.NoError(t, cage_file.RemoveAllSafer(plan.StagePath))
+
+	raw := b.String()
+
+	steps := make(map[string]int)
+	counters := make(map[string]int)
+	var commands []transplant.Event
+	for _, e := range readEvents(t, &b) {
+		switch e.Type {
+		case transplant.EventStepStart:
+			steps[e.Phase+" "+e.Step]++
+		case transplant.EventStepEnd:
+			require.Exactly(t, 1, steps[e.Phase+" "+e.Step], "step [%s] ended before it started", e.Step)
+			steps[e.Phase+" "+e.Step]++
+			require.NotNil(t, e.DurationMs)
+			require.True(t, *e.DurationMs >= 0)
+		case transplant.EventCounter:
+			require.Exactly(t, transplant.EventPhaseAudit, e.Phase)
+			require.NotNil(t, e.Value, "counter [%s] omitted its value", e.Name)
+			counters[e.Name] = *e.Value
+		case transplant.EventCommand:
+			commands = append(commands, e)
+		default:
+			require.FailNow(t, fmt.Sprintf("unexpected event type [%s]", e.Type))
+		}
+	}
+
+	require.Exactly(t, 2, steps["audit inspect files"])
+	require.Exactly(t, 2, steps["copy copy Ops.Dep implementation files to stage"])
+	for step, n := range steps {
+		require.Exactly(t, 2, n, "step [%s] did not end", step)
+	}
+
+	require.Exactly(t, 1, counters["LocalGoFiles"])
+	require.Exactly(t, 2, counters["AllDepGoFiles"])
+	require.Exactly(t, 1, counters["UsedDepGoFiles"])
+	require.Exactly(t, 1, counters["UsedDepGlobalIds"])
+	require.Contains(t, counters, "UnsupportedTraits")
+	require.Exactly(t, 0, counters["UnsupportedTraits"])
+	require.Contains(t, raw, `"Name":"UnsupportedTraits","Value":0}`)
+
+	require.Len(t, commands, 1)
+	require.True(t, strings.HasPrefix(commands[0].Command, "go list -m"), commands[0].Command)
+	require.Exactly(t, filepath.Join(s.FixturePath("egress", "event_baseline"), "origin"), commands[0].Dir)
+	require.NotNil(t, commands[0].ExitCode)
+	require.Exactly(t, 0, *commands[0].ExitCode)
+}
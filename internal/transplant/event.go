@@ -0,0 +1,260 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transplant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	std_exec "os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cage_errors "github.com/codeactual/transplant/internal/cage/errors"
+	cage_pkgs "github.com/codeactual/transplant/internal/cage/go/packages"
+	cage_exec "github.com/codeactual/transplant/internal/cage/os/exec"
+)
+
+// Values of Event.Type.
+const (
+	// EventStepStart indicates that an Audit.Generate or Copier.Run step started.
+	EventStepStart = "stepStart"
+
+	// EventStepEnd indicates that an Audit.Generate or Copier.Run step ended successfully.
+	EventStepEnd = "stepEnd"
+
+	// EventCounter holds the size of an Audit result, e.g. the number of Ops.Dep files used by Ops.From.
+	EventCounter = "counter"
+
+	// EventCommand indicates that a Go toolchain command exited.
+	EventCommand = "command"
+
+	// EventTrait indicates that inspection found a code trait which is not supported, e.g. duplicate imports.
+	// It is only fatal if followed by an EventError.
+	EventTrait = "trait"
+
+	// EventError holds an error which canceled the operation.
+	EventError = "error"
+
+	// EventMessage holds a free-text notice, e.g. a warning, which would otherwise be printed to standard error.
+	EventMessage = "message"
+)
+
+// Values of Event.Phase.
+const (
+	EventPhaseAudit = "audit"
+	EventPhaseCopy  = "copy"
+)
+
+// Event is a machine-readable progress, toolchain, or error message, e.g. to support CI annotations
+// and timing metrics.
+//
+// Fields other than Type and Time are only populated if relevant to the Type.
+type Event struct {
+	// Type is an Event* constant value.
+	Type string
+
+	// Time is when the event was emitted.
+	Time time.Time
+
+	// Phase is an EventPhase* constant value.
+	Phase string `json:",omitempty"`
+
+	// Step is the title of an Audit.Generate or Copier.Run step.
+	Step string `json:",omitempty"`
+
+	// DurationMs is the runtime of the step or command in milliseconds. It is a pointer so that a zero
+	// runtime is distinguishable from an event type without one.
+	DurationMs *float64 `json:",omitempty"`
+
+	// Name is the Audit field name of a counter, or the type of an unsupported trait.
+	Name string `json:",omitempty"`
+
+	// Value is the counter value. It is a pointer so that a zero count is distinguishable from an event type
+	// without one.
+	Value *int `json:",omitempty"`
+
+	// Command is the toolchain command's arguments, including the program name, separated by spaces.
+	Command string `json:",omitempty"`
+
+	// Dir is the toolchain command's working directory.
+	Dir string `json:",omitempty"`
+
+	// ExitCode is the toolchain command's exit code, or -1 if it could not be started.
+	ExitCode *int `json:",omitempty"`
+
+	// Msg describes the error, trait, or message.
+	Msg string `json:",omitempty"`
+
+	// File is the location of an unsupported trait, or the creation site of an error.
+	File string `json:",omitempty"`
+
+	// Line is the creation site of an error.
+	Line int `json:",omitempty"`
+
+	// PkgPath is the import path of the package which contains an unsupported trait.
+	PkgPath string `json:",omitempty"`
+}
+
+// EventLog writes Event values as JSON lines.
+//
+// Its methods are no-ops if the receiver is nil, so the Audit and Copier fields which hold one are optional.
+type EventLog struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewEventLog returns an initialized instance.
+func NewEventLog(w io.Writer) *EventLog {
+	return &EventLog{w: w}
+}
+
+// Emit writes the event as a JSON line. Event.Time is set if empty.
+func (l *EventLog) Emit(e Event) {
+	if l == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b, _ := json.Marshal(e) // all Event field types are supported
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(append(b, '\n')) //nolint:errcheck
+}
+
+// StepStart emits an EventStepStart.
+func (l *EventLog) StepStart(phase, step string) {
+	l.Emit(Event{Type: EventStepStart, Phase: phase, Step: step})
+}
+
+// StepEnd emits an EventStepEnd.
+func (l *EventLog) StepEnd(phase, step string, d time.Duration) {
+	l.Emit(Event{Type: EventStepEnd, Phase: phase, Step: step, DurationMs: durationMs(d)})
+}
+
+// Errors emits an EventError for each error, located at its creation site as reported by cage_errors.Event.
+func (l *EventLog) Errors(errs ...error) {
+	if l == nil {
+		return
+	}
+
+	var nonNil []error
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	if len(nonNil) == 0 {
+		return
+	}
+
+	// Omit stacks and causes, which also retains each Error.Msg in full, e.g. with wrapped messages.
+	event, eventErr := cage_errors.NewConfiguredEvent(cage_errors.Config{}, nonNil...)
+	if eventErr != nil {
+		for _, err := range nonNil {
+			l.Emit(Event{Type: EventError, Msg: err.Error()})
+		}
+		return
+	}
+
+	for _, e := range event.Errors {
+		line, _ := strconv.Atoi(e.Loc.Line)
+		l.Emit(Event{Type: EventError, Msg: e.Msg, File: e.Loc.File, Line: line})
+	}
+}
+
+// MessageWriter returns a writer which emits an EventMessage for each line written to it.
+func (l *EventLog) MessageWriter() io.Writer {
+	return &eventMessageWriter{log: l}
+}
+
+// eventMessageWriter emits an EventMessage for each complete line written to it.
+type eventMessageWriter struct {
+	log *EventLog
+	buf bytes.Buffer
+}
+
+// Write implements io.Writer.
+func (w *eventMessageWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil { // incomplete line
+			w.buf.WriteString(line)
+			break
+		}
+		if msg := strings.TrimSpace(line); msg != "" {
+			w.log.Emit(Event{Type: EventMessage, Msg: msg})
+		}
+	}
+	return len(p), nil
+}
+
+// eventExecutor emits an EventCommand for each command it runs.
+type eventExecutor struct {
+	cage_exec.Executor
+
+	log *EventLog
+}
+
+// Buffered implements cage_exec.Executor.
+func (e eventExecutor) Buffered(ctx context.Context, cmds ...*std_exec.Cmd) (stdout *bytes.Buffer, stderr *bytes.Buffer, res cage_exec.PipelineResult, err error) {
+	start := time.Now()
+	stdout, stderr, res, err = e.Executor.Buffered(ctx, cmds...)
+	e.emit(cmds, res, time.Since(start))
+	return stdout, stderr, res, err
+}
+
+// Standard implements cage_exec.Executor.
+func (e eventExecutor) Standard(ctx context.Context, stdout io.Writer, stderr io.Writer, stdin io.Reader, cmds ...*std_exec.Cmd) (res cage_exec.PipelineResult, err error) {
+	start := time.Now()
+	res, err = e.Executor.Standard(ctx, stdout, stderr, stdin, cmds...)
+	e.emit(cmds, res, time.Since(start))
+	return res, err
+}
+
+func (e eventExecutor) emit(cmds []*std_exec.Cmd, res cage_exec.PipelineResult, d time.Duration) {
+	for _, cmd := range cmds {
+		code := -1
+		if r, ok := res.Cmd[cmd]; ok {
+			code = r.Code
+		}
+		e.log.Emit(Event{
+			Type:       EventCommand,
+			Command:    strings.Join(cmd.Args, " "),
+			Dir:        cmd.Dir,
+			ExitCode:   &code,
+			DurationMs: durationMs(d),
+		})
+	}
+}
+
+// newExecutor returns a cage_exec.Executor which emits an EventCommand for each command if the log is non-nil.
+func newExecutor(l *EventLog) cage_exec.Executor {
+	if l == nil {
+		return cage_exec.CommonExecutor{}
+	}
+	return eventExecutor{Executor: cage_exec.CommonExecutor{}, log: l}
+}
+
+// emitTrait emits an EventTrait.
+func (l *EventLog) emitTrait(t cage_pkgs.UnsupportedTrait) {
+	l.Emit(Event{Type: EventTrait, Name: string(t.Type), File: t.FileOrDir, PkgPath: t.PkgPath, Msg: t.Msg})
+}
+
+// durationMs converts the duration to fractional milliseconds.
+func durationMs(d time.Duration) *float64 {
+	ms := float64(d) / float64(time.Millisecond)
+	return &ms
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transplant_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codeactual/transplant/internal/transplant"
+)
+
+// readEvents returns the JSON lines written by an EventLog.
+func readEvents(t *testing.T, b *bytes.Buffer) (events []transplant.Event) {
+	scanner := bufio.NewScanner(b)
+	for scanner.Scan() {
+		var e transplant.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e), scanner.Text())
+		require.False(t, e.Time.IsZero())
+		events = append(events, e)
+	}
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+func TestEventLogErrors(t *testing.T) {
+	var b bytes.Buffer
+	events := transplant.NewEventLog(&b)
+
+	events.Errors(nil, errors.New("first"), errors.Wrap(errors.New("cause"), "second"))
+
+	var nilEvents *transplant.EventLog
+	nilEvents.Errors(errors.New("ignored"))
+
+	fmt.Fprint(events.MessageWriter(), "warning: one\n\nwarning: two\npartial")
+
+	actual := readEvents(t, &b)
+	require.Len(t, actual, 4)
+
+	require.Exactly(t, transplant.EventError, actual[0].Type)
+	require.Exactly(t, "first", actual[0].Msg)
+	require.True(t, strings.HasSuffix(actual[0].File, "event_test.go"), actual[0].File)
+	require.True(t, actual[0].Line > 0)
+
+	require.Exactly(t, transplant.EventError, actual[1].Type)
+	require.Exactly(t, "second: cause", actual[1].Msg)
+	require.True(t, strings.HasSuffix(actual[1].File, "event_test.go"), actual[1].File)
+
+	require.Exactly(t, transplant.Event{Type: transplant.EventMessage, Time: actual[2].Time, Msg: "warning: one"}, actual[2])
+	require.Exactly(t, transplant.Event{Type: transplant.EventMessage, Time: actual[3].Time, Msg: "warning: two"}, actual[3])
+}
@@ -0,0 +1,268 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transplant
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	cage_git "github.com/codeactual/transplant/internal/cage/git"
+	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
+	cage_template "github.com/codeactual/transplant/internal/cage/text/template"
+)
+
+// DefaultGitCommitMessage is the Ops.Git.CommitMessage template used if none is selected.
+const DefaultGitCommitMessage = "Export from origin {{.OriginShortHead}}\n\n" +
+	"Transplant-Op: {{.OpId}}\n" +
+	"Transplant-Origin-Commit: {{.OriginHead}}\n"
+
+// worktreePrefix is the base name prefix of Worktree directories.
+const worktreePrefix = "transplant-worktree-"
+
+// gitShortHeadLen is the length of the gitTemplateData.OriginShortHead SHA prefix.
+const gitShortHeadLen = 12
+
+// gitTemplateData holds the fields available to the Ops.Git templates.
+type gitTemplateData struct {
+	OpId            string
+	OriginHead      string
+	OriginShortHead string
+}
+
+// GitCommitResult describes the outcome of GitCommit.
+type GitCommitResult struct {
+	// Sha identifies the new commit. It is empty if the export did not change the destination.
+	Sha string
+
+	// Tag is the name of the new tag. It is empty if Ops.Git.Tag is not selected or no commit was created.
+	Tag string
+}
+
+// Worktree is a temporary git worktree of an origin or copy revision.
+type Worktree struct {
+	// Dir is the absolute path to the worktree.
+	Dir string
+
+	// Sha identifies the checked out commit.
+	Sha string
+
+	// Root relocates the module directory, e.g. Ops.From.ModuleFilePath, into the worktree.
+	Root PathRebase
+
+	repo *cage_git.Repo
+
+	// topLevel is the absolute path to the root of the git working tree which the worktree mirrors.
+	topLevel string
+}
+
+// NewOriginWorktree checks out the revision, e.g. a tag or commit SHA, of the git repository which contains
+// Ops.From.ModuleFilePath into a new temporary worktree.
+//
+// Assign its Root to Config.OriginRoot before Config.ReadFile to read the origin's files from the worktree.
+func NewOriginWorktree(ctx context.Context, op Op, rev string) (w *Worktree, err error) {
+	return newWorktree(ctx, op.From.ModuleFilePath, rev, "Ops["+op.Id+"].From.ModuleFilePath")
+}
+
+// NewCopyWorktree checks out the revision, e.g. a branch or commit SHA, of the git repository which contains
+// Ops.To.ModuleFilePath into a new temporary worktree.
+//
+// Apply its Root to Ops.To.ModuleFilePath to read the copy's files from the worktree during ingress.
+func NewCopyWorktree(ctx context.Context, op Op, rev string) (w *Worktree, err error) {
+	return newWorktree(ctx, op.To.ModuleFilePath, rev, "Ops["+op.Id+"].To.ModuleFilePath")
+}
+
+// newWorktree checks out the revision of the git repository which contains the module directory.
+//
+// The worktree mirrors the whole repository so that paths outside the module directory, e.g. Ops.Dep.From.FilePath
+// values which point into other workspace modules, are also available.
+func newWorktree(ctx context.Context, moduleDir, rev, desc string) (w *Worktree, err error) {
+	repo := cage_git.NewRepo(moduleDir)
+
+	topLevel, err := repo.TopLevel(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s [%s] must be in a git working tree", desc, moduleDir)
+	}
+
+	realModuleDir, err := filepath.EvalSymlinks(moduleDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve %s [%s]", desc, moduleDir)
+	}
+	moduleRel, err := filepath.Rel(topLevel, realModuleDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get path of [%s] relative to [%s]", realModuleDir, topLevel)
+	}
+
+	sha, err := repo.RevParse(ctx, rev)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find revision [%s] of %s", rev, desc)
+	}
+
+	dir, err := ioutil.TempDir("", worktreePrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create worktree dir")
+	}
+
+	if err = repo.AddWorktree(ctx, dir, sha); err != nil {
+		if removeErr := cage_file.RemoveAllSafer(dir); removeErr != nil {
+			return nil, errors.Wrapf(removeErr, "failed to remove worktree dir [%s] after error: %+v", dir, err)
+		}
+		return nil, errors.Wrapf(err, "failed to check out revision [%s] of %s", rev, desc)
+	}
+
+	return &Worktree{
+		Dir:      dir,
+		Sha:      sha,
+		Root:     PathRebase{Old: moduleDir, New: filepath.Join(dir, moduleRel)},
+		repo:     repo,
+		topLevel: topLevel,
+	}, nil
+}
+
+// ApplyPatch applies the unified diff file, e.g. from `git diff` or `git format-patch`, to the worktree.
+//
+// Its paths are relative to the root of the repository.
+func (w *Worktree) ApplyPatch(ctx context.Context, name string) error {
+	return errors.Wrapf(cage_git.NewRepo(w.Dir).Apply(ctx, name), "failed to apply patch [%s] to worktree [%s]", name, w.Dir)
+}
+
+// Unapply returns the path with the worktree prefix replaced by the git working tree's, e.g. to report
+// the configured path of a file read from the worktree. It returns the path itself if it does not
+// descend from the worktree.
+func (w *Worktree) Unapply(p string) string {
+	if rebased := (PathRebase{Old: w.Root.New, New: w.Root.Old}).Apply(p); rebased != p {
+		return rebased
+	}
+	return PathRebase{Old: w.Dir, New: w.topLevel}.Apply(p)
+}
+
+// Remove deletes the worktree.
+func (w *Worktree) Remove(ctx context.Context) error {
+	return errors.Wrapf(w.repo.RemoveWorktree(ctx, w.Dir), "failed to remove worktree [%s]", w.Dir)
+}
+
+// RemoveOnErr deletes the worktree if at least one error is non-nil, e.g. before exiting due to the errors.
+//
+// It returns the errors, and the removal error if any. It is a no-op if the receiver is nil.
+func (w *Worktree) RemoveOnErr(ctx context.Context, errs ...error) []error {
+	if w == nil {
+		return errs
+	}
+	for _, err := range errs {
+		if err != nil {
+			if removeErr := w.Remove(ctx); removeErr != nil {
+				errs = append(errs, removeErr)
+			}
+			break
+		}
+	}
+	return errs
+}
+
+// GitCheckClean returns an error if the git working tree which contains Ops.To.ModuleFilePath has
+// uncommitted changes.
+//
+// If Ops.To.ModuleFilePath does not exist yet, e.g. before the first export, its closest existing
+// ancestor directory is checked.
+func GitCheckClean(ctx context.Context, op Op) error {
+	dir, err := existingAncestor(op.To.ModuleFilePath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	repo := cage_git.NewRepo(dir)
+
+	if _, err = repo.TopLevel(ctx); err != nil {
+		return errors.Wrapf(err, "Ops[%s].Git.Commit requires Ops[%s].To.ModuleFilePath [%s] to be in a git working tree", op.Id, op.Id, op.To.ModuleFilePath)
+	}
+
+	status, err := repo.Status(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(status) > 0 {
+		return errors.Errorf(
+			"Ops[%s].Git.Commit requires a clean working tree but [%s] has uncommitted changes:\n\t%s",
+			op.Id, dir, strings.Join(status, "\n\t"),
+		)
+	}
+
+	return nil
+}
+
+// GitCommit commits all changes under Ops.To.ModuleFilePath with the Ops.Git.CommitMessage message,
+// and creates the Ops.Git.Tag tag if selected.
+//
+// The message's origin commit is Ops.From.Rev if selected, or the HEAD commit of Ops.From.ModuleFilePath.
+//
+// No commit or tag is created if the export did not change the destination.
+func GitCommit(ctx context.Context, op Op) (res GitCommitResult, err error) {
+	originHead := op.From.Rev
+	if originHead == "" {
+		originHead, err = cage_git.NewRepo(op.From.ModuleFilePath).RevParse(ctx, "HEAD")
+		if err != nil {
+			return GitCommitResult{}, errors.Wrapf(err, "failed to get HEAD commit of Ops[%s].From.ModuleFilePath [%s]", op.Id, op.From.ModuleFilePath)
+		}
+	}
+
+	data := gitTemplateData{OpId: op.Id, OriginHead: originHead, OriginShortHead: originHead}
+	if len(originHead) > gitShortHeadLen {
+		data.OriginShortHead = originHead[:gitShortHeadLen]
+	}
+
+	message := op.Git.CommitMessage
+	if message == "" {
+		message = DefaultGitCommitMessage
+	}
+	messageBuf, err := cage_template.ExecuteBuffered(message, data)
+	if err != nil {
+		return GitCommitResult{}, errors.Wrapf(err, "failed to render Ops[%s].Git.CommitMessage", op.Id)
+	}
+
+	repo := cage_git.NewRepo(op.To.ModuleFilePath)
+
+	res.Sha, err = repo.Commit(ctx, messageBuf.String(), ".")
+	if err != nil {
+		return GitCommitResult{}, errors.Wrapf(err, "failed to commit Ops[%s].To.ModuleFilePath [%s]", op.Id, op.To.ModuleFilePath)
+	}
+	if res.Sha == "" || op.Git.Tag == "" {
+		return res, nil
+	}
+
+	tagBuf, err := cage_template.ExecuteBuffered(op.Git.Tag, data)
+	if err != nil {
+		return GitCommitResult{}, errors.Wrapf(err, "failed to render Ops[%s].Git.Tag", op.Id)
+	}
+	res.Tag = strings.TrimSpace(tagBuf.String())
+
+	if err = repo.Tag(ctx, res.Tag, res.Sha); err != nil {
+		return GitCommitResult{}, errors.Wrapf(err, "failed to create Ops[%s].Git.Tag [%s]", op.Id, res.Tag)
+	}
+
+	return res, nil
+}
+
+// existingAncestor returns the path if it exists, or its closest ancestor which exists.
+func existingAncestor(name string) (string, error) {
+	for {
+		exists, _, err := cage_file.Exists(name)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to check if [%s] exists", name)
+		}
+		if exists {
+			return name, nil
+		}
+		parent := filepath.Dir(name)
+		if parent == name {
+			return "", errors.Errorf("no ancestor of [%s] exists", name)
+		}
+		name = parent
+	}
+}
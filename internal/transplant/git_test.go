@@ -0,0 +1,295 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transplant_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	cage_git "github.com/codeactual/transplant/internal/cage/git"
+	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
+	"github.com/codeactual/transplant/internal/transplant"
+)
+
+// newGitRepo returns a Repo in a new temporary directory which has a commit identity configured.
+func newGitRepo(t *testing.T) *cage_git.Repo {
+	dir, err := ioutil.TempDir("", "transplant_git")
+	require.NoError(t, err)
+
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	repo := cage_git.NewRepo(dir)
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		_, err = repo.Run(ctx, args...)
+		require.NoError(t, err)
+	}
+
+	return repo
+}
+
+func TestGitCommit(t *testing.T) {
+	ctx := context.Background()
+
+	origin := newGitRepo(t)
+	dest := newGitRepo(t)
+	defer func() {
+		require.NoError(t, cage_file.RemoveAllSafer(origin.Dir))
+		require.NoError(t, cage_file.RemoveAllSafer(dest.Dir))
+	}()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(origin.Dir, "go.mod"), []byte("module origin.tld/user/proj\n"), 0644))
+	originHead, err := origin.Commit(ctx, "origin")
+	require.NoError(t, err)
+
+	op := transplant.Op{Id: "git_commit"}
+	op.From.ModuleFilePath = origin.Dir
+	op.To.ModuleFilePath = filepath.Join(dest.Dir, "proj") // not created yet
+	op.Git = transplant.GitSpec{
+		Commit:        true,
+		CommitMessage: transplant.DefaultGitCommitMessage,
+		Tag:           "export-{{.OriginShortHead}}",
+	}
+
+	require.NoError(t, transplant.GitCheckClean(ctx, op))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dest.Dir, "dirty.txt"), []byte("dirty"), 0644))
+	err = transplant.GitCheckClean(ctx, op)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "?? dirty.txt")
+	_, err = dest.Commit(ctx, "dirty")
+	require.NoError(t, err)
+
+	// Simulate the export.
+	f, err := cage_file.CreateFileAll(filepath.Join(op.To.ModuleFilePath, "go.mod"), 0, 0644, 0755)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	res, err := transplant.GitCommit(ctx, op)
+	require.NoError(t, err)
+	require.NotEmpty(t, res.Sha)
+	require.Exactly(t, "export-"+originHead[:12], res.Tag)
+
+	message, err := dest.Run(ctx, "log", "-1", "--format=%B")
+	require.NoError(t, err)
+	require.Exactly(
+		t,
+		"Export from origin "+originHead[:12]+"\n\nTransplant-Op: git_commit\nTransplant-Origin-Commit: "+originHead,
+		message,
+	)
+
+	tagged, err := dest.RevParse(ctx, res.Tag)
+	require.NoError(t, err)
+	require.Exactly(t, res.Sha, tagged)
+
+	require.NoError(t, transplant.GitCheckClean(ctx, op))
+
+	// No changes, no commit or tag.
+	res, err = transplant.GitCommit(ctx, op)
+	require.NoError(t, err)
+	require.Exactly(t, transplant.GitCommitResult{}, res)
+}
+
+func TestOriginWorktree(t *testing.T) {
+	ctx := context.Background()
+
+	baseDir, configFile, origin, dest := newHistoryFixture(t)
+	defer func() {
+		require.NoError(t, cage_file.RemoveAllSafer(baseDir))
+	}()
+
+	author := cage_git.Signature{Name: "Author", Email: "author@example.com", When: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	tagged := writeHistoryFiles(t, origin, author, "v1", map[string]string{
+		"go.mod":         "module origin.tld/user/proj\n\ngo 1.13\n",
+		"local/local.go": "package local\n\nimport \"origin.tld/user/proj/dep1\"\n\nconst Version = dep1.Version\n",
+		"dep1/dep1.go":   "package dep1\n\nconst Version = \"v1\"\n",
+		"dep2/dep2.go":   "package dep2\n",
+	})
+	require.NoError(t, origin.Tag(ctx, "v1", tagged))
+	writeHistoryFiles(t, origin, author, "v2", map[string]string{
+		"dep1/dep1.go": "package dep1\n\nconst Version = \"v2\"\n",
+	})
+
+	config := transplant.Config{}
+	require.Empty(t, config.ReadFile(configFile, "history"))
+
+	worktree, err := transplant.NewOriginWorktree(ctx, config.Ops["history"], "v1")
+	require.NoError(t, err)
+	require.Exactly(t, tagged, worktree.Sha)
+	require.Exactly(t, filepath.Join(origin.Dir, "dep1", "dep1.go"), worktree.Unapply(filepath.Join(worktree.Dir, "dep1", "dep1.go")))
+	require.Exactly(t, dest.Dir, worktree.Unapply(dest.Dir))
+
+	revConfig := transplant.Config{OriginRoot: worktree.Root}
+	require.Empty(t, revConfig.ReadFile(configFile, "history"))
+	op := revConfig.Ops["history"]
+	op.From.Rev = worktree.Sha
+	require.Exactly(t, worktree.Dir, op.From.ModuleFilePath)
+	require.Exactly(t, dest.Dir, op.To.ModuleFilePath)
+
+	audit := transplant.NewEgressAudit(op)
+	require.Empty(t, audit.Generate())
+	copier, err := transplant.NewCopier(ctx, audit)
+	require.NoError(t, err)
+	copier.ModuleRequire = true
+	plan, errs := copier.Run()
+	require.Empty(t, errs)
+	require.Exactly(t, tagged, plan.OriginRev)
+	require.NoError(t, cage_file.RemoveAllSafer(plan.StagePath))
+
+	require.NoError(t, worktree.Remove(ctx))
+	exists, _, err := cage_file.Exists(worktree.Dir)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	dep1, err := ioutil.ReadFile(filepath.Join(dest.Dir, "internal", "dep1", "dep1.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(dep1), `"v1"`)
+
+	op.Git = transplant.GitSpec{Commit: true}
+	res, err := transplant.GitCommit(ctx, op)
+	require.NoError(t, err)
+	info, err := dest.ReadCommit(ctx, res.Sha)
+	require.NoError(t, err)
+	require.Contains(t, info.Message, "Transplant-Origin-Commit: "+tagged)
+}
+
+func TestCopyWorktree(t *testing.T) {
+	ctx := context.Background()
+
+	baseDir, configFile, origin, dest := newHistoryFixture(t)
+	defer func() {
+		require.NoError(t, cage_file.RemoveAllSafer(baseDir))
+	}()
+
+	author := cage_git.Signature{Name: "Author", Email: "author@example.com", When: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	writeHistoryFiles(t, origin, author, "origin", map[string]string{
+		"go.mod":         "module origin.tld/user/proj\n\ngo 1.13\n",
+		"local/local.go": "package local\n\nimport (\n\t\"origin.tld/user/proj/dep1\"\n\t\"origin.tld/user/proj/dep2\"\n)\n\nconst Version = dep1.Version + dep2.Suffix\n",
+		"dep1/dep1.go":   "package dep1\n\nconst Version = \"v1\"\n",
+		"dep2/dep2.go":   "package dep2\n\nconst Suffix = \"\"\n",
+	})
+
+	config := transplant.Config{}
+	require.Empty(t, config.ReadFile(configFile, "history"))
+	op := config.Ops["history"]
+
+	// Export the origin and simulate a contributor's branch of the copy.
+
+	audit := transplant.NewEgressAudit(op)
+	require.Empty(t, audit.Generate())
+	copier, err := transplant.NewCopier(ctx, audit)
+	require.NoError(t, err)
+	copier.ModuleRequire = true
+	plan, errs := copier.Run()
+	require.Empty(t, errs)
+	require.NoError(t, cage_file.RemoveAllSafer(plan.StagePath))
+	_, err = dest.Commit(ctx, "export")
+	require.NoError(t, err)
+
+	_, err = dest.Run(ctx, "checkout", "--quiet", "-b", "contrib")
+	require.NoError(t, err)
+	writeHistoryFiles(t, dest, author, "contribution", map[string]string{
+		"local/local.go": "package local\n\nimport (\n\t\"copy.tld/user/proj/internal/dep1\"\n\t\"copy.tld/user/proj/internal/dep2\"\n)\n\nconst Version = dep1.Version + dep2.Suffix + \"-contrib\"\n",
+	})
+	_, err = dest.Run(ctx, "checkout", "--quiet", "-")
+	require.NoError(t, err)
+
+	patch, err := dest.Run(ctx, "diff", "HEAD", "contrib")
+	require.NoError(t, err)
+	patchPath := filepath.Join(baseDir, "contrib.diff")
+	require.NoError(t, ioutil.WriteFile(patchPath, []byte(patch+"\n"), 0644))
+
+	originLocal := filepath.Join(origin.Dir, "local", "local.go")
+
+	for _, useRev := range []bool{true, false} {
+		rev := "HEAD"
+		if useRev {
+			rev = "contrib"
+		}
+
+		worktree, err := transplant.NewCopyWorktree(ctx, op, rev)
+		require.NoError(t, err)
+		if !useRev {
+			require.NoError(t, worktree.ApplyPatch(ctx, patchPath))
+		}
+
+		// Read the config again because NewIngressAudit modifies the Ops.Dep slice.
+		ingressConfig := transplant.Config{}
+		require.Empty(t, ingressConfig.ReadFile(configFile, "history"))
+		ingressOp := ingressConfig.Ops["history"]
+		ingressOp.To.ModuleFilePath = worktree.Root.Apply(op.To.ModuleFilePath)
+		ingressOp.DryRun = true
+
+		audit := transplant.NewIngressAudit(ingressOp)
+		require.Empty(t, audit.Generate())
+		copier, err := transplant.NewCopier(ctx, audit)
+		require.NoError(t, err)
+		copier.OverwriteMin = true
+		plan, errs := copier.Run()
+		require.Empty(t, errs)
+		require.NoError(t, cage_file.RemoveAllSafer(plan.StagePath))
+		require.NoError(t, worktree.Remove(ctx))
+
+		require.Exactly(t, []string{originLocal}, plan.Overwrite, rev)
+		require.Empty(t, plan.Add, rev)
+		require.Empty(t, plan.Remove, rev)
+	}
+
+	// The copy's working tree was not changed.
+	status, err := dest.Status(ctx)
+	require.NoError(t, err)
+	require.Empty(t, status)
+}
+
+func TestWorktreeRemoveOnErr(t *testing.T) {
+	ctx := context.Background()
+
+	baseDir, configFile, origin, _ := newHistoryFixture(t)
+	defer func() {
+		require.NoError(t, cage_file.RemoveAllSafer(baseDir))
+	}()
+
+	author := cage_git.Signature{Name: "Author", Email: "author@example.com", When: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	writeHistoryFiles(t, origin, author, "v1", map[string]string{
+		"go.mod": "module origin.tld/user/proj\n\ngo 1.13\n",
+	})
+
+	config := transplant.Config{}
+	require.Empty(t, config.ReadFile(configFile, "history"))
+
+	var nilWorktree *transplant.Worktree
+	cause := errors.New("cause")
+	require.Exactly(t, []error{cause}, nilWorktree.RemoveOnErr(ctx, cause))
+
+	worktree, err := transplant.NewOriginWorktree(ctx, config.Ops["history"], "HEAD")
+	require.NoError(t, err)
+
+	// The worktree is retained if all errors are nil.
+	require.Exactly(t, []error{nil}, worktree.RemoveOnErr(ctx, nil))
+	exists, _, err := cage_file.Exists(worktree.Dir)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	require.Exactly(t, []error{nil, cause}, worktree.RemoveOnErr(ctx, nil, cause))
+	exists, _, err = cage_file.Exists(worktree.Dir)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
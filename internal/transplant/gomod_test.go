@@ -7,17 +7,18 @@
 package transplant_test
 
 import (
+	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
-	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	cage_os "github.com/codeactual/transplant/internal/cage/os"
+	cage_exec "github.com/codeactual/transplant/internal/cage/os/exec"
 	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
-	cage_runtime "github.com/codeactual/transplant/internal/cage/runtime"
 	testkit_require "github.com/codeactual/transplant/internal/cage/testkit/testify/require"
 )
 
@@ -35,8 +36,8 @@ func TestGomodSuite(t *testing.T) {
 // TestEgressSyncBaseline asserts that the copy's go.mod contains versions in its "require" and "replace"
 // directives which match those in the origin's go.mod.
 //
-// The golden/go.mod contains an older golang.org/x/sync than origin/go.mod because github.com/onsi/gomega@1.4.3
-// specifies that version in its go.mod as an indirect.
+// The golden/go.sum contains the origin/go.sum lines except for those of github.com/pkg/errors,
+// which the copy does not import.
 //
 // The versions in the fixture go.mod files should remain out-of-date in order to assert that the toolchain
 // use neither modifies the origin go.mod at all nor updates the copy's go.mod to use versions newer/different
@@ -54,16 +55,8 @@ func (s *GomodSuite) TestEgressSyncBaseline() {
 		}()
 	}
 
-	// go mod tidy produces a one-line difference in 1.12 from what's produced in 1.11/1.13.
-	semver, err := cage_runtime.VersionSemver()
-	require.NoError(t, err)
-	goldenPath := fixture.GoldenPath
-	if strings.HasPrefix(semver, "1.12") {
-		goldenPath += "_go112"
-	}
-
-	s.DirsMatchExceptGomod(goldenPath, fixture.Plan.StagePath)
-	s.DirsMatchExceptGomod(goldenPath, fixture.OutputPath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.Plan.StagePath)
+	s.DirsMatchExceptGomod(fixture.GoldenPath, fixture.OutputPath)
 }
 
 // TestEgressVendor asserts that if GOFLAGS contains "-mod=vendor" and <ModuleFilePath>/{go.sum,vendor/modules.txt}
@@ -71,8 +64,8 @@ func (s *GomodSuite) TestEgressSyncBaseline() {
 //
 // The origin/vendor/ tree contains fake packages in order to make the origin/ fixtures loadable.
 //
-// The golden/go.mod contains an older golang.org/x/sync than origin/go.mod because github.com/onsi/gomega@1.4.3
-// specifies that version in its go.mod as an indirect.
+// The golden/go.sum contains the origin/go.sum lines except for those of github.com/pkg/errors,
+// which the copy does not import.
 //
 // The versions in the fixture go.mod files should remain out-of-date in order to assert that the toolchain
 // use neither modifies the origin go.mod at all nor updates the copy's go.mod to use versions newer/different
@@ -93,13 +86,7 @@ func (s *GomodSuite) TestEgressVendor() {
 		}()
 	}
 
-	// go mod tidy produces a one-line difference in 1.12 from what's produced in 1.11/1.13.
-	semver, err := cage_runtime.VersionSemver()
-	require.NoError(t, err)
 	goldenPath := fixture.GoldenPath
-	if strings.HasPrefix(semver, "1.12") {
-		goldenPath += "_go112"
-	}
 
 	// assert stage content
 
@@ -183,3 +170,34 @@ func (s *GomodSuite) TestEgressVendor() {
 		filepath.Join(fixture.OutputPath, "go.sum"),
 	)
 }
+
+// TestEgressIndirect asserts that if the copy's go.mod selects Go 1.17 or newer, it also requires the modules
+// which provide packages transitively imported by the copy, as indirect, at the versions selected by the origin.
+//
+// The local package imports github.com/stretchr/testify/assert, which imports packages of github.com/davecgh/go-spew,
+// github.com/pmezard/go-difflib, and gopkg.in/yaml.v2. The origin requires github.com/pkg/errors only for
+// the unused1 package, so neither its requirement nor its go.sum lines are copied.
+//
+// The copy must build, and verify, without any "go" command updating its go.mod/go.sum.
+func (s *GomodSuite) TestEgressIndirect() {
+	t := s.T()
+
+	fixture := s.MustCopyFixtureWithGomod("egress", "gomod", "GomodSuite", "yml", "egress_indirect")
+	if fixture.Plan.StagePath != "" {
+		defer func() {
+			require.NoError(t, cage_file.RemoveAllSafer(fixture.Plan.StagePath))
+		}()
+	}
+
+	testkit_require.DirsMatch(t, fixture.GoldenPath, fixture.Plan.StagePath)
+	testkit_require.DirsMatch(t, fixture.GoldenPath, fixture.OutputPath)
+
+	ctx := context.Background()
+	for _, args := range [][]string{{"build", "./..."}, {"mod", "verify"}} {
+		cmd := exec.CommandContext(ctx, "go", args...)
+		cmd.Env = append(os.Environ(), "GO111MODULE=on", "GOFLAGS=-mod=readonly", "GOPROXY=off")
+		cmd.Dir = fixture.Plan.StagePath
+		_, stderr, _, err := cage_exec.CommonExecutor{}.Buffered(ctx, cmd)
+		require.NoError(t, err, stderr.String())
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transplant
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	cage_pkgs "github.com/codeactual/transplant/internal/cage/go/packages"
+)
+
+// Values of GraphNode.Kind.
+const (
+	// GraphNodeRoot identifies the node which represents all LocalGoFiles, i.e. Audit.LocalGoFilesDagRoot.
+	GraphNodeRoot = "root"
+
+	// GraphNodeDir identifies a node which represents an Ops.Dep package directory.
+	GraphNodeDir = "dir"
+
+	// GraphNodePackage identifies a node which represents an Ops.Dep package, e.g. a test package, or
+	// all globals of a directory if GraphConfig.Package is selected.
+	GraphNodePackage = "package"
+
+	// GraphNodeGlobal identifies a node which represents an Ops.Dep global identifier.
+	GraphNodeGlobal = "global"
+)
+
+// GraphConfig selects the scope and granularity of Audit.Graph results.
+type GraphConfig struct {
+	// Package is true if vertices should be collapsed into one node per package directory.
+	Package bool
+
+	// Target, if non-empty, limits the graph to paths from Audit.LocalGoFilesDagRoot to the global
+	// identified by "<import path>.<name>", e.g. "origin.tld/user/proj/dep1.Type.Method".
+	Target string
+
+	// Pruned is true if Ops.Dep globals which are not in Audit.DepGlobalIdUsageDag, and will be pruned
+	// from the copy, should be included as nodes without edges.
+	Pruned bool
+}
+
+// GraphNode is a vertex of Audit.DepGlobalIdUsageDag or a pruned global.
+type GraphNode struct {
+	// Id is unique within the Graph.
+	Id string
+
+	// Label is a readable name, e.g. "<import path>.<name>" of a global.
+	Label string
+
+	// Kind is a GraphNode* constant value.
+	Kind string
+
+	// Pruned is true if the node represents globals which will be omitted from the copy.
+	Pruned bool `json:",omitempty"`
+}
+
+// GraphEdge indicates that the From node uses the To node.
+type GraphEdge struct {
+	From string
+	To   string
+}
+
+// Graph is a serializable view of Audit.DepGlobalIdUsageDag.
+type Graph struct {
+	// Nodes is ordered by Id.
+	Nodes []GraphNode
+
+	// Edges is ordered by From and then To.
+	Edges []GraphEdge
+}
+
+// Graph returns a view of DepGlobalIdUsageDag based on the config.
+//
+// It must be called after Generate.
+func (a *Audit) Graph(cfg GraphConfig) (g Graph, err error) {
+	vertices := make(map[string]cage_pkgs.GlobalId)
+	for _, v := range a.DepGlobalIdUsageDag.Vertices() {
+		id := v.(cage_pkgs.GlobalId)
+		vertices[id.String()] = id
+	}
+
+	edges := make(map[string][]string)
+	for key, id := range vertices {
+		for _, to := range a.DepGlobalIdUsageDag.VerticesFrom(id) {
+			edges[key] = append(edges[key], to.(cage_pkgs.GlobalId).String())
+		}
+	}
+
+	pruned := make(map[string]cage_pkgs.GlobalId)
+	if cfg.Pruned || cfg.Target != "" {
+		for _, id := range a.prunedDepGlobalIds() {
+			pruned[id.String()] = id
+		}
+	}
+
+	if cfg.Target != "" {
+		targetKey, targetFound := "", false
+		for key, id := range vertices {
+			if a.graphNodeKind(id) == GraphNodeGlobal && id.PkgPath+"."+id.Name == cfg.Target {
+				targetKey, targetFound = key, true
+				break
+			}
+		}
+
+		if !targetFound {
+			for key, id := range pruned {
+				if id.PkgPath+"."+id.Name == cfg.Target {
+					// There is no path from the root to a pruned global, so it is the only node.
+					vertices = map[string]cage_pkgs.GlobalId{}
+					edges = map[string][]string{}
+					pruned = map[string]cage_pkgs.GlobalId{key: id}
+					return a.newGraph(cfg, vertices, edges, pruned), nil
+				}
+			}
+			return Graph{}, errors.Errorf("global [%s] was not found in Ops.Dep packages", cfg.Target)
+		}
+
+		vertices, edges = pathsTo(vertices, edges, targetKey)
+		pruned = map[string]cage_pkgs.GlobalId{}
+	}
+
+	return a.newGraph(cfg, vertices, edges, pruned), nil
+}
+
+// newGraph returns the Graph of the vertices, edges between them indexed by source vertex, and pruned globals.
+//
+// All map keys and values are cage_pkgs.GlobalId.String() values.
+func (a *Audit) newGraph(cfg GraphConfig, vertices map[string]cage_pkgs.GlobalId, edges map[string][]string, pruned map[string]cage_pkgs.GlobalId) (g Graph) {
+	nodes := make(map[string]GraphNode)
+
+	// nodeKey returns the ID of the node which represents the vertex.
+	nodeKey := func(id cage_pkgs.GlobalId) string {
+		if cfg.Package && a.graphNodeKind(id) != GraphNodeRoot {
+			return id.Dir()
+		}
+		return id.String()
+	}
+
+	addNode := func(id cage_pkgs.GlobalId, isPruned bool) string {
+		key := nodeKey(id)
+		node, ok := nodes[key]
+		if !ok {
+			node = GraphNode{Id: key, Label: a.graphNodeLabel(id), Kind: a.graphNodeKind(id), Pruned: isPruned}
+		}
+		if cfg.Package && node.Kind != GraphNodeRoot {
+			node.Kind = GraphNodePackage
+			if id.PkgPath != "" {
+				node.Label = id.PkgPath
+			}
+			// A package node is only pruned if all its globals are pruned.
+			node.Pruned = (!ok || node.Pruned) && isPruned
+		}
+		nodes[key] = node
+		return key
+	}
+
+	for _, id := range vertices {
+		addNode(id, false)
+	}
+	for _, id := range pruned {
+		addNode(id, true)
+	}
+
+	seenEdges := make(map[GraphEdge]bool)
+	for fromKey, toKeys := range edges {
+		for _, toKey := range toKeys {
+			edge := GraphEdge{From: nodeKey(vertices[fromKey]), To: nodeKey(vertices[toKey])}
+			if edge.From == edge.To || seenEdges[edge] {
+				continue
+			}
+			seenEdges[edge] = true
+			g.Edges = append(g.Edges, edge)
+		}
+	}
+
+	for _, node := range nodes {
+		g.Nodes = append(g.Nodes, node)
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].Id < g.Nodes[j].Id
+	})
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+
+	return g
+}
+
+// pathsTo returns the subset of the vertices and edges which lie on a path to the target vertex.
+//
+// All map keys and values are cage_pkgs.GlobalId.String() values.
+func pathsTo(vertices map[string]cage_pkgs.GlobalId, edges map[string][]string, target string) (map[string]cage_pkgs.GlobalId, map[string][]string) {
+	reverse := make(map[string][]string)
+	for from, toKeys := range edges {
+		for _, to := range toKeys {
+			reverse[to] = append(reverse[to], from)
+		}
+	}
+
+	// All ancestors of the target are reachable from the root because every vertex is.
+	keep := map[string]bool{target: true}
+	queue := []string{target}
+	for len(queue) > 0 {
+		var key string
+		key, queue = queue[0], queue[1:]
+		for _, from := range reverse[key] {
+			if !keep[from] {
+				keep[from] = true
+				queue = append(queue, from)
+			}
+		}
+	}
+
+	keptVertices := make(map[string]cage_pkgs.GlobalId)
+	keptEdges := make(map[string][]string)
+	for key := range keep {
+		keptVertices[key] = vertices[key]
+		for _, to := range edges[key] {
+			if keep[to] {
+				keptEdges[key] = append(keptEdges[key], to)
+			}
+		}
+	}
+
+	return keptVertices, keptEdges
+}
+
+// prunedDepGlobalIds returns the Ops.Dep globals, declared in inspected non-test files, which are not
+// in DepGlobalIdUsageDag.
+func (a *Audit) prunedDepGlobalIds() (ids []cage_pkgs.GlobalId) {
+	for _, dir := range a.inspector.GlobalIdNodes.SortedDirs() {
+		if !a.AllDepDirs.Contains(dir) {
+			continue
+		}
+
+		dirNodes := a.inspector.GlobalIdNodes[dir]
+
+		for _, pkgName := range dirNodes.SortedPkgNames() {
+			pkgNodes := dirNodes[pkgName]
+			for _, idName := range pkgNodes.SortedIds() {
+				node := pkgNodes[idName]
+				if a.isTestFilename(node.InspectInfo.Filename) {
+					continue
+				}
+				id := cage_pkgs.NewGlobalId(node.InspectInfo.PkgPath, pkgName, node.InspectInfo.Filename, idName)
+				if !a.IsDepGlobalUsedInLocal(id) {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+
+	return ids
+}
+
+// graphNodeKind returns the GraphNode.Kind of the DepGlobalIdUsageDag vertex.
+func (a *Audit) graphNodeKind(id cage_pkgs.GlobalId) string {
+	switch {
+	case id == a.LocalGoFilesDagRoot:
+		return GraphNodeRoot
+	case id.Name == "" && id.PkgName == "":
+		return GraphNodeDir
+	case id.Name == "":
+		return GraphNodePackage
+	}
+	return GraphNodeGlobal
+}
+
+// graphNodeLabel returns the GraphNode.Label of the DepGlobalIdUsageDag vertex.
+func (a *Audit) graphNodeLabel(id cage_pkgs.GlobalId) string {
+	dir := id.Dir()
+	if rel, err := filepath.Rel(a.op.From.ModuleFilePath, dir); err == nil {
+		dir = rel
+	}
+
+	switch a.graphNodeKind(id) {
+	case GraphNodeRoot:
+		return "Ops.From"
+	case GraphNodeDir:
+		return dir
+	case GraphNodePackage:
+		return dir + " (" + id.PkgName + ")"
+	}
+	return id.PkgPath + "." + id.Name
+}
+
+// WriteDot writes the graph in Graphviz DOT format. Pruned nodes are dashed and red.
+func (g Graph) WriteDot(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "digraph transplant {\n\trankdir=LR;\n\tnode [shape=box];"); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, n := range g.Nodes {
+		attrs := "label=" + strconv.Quote(n.Label)
+		if n.Kind == GraphNodeRoot {
+			attrs += ", style=bold"
+		}
+		if n.Pruned {
+			attrs += ", style=dashed, color=red, fontcolor=red"
+		}
+		if _, err := fmt.Fprintf(w, "\t%s [%s];\n", strconv.Quote(n.Id), attrs); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	for _, e := range g.Edges {
+		if _, err := fmt.Fprintf(w, "\t%s -> %s;\n", strconv.Quote(e.From), strconv.Quote(e.To)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if _, err := fmt.Fprintln(w, "}"); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// WriteJSON writes the graph as an indented JSON object.
+func (g Graph) WriteJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal Graph")
+	}
+	if _, err = w.Write(append(b, '\n')); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transplant
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	cage_errors "github.com/codeactual/transplant/internal/cage/errors"
+	cage_git "github.com/codeactual/transplant/internal/cage/git"
+	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
+	cage_strings "github.com/codeactual/transplant/internal/cage/strings"
+)
+
+// HistoryCommit describes the replay of an origin commit.
+type HistoryCommit struct {
+	// OriginSha identifies the origin commit.
+	OriginSha string
+
+	// Sha identifies the destination commit. It is empty if the export of the origin commit did not
+	// change the destination, e.g. if the commit only changed Ops.Dep files which the copy does not use.
+	Sha string
+}
+
+// History replays the origin commits which follow a revision in the destination: each is exported from
+// a temporary git worktree and committed to the destination with the origin commit's author, date, and message.
+//
+// Candidate commits are those which change Ops.From.LocalFilePath, an Ops.Dep.From.FilePath, an
+// Ops.Substitute.FilePath, or the origin's go.mod/go.sum/vendor/go.work. Because an export only includes the
+// Ops.Dep files which the copy uses, a candidate whose export does not change the destination is skipped.
+//
+// The config file is read once per commit, from its current location, with Ops.From.ModuleFilePath
+// relocated to the commit's worktree.
+type History struct {
+	// Ctx is applied to context-aware operations to support externally-defined cancellation.
+	Ctx context.Context
+
+	// ConfigFile is the name of the config file, e.g. "transplant.yml".
+	ConfigFile string
+
+	// OpId selects the Config.Ops operation.
+	OpId string
+
+	// Since is the origin revision, e.g. a tag or commit SHA, after which commits are replayed.
+	//
+	// The destination is expected to already contain the export of Since.
+	Since string
+
+	// ModuleSumWarn is applied to each Copier.
+	ModuleSumWarn bool
+
+	// GoVersionWarn is applied to each Copier.
+	GoVersionWarn bool
+
+	// Progress receives a message about each replayed commit.
+	Progress io.Writer
+
+	// Stderr receives messages about errors which are printed but not returned, and the Copier's warnings.
+	Stderr io.Writer
+}
+
+// NewHistory returns an initialized instance.
+func NewHistory(ctx context.Context, configFile, opId, since string) *History {
+	return &History{
+		Ctx:        ctx,
+		ConfigFile: configFile,
+		OpId:       opId,
+		Since:      since,
+		Progress:   ioutil.Discard,
+		Stderr:     os.Stderr,
+	}
+}
+
+// Run replays the commits, oldest first.
+//
+// It returns the replayed commits even if an error occurs, so a later run can resume from the last one.
+func (h *History) Run() (commits []HistoryCommit, errs []error) {
+	op, errs := h.readOp(PathRebase{})
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	origin := cage_git.NewRepo(op.From.ModuleFilePath)
+
+	if err := GitCheckClean(h.Ctx, op); err != nil {
+		return nil, []error{errors.WithStack(err)}
+	}
+
+	sinceSha, err := origin.RevParse(h.Ctx, h.Since)
+	if err != nil {
+		return nil, []error{errors.Wrapf(err, "failed to find origin revision [%s]", h.Since)}
+	}
+
+	shas, err := origin.RevList(h.Ctx, sinceSha+"..HEAD", historyPaths(op)...)
+	if err != nil {
+		return nil, []error{errors.Wrapf(err, "failed to list origin commits since [%s]", h.Since)}
+	}
+	if len(shas) == 0 {
+		return nil, nil
+	}
+
+	// Egress does not remove destination files, so track the files of each export in order to
+	// remove those which the next export omits, e.g. because the origin commit deleted them.
+	prevFiles, errs := h.export(op, sinceSha, true)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	dest := cage_git.NewRepo(op.To.ModuleFilePath)
+
+	for _, sha := range shas {
+		info, err := origin.ReadCommit(h.Ctx, sha)
+		if err != nil {
+			return commits, []error{errors.WithStack(err)}
+		}
+
+		files, exportErrs := h.export(op, sha, false)
+		if len(exportErrs) > 0 {
+			return commits, exportErrs
+		}
+
+		for _, name := range prevFiles.SortedSlice() {
+			if files.Contains(name) {
+				continue
+			}
+			if err = removeFileAndEmptyDirs(name, op.To.ModuleFilePath); err != nil {
+				return commits, []error{errors.WithStack(err)}
+			}
+		}
+		prevFiles = files
+
+		message := info.Message + "\n\n" +
+			"Transplant-Op: " + op.Id + "\n" +
+			"Transplant-Origin-Commit: " + info.Sha + "\n"
+
+		destSha, err := dest.CommitAs(h.Ctx, info.Author, message, ".")
+		if err != nil {
+			return commits, []error{errors.Wrapf(err, "failed to commit export of origin commit [%s]", info.Sha)}
+		}
+
+		commits = append(commits, HistoryCommit{OriginSha: info.Sha, Sha: destSha})
+
+		subject := strings.SplitN(info.Message, "\n", 2)[0]
+		if destSha == "" {
+			fmt.Fprintf(h.Progress, "%s %s: skipped (no changes)\n", shortSha(info.Sha), subject)
+		} else {
+			fmt.Fprintf(h.Progress, "%s %s: committed %s\n", shortSha(info.Sha), subject, shortSha(destSha))
+		}
+	}
+
+	return commits, nil
+}
+
+// readOp reads the config file and returns the selected operation.
+func (h *History) readOp(originRoot PathRebase) (op Op, errs []error) {
+	config := Config{OriginRoot: originRoot}
+
+	if errs = config.ReadFile(h.ConfigFile, h.OpId); len(errs) > 0 {
+		errsLen := len(errs)
+		return Op{}, append(errs, errors.Errorf("config file contains %d issue(s), canceled [%s] operation", errsLen, h.OpId))
+	}
+
+	op, ok := config.Ops[h.OpId]
+	if !ok {
+		return Op{}, []error{errors.Errorf("config file [%s] does not contain operation [%s]", h.ConfigFile, h.OpId)}
+	}
+
+	return op, nil
+}
+
+// export copies the origin revision to the destination and returns the absolute paths of
+// the destination files which the copy includes.
+//
+// The revision is checked out in a temporary worktree which is removed afterward. If dryRun is true,
+// the destination is not modified.
+func (h *History) export(op Op, rev string, dryRun bool) (files *cage_strings.Set, errs []error) {
+	worktree, err := NewOriginWorktree(h.Ctx, op, rev)
+	if err != nil {
+		return nil, []error{errors.WithStack(err)}
+	}
+
+	defer func() {
+		cage_errors.Append(&errs, worktree.Remove(h.Ctx))
+	}()
+
+	revOp, errs := h.readOp(worktree.Root)
+	if len(errs) > 0 {
+		return nil, append(errs, errors.Errorf("failed to read config for origin revision [%s]", rev))
+	}
+
+	revOp.From.Rev = worktree.Sha
+	revOp.DryRun = dryRun
+
+	audit := NewEgressAudit(revOp)
+
+	if errs = audit.Generate(); len(errs) > 0 {
+		return nil, append(errs, errors.Errorf("failed to audit origin revision [%s]", rev))
+	}
+
+	if len(audit.UnconfiguredDirs) > 0 {
+		audit.PrintUnconfiguredDirs(h.Stderr)
+		return nil, []error{errors.Errorf("operation [%s] config does not account for at least one dependency of origin revision [%s]", h.OpId, rev)}
+	}
+
+	copier, err := NewCopier(h.Ctx, audit)
+	if err != nil {
+		return nil, []error{errors.WithStack(err)}
+	}
+
+	copier.ModuleRequire = true
+	copier.ModuleSumWarn = h.ModuleSumWarn
+	copier.GoVersionWarn = h.GoVersionWarn
+	copier.OverwriteMin = true
+	copier.Stderr = h.Stderr
+
+	plan, errs := copier.Run()
+	if len(errs) > 0 {
+		fmt.Fprintf(h.Stderr, "(files staged for copy were saved here: %s)\n", plan.StagePath)
+		return nil, append(errs, errors.Errorf("failed to copy origin revision [%s]", rev))
+	}
+
+	if err = cage_file.RemoveAllSafer(plan.StagePath); err != nil {
+		return nil, []error{errors.WithStack(err)}
+	}
+
+	files = cage_strings.NewSet().
+		AddSlice(plan.Add).
+		AddSlice(plan.Overwrite).
+		AddSlice(plan.OverwriteSkip)
+
+	return files, nil
+}
+
+// historyPaths returns the paths, relative to Ops.From.ModuleFilePath, whose changes may change the export.
+func historyPaths(op Op) []string {
+	paths := []string{op.From.LocalFilePath, "go.mod", "go.sum"}
+
+	if op.From.Vendor {
+		paths = append(paths, "vendor")
+	}
+
+	if op.From.WorkFilePath != "" {
+		if rel, err := filepath.Rel(op.From.ModuleFilePath, op.From.WorkFilePath); err == nil {
+			paths = append(paths, rel, rel+".sum")
+		}
+	}
+
+	for _, dep := range op.Dep {
+		if dep.From.FilePath == "" {
+			paths = append(paths, ".")
+		} else {
+			paths = append(paths, dep.From.FilePath)
+		}
+	}
+
+	for _, sub := range op.Substitute {
+		paths = append(paths, sub.FilePath)
+	}
+
+	return paths
+}
+
+// removeFileAndEmptyDirs removes the file, if it exists, and then its ancestor directories below
+// the root directory which are left empty.
+func removeFileAndEmptyDirs(name, root string) error {
+	exists, _, err := cage_file.Exists(name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if [%s] exists", name)
+	}
+	if exists {
+		if err = cage_file.RemoveSafer(name); err != nil {
+			return errors.Wrapf(err, "failed to remove destination file [%s]", name)
+		}
+	}
+
+	for dir := filepath.Dir(name); strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		exists, _, err = cage_file.Exists(dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check if [%s] exists", dir)
+		}
+		if !exists {
+			continue
+		}
+
+		names, err := cage_file.Readdirnames(dir, 0)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read dir [%s]", dir)
+		}
+		if len(names) > 0 {
+			break
+		}
+		if err = os.Remove(dir); err != nil {
+			return errors.Wrapf(err, "failed to remove empty destination dir [%s]", dir)
+		}
+	}
+
+	return nil
+}
+
+// shortSha returns the first gitShortHeadLen characters of the SHA.
+func shortSha(sha string) string {
+	if len(sha) > gitShortHeadLen {
+		return sha[:gitShortHeadLen]
+	}
+	return sha
+}
@@ -0,0 +1,181 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transplant_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	cage_git "github.com/codeactual/transplant/internal/cage/git"
+	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
+	"github.com/codeactual/transplant/internal/transplant"
+)
+
+const historyConfig = `Ops:
+  history:
+    From:
+      ModuleFilePath: '{{._config_dir}}/origin'
+      LocalFilePath: 'local'
+    To:
+      ModuleImportPath: 'copy.tld/user/proj'
+      ModuleFilePath: '{{._config_dir}}/dest'
+      LocalFilePath: 'local'
+    Dep:
+      - From:
+          FilePath: 'dep1'
+        To:
+          FilePath: 'internal/dep1'
+      - From:
+          FilePath: 'dep2'
+        To:
+          FilePath: 'internal/dep2'
+`
+
+// writeHistoryFiles updates the files of the repository and commits them as the author.
+//
+// Files with empty content are removed.
+func writeHistoryFiles(t *testing.T, repo *cage_git.Repo, author cage_git.Signature, message string, files map[string]string) string {
+	for name, content := range files {
+		abs := filepath.Join(repo.Dir, name)
+		if content == "" {
+			require.NoError(t, os.Remove(abs))
+			continue
+		}
+		f, err := cage_file.CreateFileAll(abs, 0, 0644, 0755)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		require.NoError(t, ioutil.WriteFile(abs, []byte(content), 0644))
+	}
+
+	sha, err := repo.CommitAs(context.Background(), author, message)
+	require.NoError(t, err)
+	require.NotEmpty(t, sha)
+
+	return sha
+}
+
+// newHistoryFixture creates a base directory which contains the historyConfig file and the git repositories
+// of its origin and destination. The base directory is returned for removal.
+func newHistoryFixture(t *testing.T) (baseDir, configFile string, origin, dest *cage_git.Repo) {
+	ctx := context.Background()
+
+	baseDir, err := ioutil.TempDir("", "transplant_history")
+	require.NoError(t, err)
+	baseDir, err = filepath.EvalSymlinks(baseDir)
+	require.NoError(t, err)
+
+	configFile = filepath.Join(baseDir, "transplant.yml")
+	require.NoError(t, ioutil.WriteFile(configFile, []byte(historyConfig), 0644))
+
+	var repos []*cage_git.Repo
+	for _, name := range []string{"origin", "dest"} {
+		dir := filepath.Join(baseDir, name)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+
+		repo := cage_git.NewRepo(dir)
+		for _, args := range [][]string{
+			{"init", "--quiet"},
+			{"config", "user.name", "Committer"},
+			{"config", "user.email", "committer@example.com"},
+		} {
+			_, err = repo.Run(ctx, args...)
+			require.NoError(t, err)
+		}
+		repos = append(repos, repo)
+	}
+
+	return baseDir, configFile, repos[0], repos[1]
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+
+	baseDir, configFile, origin, dest := newHistoryFixture(t)
+	defer func() {
+		require.NoError(t, cage_file.RemoveAllSafer(baseDir))
+	}()
+	destDir := dest.Dir
+
+	alice := cage_git.Signature{Name: "Alice", Email: "alice@example.com", When: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	bob := cage_git.Signature{Name: "Bob", Email: "bob@example.com", When: time.Date(2019, 2, 1, 0, 0, 0, 0, time.UTC)}
+
+	since := writeHistoryFiles(t, origin, alice, "initial", map[string]string{
+		"go.mod":         "module origin.tld/user/proj\n\ngo 1.13\n",
+		"README.md":      "origin\n",
+		"local/local.go": "package local\n\nimport \"origin.tld/user/proj/dep1\"\n\nfunc Local() string { return dep1.Used }\n",
+		"local/extra.go": "package local\n\nconst Extra = 1\n",
+		"dep1/dep1.go":   "package dep1\n\nconst Used = \"used\"\n",
+		"dep2/dep2.go":   "package dep2\n\nconst Unused = 1\n",
+	})
+	localChange := writeHistoryFiles(t, origin, alice, "change local\n\nbody", map[string]string{
+		"local/local.go": "package local\n\nimport \"origin.tld/user/proj/dep1\"\n\nfunc Local() string { return dep1.Used + \"!\" }\n",
+	})
+	unusedDepChange := writeHistoryFiles(t, origin, bob, "change unused dep", map[string]string{
+		"dep2/dep2.go": "package dep2\n\nconst Unused = 2\n",
+	})
+	writeHistoryFiles(t, origin, bob, "change unselected file", map[string]string{
+		"README.md": "origin 2\n",
+	})
+	removal := writeHistoryFiles(t, origin, bob, "remove extra", map[string]string{
+		"local/extra.go": "",
+	})
+
+	history := transplant.NewHistory(ctx, configFile, "history", since)
+	progress := &bytes.Buffer{}
+	history.Progress = progress
+	history.Stderr = ioutil.Discard
+
+	commits, errs := history.Run()
+	require.Empty(t, errs)
+	require.Len(t, commits, 3)
+	require.Exactly(t, localChange, commits[0].OriginSha)
+	require.NotEmpty(t, commits[0].Sha)
+	require.Exactly(t, transplant.HistoryCommit{OriginSha: unusedDepChange}, commits[1])
+	require.Exactly(t, removal, commits[2].OriginSha)
+	require.NotEmpty(t, commits[2].Sha)
+
+	require.Contains(t, progress.String(), "change unused dep: skipped (no changes)")
+
+	info, err := dest.ReadCommit(ctx, commits[0].Sha)
+	require.NoError(t, err)
+	require.Exactly(t, alice.Name, info.Author.Name)
+	require.Exactly(t, alice.Email, info.Author.Email)
+	require.True(t, alice.When.Equal(info.Author.When))
+	require.Exactly(t, "change local\n\nbody\n\nTransplant-Op: history\nTransplant-Origin-Commit: "+localChange, info.Message)
+
+	info, err = dest.ReadCommit(ctx, commits[2].Sha)
+	require.NoError(t, err)
+	require.Exactly(t, bob.Name, info.Author.Name)
+	require.True(t, bob.When.Equal(info.Author.When))
+
+	local, err := ioutil.ReadFile(filepath.Join(destDir, "local", "local.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(local), `"copy.tld/user/proj/internal/dep1"`)
+	require.Contains(t, string(local), `dep1.Used + "!"`)
+
+	for _, name := range []string{"local/extra.go", "internal/dep2", "README.md"} {
+		exists, _, existsErr := cage_file.Exists(filepath.Join(destDir, name))
+		require.NoError(t, existsErr)
+		require.False(t, exists, name)
+	}
+
+	status, err := dest.Status(ctx)
+	require.NoError(t, err)
+	require.Empty(t, status)
+
+	// The worktrees were removed.
+	worktrees, err := origin.Run(ctx, "worktree", "list", "--porcelain")
+	require.NoError(t, err)
+	require.NotContains(t, worktrees, "transplant-worktree-")
+}
@@ -0,0 +1,246 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transplant
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cage_filepath "github.com/codeactual/transplant/internal/cage/path/filepath"
+)
+
+// Values of FileProvenance.Rewrite.
+const (
+	// RewriteImportPath indicates that import declarations were updated to destination import paths.
+	RewriteImportPath = "import paths"
+
+	// RewriteReplaceString indicates that Ops.From.ReplaceString/Ops.Dep.From.ReplaceString replaced
+	// import path substrings in the file's text.
+	RewriteReplaceString = "ReplaceString"
+
+	// RewritePackageClause indicates that the package clause was renamed after the destination import path.
+	RewritePackageClause = "package clause"
+)
+
+// FileProvenance describes how a CopyPlan.Add/CopyPlan.Overwrite file was selected and produced.
+type FileProvenance struct {
+	// Name is the absolute path of the destination file.
+	Name string `yaml:"Name"`
+
+	// Origin is the absolute path of the file's source, or empty if it was generated, e.g. go.mod.
+	Origin string `json:",omitempty" toml:",omitempty" yaml:"Origin,omitempty"`
+
+	// Section identifies the config or step which selected the file.
+	//
+	// Examples: "Ops.From.GoFilePath", "Ops.From.Tests", "Ops.Dep[1].From.CopyOnlyFilePath", "vendor", "go.mod".
+	Section string `json:",omitempty" toml:",omitempty" yaml:"Section,omitempty"`
+
+	// Pattern is the Section's Include pattern which matched the file, or which matched its directory if the
+	// Section is GoFilePath-based. It is empty if no pattern applies, e.g. the directory is the Section's base path.
+	Pattern string `json:",omitempty" toml:",omitempty" yaml:"Pattern,omitempty"`
+
+	// Rewrite describes the changes which were applied to the file, e.g. RewriteImportPath or
+	// "pruned 3 global identifier(s)".
+	Rewrite []string `json:",omitempty" toml:",omitempty" yaml:"Rewrite,omitempty"`
+}
+
+// addProvenance records the provenance of a stage file if Copier.Provenance is selected.
+func (c *Copier) addProvenance(p FileProvenance) {
+	if !c.Provenance {
+		return
+	}
+	c.provenance[p.Name] = p
+}
+
+// addFileProvenance records the provenance of a stage file copied from an Ops.From or Ops.Dep.From file
+// if Copier.Provenance is selected.
+func (c *Copier) addFileProvenance(name, origin string, rewrites fileRewrites) {
+	if !c.Provenance {
+		return
+	}
+
+	var section, pattern string
+	if c.Audit.isLocalFile(origin) {
+		section, pattern = c.localProvenance(origin)
+	} else {
+		for n, d := range c.Op.Dep {
+			depPath := FromAbs(c.Op, d.From.FilePath)
+			if origin == depPath || strings.HasPrefix(origin, depPath+string(filepath.Separator)) {
+				section, pattern = c.depProvenance(n, origin)
+				break
+			}
+		}
+	}
+
+	c.addProvenance(FileProvenance{Name: name, Origin: origin, Section: section, Pattern: pattern, Rewrite: rewrites})
+}
+
+// fileRewrites collects the FileProvenance.Rewrite values of a stage file.
+type fileRewrites []string
+
+// step records the rewrite if the content changed, and returns the new content.
+func (r *fileRewrites) step(rewrite string, before, after []byte) []byte {
+	if !bytes.Equal(before, after) {
+		*r = append(*r, rewrite)
+	}
+	return after
+}
+
+// add records the rewrite if it is non-empty.
+func (r *fileRewrites) add(rewrite string) {
+	if rewrite != "" {
+		*r = append(*r, rewrite)
+	}
+}
+
+// imports records RewriteImportPath if the import paths of the origin Go file differ from those in its stage content.
+func (r *fileRewrites) imports(origin string, stageBytes []byte) {
+	if cage_filepath.IsGoFile(origin) && importsChanged(origin, stageBytes) {
+		*r = append(fileRewrites{RewriteImportPath}, *r...)
+	}
+}
+
+// importsChanged returns true if the import paths of the Go file differ from those in its stage content.
+//
+// It returns false if either cannot be parsed.
+func importsChanged(origin string, stageBytes []byte) bool {
+	fset := token.NewFileSet()
+
+	originAst, err := parser.ParseFile(fset, origin, nil, parser.ImportsOnly)
+	if err != nil {
+		return false
+	}
+	stageAst, err := parser.ParseFile(fset, origin, stageBytes, parser.ImportsOnly)
+	if err != nil {
+		return false
+	}
+
+	if len(originAst.Imports) != len(stageAst.Imports) {
+		return true
+	}
+	for n := range originAst.Imports {
+		if originAst.Imports[n].Path.Value != stageAst.Imports[n].Path.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// renameRewrite returns the FileProvenance.Rewrite value of a file whose base name was changed by
+// File.RenameIfGoFileNamedAfterRootPackage, or an empty string if it was not renamed.
+func renameRewrite(origin, renamed string) string {
+	if origin == renamed {
+		return ""
+	}
+	return "renamed from " + filepath.Base(origin)
+}
+
+// pruneRewrite returns the FileProvenance.Rewrite value of a file whose global identifiers were pruned,
+// or an empty string if none were.
+func pruneRewrite(count int) string {
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("pruned %d global identifier(s)", count)
+}
+
+// matchedPattern returns the first query Include pattern which matches the path, relative to the base path,
+// or an empty string if none match or the path is the base path.
+func matchedPattern(base string, query FilePathQuery, name string) string {
+	rel, err := filepath.Rel(base, name)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+
+	res, err := cage_filepath.PathMatchAny(cage_filepath.MatchAnyInput{
+		Name:    rel,
+		Include: query.Include,
+		Exclude: query.Exclude,
+	})
+	if err != nil {
+		return ""
+	}
+
+	return res.Include
+}
+
+// localProvenance returns the section and pattern which selected an Ops.From file.
+func (c *Copier) localProvenance(filename string) (section, pattern string) {
+	base := FromAbs(c.Op, c.Op.From.LocalFilePath)
+
+	switch {
+	case c.Audit.LocalGoTestFiles.Contains(filename):
+		return "Ops.From.Tests", matchedPattern(base, c.Op.From.GoFilePath, filepath.Dir(filename))
+	case c.Audit.LocalGoFiles.Contains(filename):
+		return "Ops.From.GoFilePath", matchedPattern(base, c.Op.From.GoFilePath, filepath.Dir(filename))
+	case c.Audit.LocalGoDescendantFiles.Contains(filename):
+		return "Ops.From.GoDescendantFilePath", matchedPattern(base, c.Op.From.GoDescendantFilePath, filename)
+	case c.Audit.LocalEmbedFiles.Contains(filename):
+		return "Ops.From go:embed", ""
+	case c.Audit.LocalOtherFiles.Contains(filename):
+		return "Ops.From non-Go source", matchedPattern(base, c.Op.From.GoFilePath, filepath.Dir(filename))
+	}
+	return "Ops.From.CopyOnlyFilePath", matchedPattern(base, c.Op.From.CopyOnlyFilePath, filename)
+}
+
+// depProvenance returns the section and pattern which selected a file of the Ops.Dep at the index.
+func (c *Copier) depProvenance(depIndex int, filename string) (section, pattern string) {
+	dep := c.Op.Dep[depIndex]
+	base := FromAbs(c.Op, dep.From.FilePath)
+	prefix := fmt.Sprintf("Ops.Dep[%d].From", depIndex)
+
+	switch {
+	case c.Audit.DepGoTestFiles.Contains(filename):
+		return prefix + ".Tests", matchedPattern(base, dep.From.GoFilePath, filepath.Dir(filename))
+	case c.Audit.UsedDepGoFiles.Contains(filename):
+		return prefix + ".GoFilePath", matchedPattern(base, dep.From.GoFilePath, filepath.Dir(filename))
+	case c.Audit.DepGoDescendantFiles.Contains(filename):
+		return prefix + ".GoDescendantFilePath", matchedPattern(base, dep.From.GoDescendantFilePath, filename)
+	case c.Audit.DepEmbedFiles.Contains(filename):
+		return prefix + " go:embed", ""
+	case c.Audit.DepOtherFiles.Contains(filename):
+		return prefix + " non-Go source", matchedPattern(base, dep.From.GoFilePath, filepath.Dir(filename))
+	}
+	return prefix + ".CopyOnlyFilePath", matchedPattern(base, dep.From.CopyOnlyFilePath, filename)
+}
+
+// finalizeProvenance populates CopyPlan.Provenance with the recorded provenance of each added or
+// overwritten file after applying Ops.From.RenameFilePath renames.
+func (c *Copier) finalizeProvenance() {
+	if !c.Provenance {
+		return
+	}
+
+	for _, p := range c.Op.From.RenameFilePath {
+		oldName := ToAbs(c.Op, p.Old)
+		prov, ok := c.provenance[oldName]
+		if !ok {
+			continue
+		}
+		delete(c.provenance, oldName)
+		prov.Name = ToAbs(c.Op, p.New)
+		prov.Rewrite = append(prov.Rewrite, "renamed from "+p.Old+" by Ops.From.RenameFilePath")
+		c.provenance[prov.Name] = prov
+	}
+
+	for _, name := range append(append([]string{}, c.Plan.Add...), c.Plan.Overwrite...) {
+		prov, ok := c.provenance[name]
+		if !ok {
+			prov = FileProvenance{Name: name}
+		}
+		c.Plan.Provenance = append(c.Plan.Provenance, prov)
+	}
+
+	sort.Slice(c.Plan.Provenance, func(i, j int) bool {
+		return c.Plan.Provenance[i].Name < c.Plan.Provenance[j].Name
+	})
+}
@@ -0,0 +1,305 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transplant
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReportFigures describes the size and pruning impact of an export on a set of Ops.Dep non-test Go files.
+type ReportFigures struct {
+	// FilesAvailable is the number of files under the Ops.Dep.From.FilePath trees.
+	FilesAvailable int `yaml:"FilesAvailable"`
+
+	// FilesCopied is the number of files which were copied because they contain a direct/transitive
+	// dependency of packages under Ops.From.FilePath.
+	FilesCopied int `yaml:"FilesCopied"`
+
+	// LinesBefore is the line count of the available files.
+	LinesBefore int `yaml:"LinesBefore"`
+
+	// LinesAfter is the line count of the copied files after pruning and rewrites.
+	LinesAfter int `yaml:"LinesAfter"`
+
+	// BytesBefore is the size of the available files.
+	BytesBefore int64 `yaml:"BytesBefore"`
+
+	// BytesAfter is the size of the copied files after pruning and rewrites.
+	BytesAfter int64 `yaml:"BytesAfter"`
+
+	// GlobalsKept is the number of global identifiers declared in the copied files.
+	GlobalsKept int `yaml:"GlobalsKept"`
+
+	// GlobalsPruned is the number of global identifiers omitted from the copy, including those
+	// declared in files which were not copied.
+	GlobalsPruned int `yaml:"GlobalsPruned"`
+}
+
+func (f ReportFigures) String() string {
+	return fmt.Sprintf(
+		"files %d/%d, lines %d -> %d, bytes %d -> %d, globals %d kept %d pruned",
+		f.FilesCopied, f.FilesAvailable, f.LinesBefore, f.LinesAfter, f.BytesBefore, f.BytesAfter, f.GlobalsKept, f.GlobalsPruned,
+	)
+}
+
+// add increments each figure by its counterpart in the input.
+func (f *ReportFigures) add(o ReportFigures) {
+	f.FilesAvailable += o.FilesAvailable
+	f.FilesCopied += o.FilesCopied
+	f.LinesBefore += o.LinesBefore
+	f.LinesAfter += o.LinesAfter
+	f.BytesBefore += o.BytesBefore
+	f.BytesAfter += o.BytesAfter
+	f.GlobalsKept += o.GlobalsKept
+	f.GlobalsPruned += o.GlobalsPruned
+}
+
+// ReportPackage holds the figures of an Ops.Dep package.
+type ReportPackage struct {
+	// Dir is the package's directory relative to Ops.From.ModuleFilePath.
+	Dir string `yaml:"Dir"`
+
+	Figures ReportFigures `yaml:"Figures"`
+}
+
+// ReportDep holds the figures of an Ops.Dep and each of its packages.
+type ReportDep struct {
+	// FilePath is the Ops.Dep.From.FilePath value.
+	FilePath string `yaml:"FilePath"`
+
+	Figures ReportFigures `yaml:"Figures"`
+
+	// Packages is ordered by Dir.
+	Packages []ReportPackage `json:",omitempty" toml:",omitempty" yaml:"Packages,omitempty"`
+}
+
+// Report describes the size and pruning impact of an export.
+//
+// It is written to Ops.To.ReportFilePath if selected, without the Previous field.
+type Report struct {
+	// Dep is ordered by Ops.Dep index.
+	Dep []ReportDep `json:",omitempty" toml:",omitempty" yaml:"Dep,omitempty"`
+
+	// Modules is the number of third-party modules required by the copy's go.mod files.
+	Modules int `yaml:"Modules"`
+
+	// Total holds the sum of the Dep figures.
+	Total ReportFigures `yaml:"Total"`
+
+	// Previous holds the Total figures of the report found at Ops.To.ReportFilePath, if any,
+	// before the export.
+	Previous *ReportFigures `json:",omitempty" toml:",omitempty" yaml:"Previous,omitempty"`
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+
+	for _, dep := range r.Dep {
+		b.WriteString(fmt.Sprintf("Ops.Dep[%s]: %s\n", dep.FilePath, dep.Figures))
+		for _, pkg := range dep.Packages {
+			b.WriteString(fmt.Sprintf("\t%s: %s\n", pkg.Dir, pkg.Figures))
+		}
+	}
+
+	b.WriteString(fmt.Sprintf("Modules: %d\n", r.Modules))
+	b.WriteString(fmt.Sprintf("Total: %s\n", r.Total))
+	if r.Previous != nil {
+		b.WriteString(fmt.Sprintf("Previous: %s\n", r.Previous))
+	}
+
+	return b.String()
+}
+
+// reportFile holds the figures of an Ops.Dep non-test Go file which was copied to the stage.
+type reportFile struct {
+	// lines is the line count of the stage file.
+	lines int
+
+	// bytes is the size of the stage file.
+	bytes int64
+
+	// pruned is the number of global identifiers omitted from the stage file.
+	pruned int
+}
+
+// addReportFile records the figures of an Ops.Dep non-test Go file which was copied to the stage
+// if the report will be generated.
+func (c *Copier) addReportFile(filename string, stageBytes []byte, pruned int) {
+	if !c.Report && c.Op.To.ReportFilePath == "" {
+		return
+	}
+	c.reportFiles[filename] = reportFile{lines: countLines(stageBytes), bytes: int64(len(stageBytes)), pruned: pruned}
+}
+
+// countLines returns the number of lines in the content, including a final line without a newline.
+func countLines(b []byte) int {
+	n := bytes.Count(b, []byte("\n"))
+	if len(b) > 0 && b[len(b)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+// report populates CopyPlan.Report if Copier.Report or Ops.To.ReportFilePath is selected, and writes
+// Ops.To.ReportFilePath to the stage.
+func (c *Copier) report() (errs []error) {
+	// Ingress does not attempt to update the origin's Ops.Dep packages by design, so there is no pruning to report.
+	if c.Op.Ingress {
+		return []error{}
+	}
+
+	if !c.Report && c.Op.To.ReportFilePath == "" {
+		return []error{}
+	}
+
+	r := Report{Modules: len(c.requires)}
+
+	for _, dep := range c.Op.Dep {
+		depPath := FromAbs(c.Op, dep.From.FilePath)
+		reportDep := ReportDep{FilePath: dep.From.FilePath}
+		pkgs := make(map[string]*ReportFigures)
+
+		for _, filename := range c.Audit.AllDepGoFiles.SortedSlice() {
+			if c.Audit.DepGoTestFiles.Contains(filename) || c.Audit.isLocalFile(filename) {
+				continue
+			}
+			if filename != depPath && !strings.HasPrefix(filename, depPath+string(filepath.Separator)) {
+				continue
+			}
+
+			originBytes, err := ioutil.ReadFile(filename)
+			if err != nil {
+				return []error{errors.Wrapf(err, "failed to read file [%s]", filename)}
+			}
+
+			globals := countGlobalIds(filename, originBytes)
+
+			fig := ReportFigures{
+				FilesAvailable: 1,
+				LinesBefore:    countLines(originBytes),
+				BytesBefore:    int64(len(originBytes)),
+			}
+			if staged, ok := c.reportFiles[filename]; ok {
+				fig.FilesCopied = 1
+				fig.LinesAfter = staged.lines
+				fig.BytesAfter = staged.bytes
+				fig.GlobalsPruned = staged.pruned
+				if globals > staged.pruned {
+					fig.GlobalsKept = globals - staged.pruned
+				}
+			} else {
+				fig.GlobalsPruned = globals
+			}
+
+			pkgDir, err := filepath.Rel(c.Op.From.ModuleFilePath, filepath.Dir(filename))
+			if err != nil {
+				return []error{errors.Wrapf(err, "failed to get path of [%s] relative to Ops.From.ModuleFilePath", filename)}
+			}
+			if pkgs[pkgDir] == nil {
+				pkgs[pkgDir] = &ReportFigures{}
+			}
+			pkgs[pkgDir].add(fig)
+			reportDep.Figures.add(fig)
+		}
+
+		for dir, fig := range pkgs {
+			reportDep.Packages = append(reportDep.Packages, ReportPackage{Dir: dir, Figures: *fig})
+		}
+		sort.Slice(reportDep.Packages, func(i, j int) bool {
+			return reportDep.Packages[i].Dir < reportDep.Packages[j].Dir
+		})
+
+		r.Total.add(reportDep.Figures)
+		r.Dep = append(r.Dep, reportDep)
+	}
+
+	if c.Op.To.ReportFilePath == "" {
+		c.Plan.Report = &r
+		return []error{}
+	}
+
+	reportAbsPath := ToAbs(c.Op, c.Op.To.ReportFilePath)
+	if previousBytes, err := ioutil.ReadFile(reportAbsPath); err == nil {
+		var previous Report
+		if err = json.Unmarshal(previousBytes, &previous); err != nil {
+			return []error{errors.Wrapf(err, "failed to parse Ops.To.ReportFilePath [%s]", reportAbsPath)}
+		}
+		r.Previous = &previous.Total
+	} else if !os.IsNotExist(err) {
+		return []error{errors.Wrapf(err, "failed to read Ops.To.ReportFilePath [%s]", reportAbsPath)}
+	}
+
+	c.Plan.Report = &r
+
+	persisted := r
+	persisted.Previous = nil
+	reportBytes, err := json.MarshalIndent(persisted, "", "  ")
+	if err != nil {
+		return []error{errors.Wrap(err, "failed to marshal Report")}
+	}
+	reportBytes = append(reportBytes, '\n')
+
+	stageReportPath := c.Stage.Path(c.Op.To.ReportFilePath)
+	if err = os.MkdirAll(filepath.Dir(stageReportPath), newDirMode); err != nil {
+		return []error{errors.Wrapf(err, "failed to create stage dir [%s]", filepath.Dir(stageReportPath))}
+	}
+	if err = ioutil.WriteFile(stageReportPath, reportBytes, newFileMode); err != nil {
+		return []error{errors.Wrapf(err, "failed to write stage file [%s]", stageReportPath)}
+	}
+	if err = c.Stage.AddFileByName(c.Op.To.ReportFilePath); err != nil {
+		return []error{errors.WithStack(err)}
+	}
+	if skip, err := c.skipWrite(reportAbsPath, bytes.NewReader(reportBytes)); err != nil {
+		return []error{errors.WithStack(err)}
+	} else if skip {
+		c.logFileActivity(reportAbsPath, skipOverwriteLogMsg)
+	}
+
+	c.logFileActivity(reportAbsPath, "added to stage as the Ops.To.ReportFilePath")
+	c.addProvenance(FileProvenance{Name: reportAbsPath, Section: "Ops.To.ReportFilePath"})
+
+	return []error{}
+}
+
+// countGlobalIds returns the number of global identifiers declared in the Go file's content,
+// including methods, or 0 if it cannot be parsed.
+func countGlobalIds(filename string, content []byte) (n int) {
+	f, err := parser.ParseFile(token.NewFileSet(), filename, content, 0)
+	if err != nil {
+		return 0
+	}
+
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			n++
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					n++
+				case *ast.ValueSpec:
+					n += len(s.Names)
+				}
+			}
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,3 @@
+#include "dep1.h"
+
+int add(int a, int b) { return a + b; }
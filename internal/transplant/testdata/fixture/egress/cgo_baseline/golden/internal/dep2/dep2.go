@@ -0,0 +1,4 @@
+package dep2
+
+// Sub is implemented in sub.s.
+func Sub(a, b int) int
@@ -0,0 +1,10 @@
+package proj
+
+import (
+	"copy.tld/user/proj/internal/dep1"
+	"copy.tld/user/proj/internal/dep2"
+)
+
+func ExportedFunc1() int {
+	return dep1.Add(1, 2) + dep2.Sub(3, 2)
+}
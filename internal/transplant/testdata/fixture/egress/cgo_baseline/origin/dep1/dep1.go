@@ -0,0 +1,21 @@
+package dep1
+
+// #include "dep1.h"
+import "C"
+
+func Add(a, b int) int {
+	return int(C.add(C.int(a), C.int(b)))
+}
+
+//export Callback
+func Callback() C.int {
+	return C.int(callbackValue())
+}
+
+func callbackValue() int {
+	return 1
+}
+
+func Unused() int {
+	return 2
+}
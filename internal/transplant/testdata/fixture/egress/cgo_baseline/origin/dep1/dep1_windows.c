@@ -0,0 +1,3 @@
+#include <windows.h>
+
+int windows_only(void) { return GetCurrentProcessId() > 0; }
@@ -0,0 +1,8 @@
+package dep2
+
+// Sub is implemented in sub.s.
+func Sub(a, b int) int
+
+func Unused() int {
+	return 2
+}
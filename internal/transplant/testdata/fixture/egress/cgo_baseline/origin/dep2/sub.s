@@ -0,0 +1,8 @@
+#include "textflag.h"
+
+// func Sub(a, b int) int
+TEXT ·Sub(SB), NOSPLIT, $0-24
+	MOVQ a+0(FP), AX
+	SUBQ b+8(FP), AX
+	MOVQ AX, ret+16(FP)
+	RET
@@ -0,0 +1,5 @@
+//go:build internal
+
+#include "textflag.h"
+
+// Instrumented variants of the dep2 functions are only assembled in internal builds.
@@ -0,0 +1,10 @@
+package local
+
+import (
+	"origin.tld/user/proj/dep1"
+	"origin.tld/user/proj/dep2"
+)
+
+func ExportedFunc1() int {
+	return dep1.Add(1, 2) + dep2.Sub(3, 2)
+}
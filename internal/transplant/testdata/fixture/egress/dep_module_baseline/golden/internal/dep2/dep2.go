@@ -0,0 +1,5 @@
+package dep2
+
+func Sum(a, b int) int {
+	return a + b
+}
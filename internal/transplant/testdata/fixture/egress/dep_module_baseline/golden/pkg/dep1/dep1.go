@@ -0,0 +1,9 @@
+package dep1
+
+import (
+	"copy.tld/user/proj/internal/dep2"
+)
+
+func Add(a, b int) int {
+	return dep2.Sum(a, b)
+}
@@ -0,0 +1,7 @@
+module copy.tld/user/proj/pkg/dep1
+
+go 1.12
+
+require copy.tld/user/proj v0.0.0-00010101000000-000000000000
+
+replace copy.tld/user/proj => ../..
@@ -0,0 +1,9 @@
+package proj
+
+import (
+	"copy.tld/user/proj/pkg/dep1"
+)
+
+func ExportedFunc1() int {
+	return dep1.Add(1, 2)
+}
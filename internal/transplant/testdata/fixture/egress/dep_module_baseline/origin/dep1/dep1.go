@@ -0,0 +1,13 @@
+package dep1
+
+import (
+	"origin.tld/user/proj/dep2"
+)
+
+func Add(a, b int) int {
+	return dep2.Sum(a, b)
+}
+
+func Unused() int {
+	return 0
+}
@@ -0,0 +1,9 @@
+package local
+
+import (
+	"origin.tld/user/proj/dep1"
+)
+
+func ExportedFunc1() int {
+	return dep1.Add(1, 2)
+}
@@ -0,0 +1,8 @@
+package proj
+
+import (
+	"copy.tld/user/proj/internal/dep1"
+	"copy.tld/user/proj/internal/dep2"
+)
+
+const Version = dep1.Version + dep2.Suffix
@@ -0,0 +1,3 @@
+package dep1
+
+const Version = "v2"
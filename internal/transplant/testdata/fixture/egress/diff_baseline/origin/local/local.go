@@ -0,0 +1,8 @@
+package local
+
+import (
+	"origin.tld/user/proj/dep1"
+	"origin.tld/user/proj/dep2"
+)
+
+const Version = dep1.Version + dep2.Suffix
@@ -0,0 +1,3 @@
+module copy.tld/user/proj
+
+go 1.12
@@ -0,0 +1,15 @@
+package proj
+
+import (
+	"embed"
+
+	"copy.tld/user/proj/internal/dep1"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+func ExportedFunc1() string {
+	b, _ := templates.ReadFile("templates/page.tmpl")
+	return string(b) + dep1.ExportedFunc1()
+}
@@ -0,0 +1,14 @@
+package dep1
+
+import "embed"
+
+//go:embed static
+var static embed.FS
+
+func ExportedFunc1() string {
+	b, _ := static.ReadFile("static/a.txt")
+	return string(b)
+}
+
+func ExportedFunc2() {
+}
@@ -0,0 +1,10 @@
+package local
+
+import "embed"
+
+//go:embed missing/*
+var missing embed.FS
+
+func ExportedFunc1() {
+	_, _ = missing.ReadFile("missing/a.txt")
+}
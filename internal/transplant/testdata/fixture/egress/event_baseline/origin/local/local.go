@@ -0,0 +1,5 @@
+package local
+
+import "origin.tld/user/proj/dep1"
+
+const Version = dep1.Version
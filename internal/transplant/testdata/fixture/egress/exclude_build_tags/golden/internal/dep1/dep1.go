@@ -0,0 +1,4 @@
+package dep1
+
+func ExportedFunc1() {
+}
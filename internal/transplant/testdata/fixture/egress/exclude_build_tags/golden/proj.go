@@ -0,0 +1,7 @@
+package proj
+
+import "copy.tld/user/proj/internal/dep1"
+
+func ExportedFunc1() {
+	dep1.ExportedFunc1()
+}
@@ -0,0 +1,7 @@
+package dep1
+
+func ExportedFunc1() {
+}
+
+func ExportedFunc2() {
+}
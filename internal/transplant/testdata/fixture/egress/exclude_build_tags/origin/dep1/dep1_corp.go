@@ -0,0 +1,6 @@
+// +build linux,corp
+
+package dep1
+
+func ExportedFunc3() {
+}
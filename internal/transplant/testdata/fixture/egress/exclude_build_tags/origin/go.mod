@@ -0,0 +1,3 @@
+module origin.tld/user/proj
+
+go 1.12
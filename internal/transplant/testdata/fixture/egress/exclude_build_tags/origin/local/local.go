@@ -0,0 +1,7 @@
+package local
+
+import "origin.tld/user/proj/dep1"
+
+func ExportedFunc1() {
+	dep1.ExportedFunc1()
+}
@@ -0,0 +1,10 @@
+//go:build internal
+// +build internal
+
+package local
+
+import "origin.tld/user/proj/dep1"
+
+func ExportedFunc2() {
+	dep1.ExportedFunc2()
+}
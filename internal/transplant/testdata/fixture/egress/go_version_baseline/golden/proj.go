@@ -0,0 +1,14 @@
+package proj
+
+import (
+	"strings"
+)
+
+func First[T comparable](s []T) T {
+	return s[0]
+}
+
+func Key(s string) string {
+	key, _, _ := strings.Cut(s, "=")
+	return key
+}
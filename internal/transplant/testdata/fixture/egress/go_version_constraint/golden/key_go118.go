@@ -0,0 +1,12 @@
+//go:build go1.18
+
+package proj
+
+import (
+	"strings"
+)
+
+func key(s string) string {
+	k, _, _ := strings.Cut(s, "=")
+	return k
+}
@@ -0,0 +1,5 @@
+package proj
+
+func Key(s string) string {
+	return key(s)
+}
@@ -0,0 +1,5 @@
+package old
+
+func First[T comparable](s []T) T {
+	return s[0]
+}
@@ -0,0 +1,5 @@
+package local
+
+func Key(s string) string {
+	return key(s)
+}
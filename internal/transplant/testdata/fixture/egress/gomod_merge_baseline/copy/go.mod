@@ -0,0 +1,17 @@
+// Maintained in the public repository.
+module copy.tld/user/proj
+
+go 1.12
+
+toolchain go1.21.0
+
+require (
+	github.com/pkg/errors v0.8.0 // pinned for the v1 API
+	github.com/spf13/pflag v1.0.2
+)
+
+exclude github.com/pkg/errors v0.7.0
+
+replace github.com/spf13/pflag => ../pflag
+
+retract v0.1.0 // published by mistake
@@ -0,0 +1,12 @@
+// Maintained in the public repository.
+module copy.tld/user/proj
+
+go 1.12
+
+toolchain go1.21.0
+
+require github.com/pkg/errors v0.8.1 // pinned for the v1 API
+
+exclude github.com/pkg/errors v0.7.0
+
+retract v0.1.0 // published by mistake
@@ -0,0 +1,9 @@
+package proj
+
+import (
+	"github.com/pkg/errors"
+)
+
+func ExportedFunc1() error {
+	return errors.New("ExportedFunc1")
+}
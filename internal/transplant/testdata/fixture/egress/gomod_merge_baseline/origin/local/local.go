@@ -0,0 +1,9 @@
+package local
+
+import (
+	"github.com/pkg/errors"
+)
+
+func ExportedFunc1() error {
+	return errors.New("ExportedFunc1")
+}
@@ -0,0 +1,7 @@
+package dep1
+
+import "origin.tld/user/proj/dep2"
+
+func Used() string { return dep2.Name }
+
+func Unused() int { return 1 }
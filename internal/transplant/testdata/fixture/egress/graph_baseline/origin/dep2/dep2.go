@@ -0,0 +1,5 @@
+package dep2
+
+const Name = "dep2"
+
+const Other = "other"
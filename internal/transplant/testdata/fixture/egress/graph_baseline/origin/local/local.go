@@ -0,0 +1,5 @@
+package local
+
+import "origin.tld/user/proj/dep1"
+
+func Local() string { return dep1.Used() }
@@ -0,0 +1,5 @@
+module copy.tld/user/proj
+
+go 1.12
+
+require github.com/pkg/errors v0.8.1
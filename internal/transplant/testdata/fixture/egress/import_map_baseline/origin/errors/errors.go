@@ -0,0 +1,7 @@
+package errors
+
+import "fmt"
+
+func New(message string) error {
+	return fmt.Errorf("%s", message)
+}
@@ -0,0 +1,9 @@
+package local
+
+import (
+	"origin.tld/user/proj/errors"
+)
+
+func ExportedFunc1() error {
+	return errors.New("ExportedFunc1")
+}
@@ -0,0 +1,5 @@
+package stack
+
+func Caller() string {
+	return "caller"
+}
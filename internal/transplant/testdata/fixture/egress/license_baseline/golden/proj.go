@@ -0,0 +1,12 @@
+package proj
+
+import (
+	"github.com/pkg/errors"
+
+	"copy.tld/user/proj/internal/dep/third_party/stack"
+	"copy.tld/user/proj/internal/dep/util"
+)
+
+func ExportedFunc1() error {
+	return errors.New(stack.Caller() + util.Name)
+}
@@ -0,0 +1,6 @@
+// Copyright 2019 Util Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+const Name = "util"
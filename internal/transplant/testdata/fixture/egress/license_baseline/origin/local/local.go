@@ -0,0 +1,12 @@
+package local
+
+import (
+	"github.com/pkg/errors"
+
+	"origin.tld/user/proj/dep/third_party/stack"
+	"origin.tld/user/proj/dep/util"
+)
+
+func ExportedFunc1() error {
+	return errors.New(stack.Caller() + util.Name)
+}
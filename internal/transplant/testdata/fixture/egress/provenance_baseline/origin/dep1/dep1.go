@@ -0,0 +1,5 @@
+package dep1
+
+const Version = "v1"
+
+func Unused() int { return 1 }
@@ -0,0 +1,3 @@
+package dep10
+
+const Version = "v10"
@@ -0,0 +1,5 @@
+package fork
+
+func Name() string {
+	return "fork"
+}
@@ -0,0 +1,12 @@
+module copy.tld/user/proj
+
+go 1.12
+
+require (
+	example.com/fork v1.0.0
+	github.com/pkg/errors v0.8.0
+)
+
+replace example.com/fork => ./third_party/example.com/fork
+
+replace github.com/pkg/errors => github.com/pkg/errors v0.8.1
@@ -0,0 +1,10 @@
+package proj
+
+import (
+	"example.com/fork"
+	"github.com/pkg/errors"
+)
+
+func ExportedFunc1() error {
+	return errors.New(fork.Name())
+}
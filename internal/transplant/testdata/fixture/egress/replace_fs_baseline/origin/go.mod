@@ -0,0 +1,12 @@
+module origin.tld/user/proj
+
+go 1.12
+
+require (
+	example.com/fork v1.0.0
+	github.com/pkg/errors v0.8.0
+)
+
+replace example.com/fork => ../fork
+
+replace github.com/pkg/errors => github.com/pkg/errors v0.8.1
@@ -0,0 +1,5 @@
+package proj
+
+import "copy.tld/user/proj/internal/dep1"
+
+const Version = dep1.Version
@@ -0,0 +1,3 @@
+package dep2
+
+const Unused = 1
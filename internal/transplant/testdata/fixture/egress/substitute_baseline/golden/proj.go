@@ -0,0 +1,10 @@
+package proj
+
+import (
+	"copy.tld/user/proj/internal/dep/telemetry"
+)
+
+func ExportedFunc1() bool {
+	telemetry.Record("ExportedFunc1")
+	return telemetry.Enabled
+}
@@ -0,0 +1,6 @@
+package telemetry
+
+const Enabled = false
+
+func Record(name string) {
+}
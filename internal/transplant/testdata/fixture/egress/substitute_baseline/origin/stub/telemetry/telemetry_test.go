@@ -0,0 +1,9 @@
+package telemetry
+
+import (
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	Record("test")
+}
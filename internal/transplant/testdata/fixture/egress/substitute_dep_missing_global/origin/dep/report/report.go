@@ -0,0 +1,10 @@
+package report
+
+import (
+	"origin.tld/user/proj/dep/telemetry"
+)
+
+func Report(name string) {
+	telemetry.Record(name)
+	telemetry.Flush()
+}
@@ -0,0 +1,14 @@
+package telemetry
+
+import (
+	"origin.tld/user/proj/dep/secret"
+)
+
+const Enabled = true
+
+func Record(name string) {
+	secret.Send(name)
+}
+
+func Flush() {
+}
@@ -0,0 +1,9 @@
+package local
+
+import (
+	"origin.tld/user/proj/dep/report"
+)
+
+func ExportedFunc1() {
+	report.Report("ExportedFunc1")
+}
@@ -0,0 +1,4 @@
+package secret
+
+func Send(name string) {
+}
@@ -0,0 +1,10 @@
+package local
+
+import (
+	"origin.tld/user/proj/dep/telemetry"
+)
+
+func ExportedFunc1() bool {
+	telemetry.Record("ExportedFunc1")
+	return telemetry.Enabled
+}
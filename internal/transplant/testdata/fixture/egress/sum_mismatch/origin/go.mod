@@ -0,0 +1,5 @@
+module origin.tld/user/proj
+
+go 1.13
+
+require github.com/pkg/errors v0.8.1
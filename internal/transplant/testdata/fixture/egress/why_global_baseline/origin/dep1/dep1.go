@@ -0,0 +1,10 @@
+package dep1
+
+import "origin.tld/user/proj/dep2"
+
+func Used() string {
+	_ = dep2.T{}
+	return dep2.Name
+}
+
+func Unused() int { return 1 }
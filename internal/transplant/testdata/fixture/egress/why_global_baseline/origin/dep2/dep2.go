@@ -0,0 +1,9 @@
+package dep2
+
+const Name = "dep2"
+
+const Other = "other"
+
+type T struct{}
+
+func (T) M() {}
@@ -0,0 +1,5 @@
+package dep1
+
+func Add(a, b int) int {
+	return a + b
+}
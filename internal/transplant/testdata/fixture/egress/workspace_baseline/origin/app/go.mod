@@ -0,0 +1,3 @@
+module origin.tld/user/app
+
+go 1.12
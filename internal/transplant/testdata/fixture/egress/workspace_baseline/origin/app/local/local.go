@@ -0,0 +1,9 @@
+package local
+
+import (
+	"origin.tld/user/cage/dep1"
+)
+
+func ExportedFunc1() int {
+	return dep1.Add(1, 2)
+}
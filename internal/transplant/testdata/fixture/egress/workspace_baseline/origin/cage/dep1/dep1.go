@@ -0,0 +1,9 @@
+package dep1
+
+func Add(a, b int) int {
+	return a + b
+}
+
+func Unused() int {
+	return 0
+}
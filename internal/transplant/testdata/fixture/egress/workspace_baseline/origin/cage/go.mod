@@ -0,0 +1,3 @@
+module origin.tld/user/cage
+
+go 1.12
@@ -0,0 +1,10 @@
+module copy.tld/user/proj
+
+go 1.17
+
+require (
+	github.com/stretchr/testify v1.4.0
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v2 v2.2.8 // indirect
+)
@@ -0,0 +1,8 @@
+package local
+
+import "github.com/stretchr/testify/assert"
+
+func LocalFunc() {
+	var a assert.TestingT
+	_ = a
+}
@@ -0,0 +1,7 @@
+package unused1
+
+import "github.com/pkg/errors"
+
+func Unused1Func() error {
+	return errors.New("unused1")
+}
@@ -1,10 +0,0 @@
-module copy.tld/user/proj
-
-go 1.12
-
-require (
-	github.com/mitchellh/mapstructure v1.1.0
-	github.com/onsi/gomega v1.4.3
-	github.com/stretchr/testify v1.3.0
-	golang.org/x/sync v0.0.0-20180314180146-1d60e4601c6f
-)
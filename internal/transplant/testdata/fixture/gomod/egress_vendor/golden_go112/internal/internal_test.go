@@ -1,12 +0,0 @@
-package internal_test
-
-import (
-	"testing"
-
-	"github.com/stretchr/testify/suite"
-)
-
-func TestDep1(t *testing.T) {
-	var s suite.Suite
-	_ = s
-}
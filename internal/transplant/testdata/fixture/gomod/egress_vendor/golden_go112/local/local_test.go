@@ -1,12 +0,0 @@
-package local_test
-
-import (
-	"testing"
-
-	"github.com/onsi/gomega"
-)
-
-func TestDep1(t *testing.T) {
-	var a gomega.GomegaAsyncAssertion
-	_ = a
-}
@@ -15,8 +15,10 @@ import (
 
 	"github.com/pkg/errors"
 	std_viper "github.com/spf13/viper"
+	"golang.org/x/mod/module"
 
 	cage_viper "github.com/codeactual/transplant/internal/cage/config/viper"
+	cage_build "github.com/codeactual/transplant/internal/cage/go/build"
 	cage_mod "github.com/codeactual/transplant/internal/cage/go/mod"
 	cage_file "github.com/codeactual/transplant/internal/cage/os/file"
 	cage_filepath "github.com/codeactual/transplant/internal/cage/path/filepath"
@@ -92,6 +94,80 @@ type RenameSpec struct {
 	New string
 }
 
+// ImportMapSpec maps origin packages to a published module which provides them, so the copy requires
+// the module instead of including a copy of the packages.
+type ImportMapSpec struct {
+	// From is an origin import path prefix, e.g. "origin.tld/user/proj/cage".
+	//
+	// Packages under it are treated as third-party dependencies rather than Ops.Dep packages.
+	//
+	// It is a source path during egress and destination path during ingress.
+	From string
+
+	// To selects the published module in "<module path>@<version>" format, e.g. "github.com/user/cage@v1.2.0".
+	//
+	// Import paths under From are rewritten to be under the module path, and the copy's go.mod requires the version.
+	To string
+
+	// ToImportPath is the module path selected by To.
+	//
+	// It is a destination path during egress and source path during ingress.
+	ToImportPath string `mapstructure:"-"`
+
+	// ToVersion is the module version selected by To.
+	ToVersion string `mapstructure:"-"`
+}
+
+// SubstituteSpec selects an Ops.Dep package whose files are replaced in the copy by the files of another
+// origin directory, e.g. a no-op implementation of a package which must not leave the origin.
+type SubstituteSpec struct {
+	// ImportPath identifies the substituted Ops.Dep package, e.g. "origin.tld/user/proj/internal/telemetry".
+	ImportPath string
+
+	// FilePath is a directory, relative to Ops.From.ModuleFilePath, whose files are copied to the
+	// substituted package's destination instead of the package's own files.
+	//
+	// Its Go package must have the same name as the substituted package, and declare every global which
+	// Ops.From and copied Ops.Dep packages use from the latter. Its test files are only copied if the
+	// substituted package's Ops.Dep.From.Tests is true.
+	FilePath string
+}
+
+// LicenseSpec selects how the licenses of third-party code in the copy, i.e. required modules and
+// Ops.Dep trees, are collected and checked.
+//
+// Licenses are only collected if NoticeFilePath or Deny is selected.
+type LicenseSpec struct {
+	// NoticeFilePath is a path relative to Ops.To.ModuleFilePath, e.g. "THIRD_PARTY_NOTICES", of a generated file
+	// which contains the text of each collected license and notice file.
+	NoticeFilePath string
+
+	// Deny holds SPDX license identifiers, e.g. "AGPL-3.0", or path.Match patterns, e.g. "GPL-*", of licenses
+	// which must not be included in the copy. Use "unknown" to also deny licenses which are not recognized.
+	//
+	// A license which is an SPDX "OR" expression is denied only if each of its alternatives is denied.
+	Deny []string
+}
+
+// GitSpec selects how the export is committed to the git repository of Ops.To.ModuleFilePath.
+//
+// CommitMessage and Tag are text/template strings which can use these fields:
+//
+//   - OpId: Ops.Id
+//   - OriginHead: full SHA of the HEAD commit of the Ops.From.ModuleFilePath repository
+//   - OriginShortHead: first 12 characters of OriginHead
+type GitSpec struct {
+	// Commit enables the commit. The copy is canceled if the destination repository has uncommitted changes.
+	Commit bool
+
+	// CommitMessage is the template of the commit message. If empty, DefaultGitCommitMessage is used.
+	CommitMessage string
+
+	// Tag is the template of a lightweight tag name, e.g. "export-{{.OriginShortHead}}", created if the
+	// commit is created.
+	Tag string
+}
+
 // ReplaceStringSpec defines the scope of string replacements to perform during copy operations.
 type ReplaceStringSpec struct {
 	// ImportPath matches files which should have Ops.From/Ops.To import paths converted
@@ -108,8 +184,8 @@ type RootFrom struct {
 	//
 	// It is the base directory for all *FilePath patterns.
 	//
-	// If it contains a go.sum, and `go mod tidy` execution in the destination during egress also
-	// creates one, the destination's hashes will be verified against the origin's.
+	// If it contains a go.sum, and the destination requires any module it provides hashes for during egress,
+	// the destination's hashes will be verified against the origin's.
 	//
 	// If it contains a vendor/modules.txt file, `go mod vendor` will be used to create one in the destination.
 	ModuleFilePath string
@@ -165,9 +241,20 @@ type RootFrom struct {
 	// ModuleSum is true if <ModuleFilePath>/go.sum was found during config validation/finalization.
 	ModuleSum bool `mapstructure:"-"`
 
+	// WorkFilePath is the absolute path to the go.work which lists ModuleFilePath as a workspace module.
+	//
+	// It is found in ModuleFilePath or its ancestors, or selected by GOWORK, during config validation/finalization.
+	// It is empty if no go.work applies or the go.work does not list ModuleFilePath.
+	WorkFilePath string `mapstructure:"-"`
+
 	// Tests is true if GoFilePath-matched test packages and their dependencies should be included.
 	Tests bool
 
+	// Rev is the full SHA of the origin commit which ModuleFilePath was checked out from, e.g. by `--origin-rev`.
+	//
+	// It is empty if ModuleFilePath is the origin's working tree.
+	Rev string `mapstructure:"-"`
+
 	// Vendor is true if GOFLAGS contains "-mod=vendor" and <ModuleFilePath>/{go.sum,vendor/modules.txt} exists
 	// (as indication that the origin is using the same vendoring tool as we are).
 	//
@@ -190,6 +277,25 @@ type RootTo struct {
 	//
 	// It is computed based on ModuleImportPath and LocalFilePath.
 	LocalImportPath string `mapstructure:"-"`
+
+	// ReplaceFilePath is a path relative to ModuleFilePath into which the targets of filesystem `replace`
+	// directives in the origin go.mod, e.g. "../forks/x", are copied if they are not copied by an Ops.Dep.
+	//
+	// Each target is copied into a subdirectory named after the replaced module path, e.g. "third_party/example.com/x",
+	// and the copy's `replace` directive is rewritten to point to it.
+	ReplaceFilePath string
+
+	// ReportFilePath is a path relative to ModuleFilePath, e.g. ".transplant/report.json", of a generated file
+	// which holds the export's size and pruning report.
+	//
+	// If selected, the report is always generated and the totals of the file's previous version, if any,
+	// are included in CopyPlan.Report for comparison.
+	ReportFilePath string
+
+	// GoVersion is the `go` directive value, e.g. "1.13", of the copy's go.mod files.
+	//
+	// If empty, the origin go.mod's `go` directive is used.
+	GoVersion string
 }
 
 // DepFrom describes the origin of a specific dependency included in the copy operation.
@@ -197,13 +303,24 @@ type DepFrom struct {
 	// FilePath is a path relative to Ops.From.ModuleFilePath.
 	//
 	// It is used as the base path for all *FilePath patterns.
+	//
+	// If Ops.From.WorkFilePath is non-empty, it may point into another module of the workspace,
+	// e.g. "../cage/internal", in which case it may contain "..".
 	FilePath string
 
 	// ImportPath is the prefix of all packages under FilePath.
 	//
-	// It is computed based on Ops.From.ModuleImportPath and this type's FilePath.
+	// It is computed based on ModuleImportPath and the path of FilePath relative to ModuleFilePath.
 	ImportPath string `mapstructure:"-"`
 
+	// ModuleFilePath is the absolute path to the root of the module which contains FilePath.
+	//
+	// It is Ops.From.ModuleFilePath unless FilePath points into another module of the Ops.From.WorkFilePath workspace.
+	ModuleFilePath string `mapstructure:"-"`
+
+	// ModuleImportPath is the import path of the module rooted in ModuleFilePath.
+	ModuleImportPath string `mapstructure:"-"`
+
 	// GoFilePath matches directories in which implementation (and optionally test) packages should
 	// be copied and analyzed for their dependencies.
 	//
@@ -249,6 +366,13 @@ type DepTo struct {
 	//
 	// It is computed based on Ops.To.ModuleImportPath and this type's FilePath.
 	ImportPath string `mapstructure:"-"`
+
+	// Module is true if FilePath should be the root of a separate module, with its own go.mod/go.sum,
+	// whose path is ImportPath.
+	//
+	// The Ops.To module, and any other Ops.Dep module, which imports it will resolve it with
+	// a `replace <ImportPath> => ./<relative path>` directive.
+	Module bool
 }
 
 // Dep describes a specific dependency included in the copy operation.
@@ -275,6 +399,28 @@ type Op struct {
 	// e.g. first-party packages/modules centrally shared in the repo.
 	Dep []Dep
 
+	// ImportMap selects origin packages, e.g. first-party libraries which are also published as their own
+	// modules, which the copy should import from a published module instead of an Ops.Dep copy.
+	ImportMap []ImportMapSpec
+
+	// Substitute selects Ops.Dep packages whose files are replaced in the copy by alternative implementations.
+	//
+	// The substitute files are not copied back during ingress.
+	Substitute []SubstituteSpec
+
+	// License selects how the licenses of third-party code in the copy are collected and checked.
+	License LicenseSpec
+
+	// Git selects how the export is committed to the destination repository.
+	Git GitSpec
+
+	// ExcludeBuildTags holds build tags, e.g. "internal", which mark Ops.From and Ops.Dep.From Go files
+	// that must not be copied.
+	//
+	// A file is excluded if its build constraint cannot be satisfied unless one of the tags is set.
+	// Globals used only by excluded files are pruned because the files are also omitted from inspection.
+	ExcludeBuildTags []string
+
 	// DryRun is true if the operation should perform all steps except creating/modifying Ops.To.FilePath.
 	DryRun bool `mapstructure:"-"`
 
@@ -313,11 +459,17 @@ func (op *Op) finalizeIngress() {
 
 		op.Dep[d].From.FilePath = op.Dep[d].To.FilePath
 		op.Dep[d].From.ImportPath = op.Dep[d].To.ImportPath
+		op.Dep[d].From.ModuleFilePath = op.From.ModuleFilePath
+		op.Dep[d].From.ModuleImportPath = op.From.ModuleImportPath
 
 		op.Dep[d].To.FilePath = from.FilePath
 		op.Dep[d].To.ImportPath = from.ImportPath
 	}
 
+	for m := range op.ImportMap {
+		op.ImportMap[m].From, op.ImportMap[m].ToImportPath = op.ImportMap[m].ToImportPath, op.ImportMap[m].From
+	}
+
 	op.Ingress = true
 }
 
@@ -332,6 +484,34 @@ type Config struct {
 	// https://github.com/spf13/viper/issues/411
 	// https://github.com/spf13/viper/pull/635
 	Template map[string]string
+
+	// OriginRoot, if selected, relocates the origin's files during ReadFile, e.g. to read them from a temporary
+	// git worktree of a past revision.
+	OriginRoot PathRebase `mapstructure:"-"`
+}
+
+// PathRebase selects a directory whose descendant paths should be rewritten to descend from another.
+type PathRebase struct {
+	// Old is the absolute path of the directory whose descendants are rewritten.
+	Old string
+
+	// New is the absolute path of the directory which replaces Old.
+	New string
+}
+
+// Apply returns the path with the Old prefix replaced by New, or the path itself if it does not
+// descend from Old or no rebase is selected.
+func (r PathRebase) Apply(p string) string {
+	if r.Old == "" || r.New == "" {
+		return p
+	}
+	if p == r.Old {
+		return r.New
+	}
+	if strings.HasPrefix(p, r.Old+string(filepath.Separator)) {
+		return filepath.Join(r.New, strings.TrimPrefix(p, r.Old+string(filepath.Separator)))
+	}
+	return p
 }
 
 // ReadFile populates Config fields with values from the named file.
@@ -459,6 +639,10 @@ func (c *Config) ReadFile(name string, _opIds ...string) (errs []error) {
 			&op.To.LocalImportPath,
 		}
 
+		for s := range op.ExcludeBuildTags {
+			opValueStrings = append(opValueStrings, &op.ExcludeBuildTags[s])
+		}
+
 		for s := range op.From.GoFilePath.Include {
 			opValueStrings = append(opValueStrings, &op.From.GoFilePath.Include[s])
 		}
@@ -491,6 +675,16 @@ func (c *Config) ReadFile(name string, _opIds ...string) (errs []error) {
 			opValueStrings = append(opValueStrings, &op.From.ReplaceString.ImportPath.Exclude[s])
 		}
 
+		for n := range op.ImportMap {
+			opValueStrings = append(opValueStrings, &op.ImportMap[n].From, &op.ImportMap[n].To)
+		}
+
+		for n := range op.Substitute {
+			opValueStrings = append(opValueStrings, &op.Substitute[n].ImportPath, &op.Substitute[n].FilePath)
+		}
+
+		opValueStrings = append(opValueStrings, &op.To.ReportFilePath, &op.License.NoticeFilePath)
+
 		for n := range op.Dep {
 			opValueStrings = append(
 				opValueStrings,
@@ -602,6 +796,7 @@ func (c *Config) ReadFile(name string, _opIds ...string) (errs []error) {
 		if op.From.ModuleFilePath != "" && !filepath.IsAbs(op.From.ModuleFilePath) {
 			errs = append(errs, errors.Errorf("Op[%s].From.ModuleFilePath [%s] cannot be relative", opId, op.From.ModuleFilePath))
 		}
+		op.From.ModuleFilePath = c.OriginRoot.Apply(op.From.ModuleFilePath)
 		originMod, err := cage_mod.NewModFromFile(FromAbs(op, "go.mod"))
 		if err == nil {
 			op.From.ModuleImportPath = originMod.Path
@@ -609,6 +804,25 @@ func (c *Config) ReadFile(name string, _opIds ...string) (errs []error) {
 			errs = append(errs, errors.Wrapf(err, "failed to parse <Ops[%s].From.ModuleFilePath>/go.mod", opId))
 		}
 
+		var work *cage_mod.Work
+		if op.From.ModuleFilePath != "" {
+			workFile, workErr := cage_mod.FindWorkFile(op.From.ModuleFilePath)
+			if workErr == nil && workFile != "" {
+				work, workErr = cage_mod.NewWorkFromFile(workFile)
+			}
+			if workErr == nil {
+				// Only select workspace modules if the go command would also use the go.work
+				// when operating in the origin module.
+				if work != nil && work.ModuleDir(op.From.ModuleFilePath) == op.From.ModuleFilePath {
+					op.From.WorkFilePath = workFile
+				} else {
+					work = nil
+				}
+			} else {
+				errs = append(errs, errors.Wrapf(workErr, "failed to read go.work of Ops[%s].From.ModuleFilePath", opId))
+			}
+		}
+
 		op.From.LocalFilePath = FilepathClean(op.From.LocalFilePath)
 		if filepath.IsAbs(op.From.LocalFilePath) {
 			errs = append(errs, errors.Errorf("Op[%s].From.LocalFilePath [%s] must be relative (to ModuleFilePath) ", opId, op.From.LocalFilePath))
@@ -628,6 +842,14 @@ func (c *Config) ReadFile(name string, _opIds ...string) (errs []error) {
 			// assume leaf package name conventionally matches the leaf dir name
 			op.To.LocalImportPath = path.Join(op.To.ModuleImportPath, op.To.LocalFilePath)
 		}
+		op.To.ReplaceFilePath = FilepathClean(op.To.ReplaceFilePath)
+		if filepath.IsAbs(op.To.ReplaceFilePath) {
+			errs = append(errs, errors.Errorf("Op[%s].To.ReplaceFilePath [%s] must be relative (to ModuleFilePath) ", opId, op.To.ReplaceFilePath))
+		}
+		op.To.ReportFilePath = FilepathClean(op.To.ReportFilePath)
+		if filepath.IsAbs(op.To.ReportFilePath) {
+			errs = append(errs, errors.Errorf("Op[%s].To.ReportFilePath [%s] must be relative (to ModuleFilePath) ", opId, op.To.ReportFilePath))
+		}
 
 		for n := 0; n < len(op.Dep); n++ { // only use 'n' because we need to update ops.Dep[n] by pointer
 			op.Dep[n].From.FilePath = FilepathClean(op.Dep[n].From.FilePath)
@@ -640,11 +862,96 @@ func (c *Config) ReadFile(name string, _opIds ...string) (errs []error) {
 				errs = append(errs, errors.Errorf("Ops[%s].Dep[%s].To.FilePath must be relative (to Ops[%s].To.ModuleFilePath)", opId, op.Dep[n].From.FilePath, opId))
 			}
 
+			op.Dep[n].From.ModuleFilePath = op.From.ModuleFilePath
+			op.Dep[n].From.ModuleImportPath = op.From.ModuleImportPath
+			depFromAbs := FromAbs(op, op.Dep[n].From.FilePath)
+			if work != nil {
+				if modDir := work.ModuleDir(depFromAbs); modDir != "" && modDir != op.From.ModuleFilePath {
+					depMod, depModErr := cage_mod.NewModFromFile(filepath.Join(modDir, "go.mod"))
+					if depModErr == nil {
+						op.Dep[n].From.ModuleFilePath = modDir
+						op.Dep[n].From.ModuleImportPath = depMod.Path
+					} else {
+						errs = append(errs, errors.Wrapf(depModErr, "failed to parse go.mod of Ops[%s].Dep[%s].From.FilePath workspace module", opId, op.Dep[n].From.FilePath))
+					}
+				}
+			}
+
 			// assume leaf package name conventionally matches the leaf dir name
-			op.Dep[n].From.ImportPath = path.Join(op.From.ModuleImportPath, op.Dep[n].From.FilePath)
+			depFromRel, relErr := filepath.Rel(op.Dep[n].From.ModuleFilePath, depFromAbs)
+			if relErr == nil {
+				op.Dep[n].From.ImportPath = path.Join(op.Dep[n].From.ModuleImportPath, filepath.ToSlash(depFromRel))
+			} else {
+				errs = append(errs, errors.Wrapf(relErr, "failed to get Ops[%s].Dep[%s].From.FilePath relative to its module", opId, op.Dep[n].From.FilePath))
+			}
 			op.Dep[n].To.ImportPath = path.Join(op.To.ModuleImportPath, op.Dep[n].To.FilePath)
 		}
 
+		for n, m := range op.ImportMap {
+			if m.From == "" {
+				errs = append(errs, errors.Errorf("Ops[%s].ImportMap[%d].From is empty", opId, n))
+			} else if m.From == op.From.LocalImportPath || strings.HasPrefix(op.From.LocalImportPath, m.From+"/") {
+				errs = append(errs, errors.Errorf("Ops[%s].ImportMap[%s].From cannot contain Ops.From.LocalImportPath [%s]", opId, m.From, op.From.LocalImportPath))
+			}
+
+			toParts := strings.Split(m.To, "@")
+			if len(toParts) != 2 {
+				errs = append(errs, errors.Errorf("Ops[%s].ImportMap[%s].To [%s] must be in <module path>@<version> format, e.g. example.com/user/proj@v1.2.0", opId, m.From, m.To))
+				continue
+			}
+			if checkErr := module.Check(toParts[0], toParts[1]); checkErr != nil {
+				errs = append(errs, errors.Wrapf(checkErr, "Ops[%s].ImportMap[%s].To [%s] is not a valid module version", opId, m.From, m.To))
+				continue
+			}
+			op.ImportMap[n].ToImportPath = toParts[0]
+			op.ImportMap[n].ToVersion = toParts[1]
+		}
+
+		substituteImportPaths := cage_strings.NewSet()
+		for n := range op.Substitute {
+			op.Substitute[n].FilePath = FilepathClean(op.Substitute[n].FilePath)
+			sub := op.Substitute[n]
+
+			if sub.ImportPath == "" {
+				errs = append(errs, errors.Errorf("Ops[%s].Substitute[%d].ImportPath is empty", opId, n))
+			} else if !substituteImportPaths.Add(sub.ImportPath) {
+				errs = append(errs, errors.Errorf("Ops[%s].Substitute[%s].ImportPath is selected more than once", opId, sub.ImportPath))
+			}
+			if sub.FilePath == "" {
+				errs = append(errs, errors.Errorf("Ops[%s].Substitute[%s].FilePath is empty", opId, sub.ImportPath))
+			} else if filepath.IsAbs(sub.FilePath) {
+				errs = append(errs, errors.Errorf("Ops[%s].Substitute[%s].FilePath must be relative (to Ops[%s].From.ModuleFilePath)", opId, sub.ImportPath, opId))
+			} else if strings.Contains(sub.FilePath, "..") {
+				errs = append(errs, errors.Errorf("Ops[%s].Substitute[%s].FilePath [%s] cannot contain '..'", opId, sub.ImportPath, sub.FilePath))
+			}
+		}
+
+		op.License.NoticeFilePath = FilepathClean(op.License.NoticeFilePath)
+		if filepath.IsAbs(op.License.NoticeFilePath) {
+			errs = append(errs, errors.Errorf("Ops[%s].License.NoticeFilePath [%s] must be relative (to Ops[%s].To.ModuleFilePath)", opId, op.License.NoticeFilePath, opId))
+		} else if strings.Contains(op.License.NoticeFilePath, "..") {
+			errs = append(errs, errors.Errorf("Ops[%s].License.NoticeFilePath [%s] cannot contain '..'", opId, op.License.NoticeFilePath))
+		}
+		for n, pattern := range op.License.Deny {
+			if pattern == "" {
+				errs = append(errs, errors.Errorf("Ops[%s].License.Deny[%d] is empty", opId, n))
+			} else if _, matchErr := path.Match(pattern, ""); matchErr != nil {
+				errs = append(errs, errors.Wrapf(matchErr, "Ops[%s].License.Deny[%d] [%s] is not a valid pattern", opId, n, pattern))
+			}
+		}
+
+		if _, tmplErr := cage_template.ExecuteBuffered(op.Git.CommitMessage, gitTemplateData{}); tmplErr != nil {
+			errs = append(errs, errors.Wrapf(tmplErr, "Ops[%s].Git.CommitMessage is not a valid template", opId))
+		}
+		if op.Git.Tag != "" {
+			if !op.Git.Commit {
+				errs = append(errs, errors.Errorf("Ops[%s].Git.Tag requires Ops[%s].Git.Commit", opId, opId))
+			}
+			if _, tmplErr := cage_template.ExecuteBuffered(op.Git.Tag, gitTemplateData{}); tmplErr != nil {
+				errs = append(errs, errors.Wrapf(tmplErr, "Ops[%s].Git.Tag is not a valid template", opId))
+			}
+		}
+
 		for _, r := range op.From.RenameFilePath {
 			if r.Old == "" {
 				errs = append(errs, errors.Errorf("Ops[%s].From.RenameFilePath.Old is empty", opId))
@@ -706,6 +1013,16 @@ func (c *Config) ReadFile(name string, _opIds ...string) (errs []error) {
 			}
 		}
 
+		for n, tag := range op.ExcludeBuildTags {
+			if tag == "" {
+				errs = append(errs, errors.Errorf("Ops[%s].ExcludeBuildTags[%d] is empty", opId, n))
+			}
+		}
+
+		if op.To.GoVersion != "" && !cage_build.IsGoVersion(op.To.GoVersion) {
+			errs = append(errs, errors.Errorf("Ops[%s].To.GoVersion [%s] is not a Go version, e.g. \"1.13\"", opId, op.To.GoVersion))
+		}
+
 		// disallowed value checks
 
 		if strings.Contains(op.From.LocalFilePath, "..") {
@@ -714,13 +1031,23 @@ func (c *Config) ReadFile(name string, _opIds ...string) (errs []error) {
 		if strings.Contains(op.To.LocalFilePath, "..") {
 			errs = append(errs, errors.Errorf("Ops[%s].To.LocalFilePath [%s] cannot contain '..'", opId, op.To.LocalFilePath))
 		}
+		if strings.Contains(op.To.ReplaceFilePath, "..") {
+			errs = append(errs, errors.Errorf("Ops[%s].To.ReplaceFilePath [%s] cannot contain '..'", opId, op.To.ReplaceFilePath))
+		}
+		if strings.Contains(op.To.ReportFilePath, "..") {
+			errs = append(errs, errors.Errorf("Ops[%s].To.ReportFilePath [%s] cannot contain '..'", opId, op.To.ReportFilePath))
+		}
 		for _, dep := range op.Dep {
-			if strings.Contains(dep.From.FilePath, "..") {
+			// Allow paths into other modules of the workspace, e.g. "../cage/internal".
+			if strings.Contains(dep.From.FilePath, "..") && dep.From.ModuleFilePath == op.From.ModuleFilePath {
 				errs = append(errs, errors.Errorf("Ops[%s].Dep[%s].From.FilePath cannot contain '..'", opId, dep.From.FilePath))
 			}
 			if strings.Contains(dep.To.FilePath, "..") {
 				errs = append(errs, errors.Errorf("Ops[%s].Dep[%s].To.FilePath cannot contain '..'", opId, dep.To.FilePath))
 			}
+			if dep.To.Module && (dep.To.FilePath == "" || dep.To.FilePath == ".") {
+				errs = append(errs, errors.Errorf("Ops[%s].Dep[%s].To.FilePath cannot be empty if To.Module is true", opId, dep.From.FilePath))
+			}
 		}
 
 		// Ops.To / Ops.Dep.To duplicate/overlap checks
@@ -783,21 +1110,74 @@ func FilepathClean(p string) string {
 	return filepath.Clean(p)
 }
 
-// AllImportPathReplacer returns a replacer covering Ops.From.LocalImportPath and all Ops.Dep.From.ImportPath values.
+// AllImportPathReplacer returns a replacer covering Ops.From.LocalImportPath and all Ops.Dep.From.ImportPath
+// and Ops.ImportMap.From values.
 func AllImportPathReplacer(op Op) *cage_strings.ReplaceSet {
 	r := &cage_strings.ReplaceSet{}
 	r.Add(op.From.LocalImportPath, op.To.LocalImportPath, -1)
 	for _, dep := range op.Dep {
 		r.Add(dep.From.ImportPath, dep.To.ImportPath, -1)
 	}
+	for _, m := range op.ImportMap {
+		r.Add(m.From, m.ToImportPath, -1)
+	}
 	return r
 }
 
-// DepImportPathReplacer returns a replacer covering all Ops.Dep.From.ImportPath values.
+// DepImportPathReplacer returns a replacer covering all Ops.Dep.From.ImportPath and Ops.ImportMap.From values.
 func DepImportPathReplacer(op Op) *cage_strings.ReplaceSet {
 	r := &cage_strings.ReplaceSet{}
 	for _, dep := range op.Dep {
 		r.Add(dep.From.ImportPath, dep.To.ImportPath, -1)
 	}
+	for _, m := range op.ImportMap {
+		r.Add(m.From, m.ToImportPath, -1)
+	}
 	return r
 }
+
+// MappedImport returns the Ops.ImportMap element with the longest From prefix of the import path.
+func (op Op) MappedImport(importPath string) (spec ImportMapSpec, found bool) {
+	for _, m := range op.ImportMap {
+		if m.From == "" || (importPath != m.From && !strings.HasPrefix(importPath, m.From+"/")) {
+			continue
+		}
+		if len(m.From) > len(spec.From) {
+			spec, found = m, true
+		}
+	}
+	return spec, found
+}
+
+// SubstituteOf returns the Ops.Substitute element which selects the import path.
+func (op Op) SubstituteOf(importPath string) (spec SubstituteSpec, found bool) {
+	for _, sub := range op.Substitute {
+		if sub.ImportPath == importPath {
+			return sub, true
+		}
+	}
+	return SubstituteSpec{}, false
+}
+
+// SubstituteDirs returns the absolute path to the substituted package's origin directory, and the path
+// of its destination directory relative to Ops.To.ModuleFilePath.
+//
+// If the package is not found under any Ops.Dep.From.ImportPath, found is false.
+func SubstituteDirs(op Op, spec SubstituteSpec) (fromDir, toRelDir string, found bool) {
+	dep, found := SubstituteDep(op, spec)
+	if !found {
+		return "", "", false
+	}
+	rel := filepath.FromSlash(strings.TrimPrefix(strings.TrimPrefix(spec.ImportPath, dep.From.ImportPath), "/"))
+	return FromAbs(op, dep.From.FilePath, rel), filepath.Join(dep.To.FilePath, rel), true
+}
+
+// SubstituteDep returns the Ops.Dep whose From.ImportPath contains the substituted package.
+func SubstituteDep(op Op, spec SubstituteSpec) (dep Dep, found bool) {
+	for _, dep := range op.Dep {
+		if spec.ImportPath == dep.From.ImportPath || strings.HasPrefix(spec.ImportPath, dep.From.ImportPath+"/") {
+			return dep, true
+		}
+	}
+	return Dep{}, false
+}
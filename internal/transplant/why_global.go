@@ -0,0 +1,236 @@
+// Copyright (C) 2019 The transplant Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package transplant
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+
+	"github.com/pkg/errors"
+
+	cage_pkgs "github.com/codeactual/transplant/internal/cage/go/packages"
+	cage_strings "github.com/codeactual/transplant/internal/cage/strings"
+)
+
+// GlobalUsage is an edge of a WhyGlobalResult.Chain.
+type GlobalUsage struct {
+	// From is the "<import path>.<name>" of the global which uses To.
+	From string
+
+	// To is the "<import path>.<name>" of the used global.
+	To string
+
+	// Position is where From uses To. If From does not refer to To, e.g. To is a method of the From type,
+	// it is the location of To's declaration.
+	Position token.Position
+
+	// Reason describes why To is included if From does not refer to it, e.g. "method of used type".
+	Reason string `json:",omitempty"`
+}
+
+func (u GlobalUsage) String() string {
+	s := fmt.Sprintf("%s -> %s [%s:%d]", u.From, u.To, u.Position.Filename, u.Position.Line)
+	if u.Reason != "" {
+		s += " (" + u.Reason + ")"
+	}
+	return s
+}
+
+// WhyGlobalResult explains why an Ops.Dep global is kept in, or pruned from, the copy.
+type WhyGlobalResult struct {
+	// Target is the "<import path>.<name>" of the queried global.
+	Target string
+
+	// Pruned is true if no path exists from the Ops.From files to Target.
+	Pruned bool
+
+	// Chain is the shortest sequence of usages from an Ops.From global to Target, through Ops.Dep globals.
+	// It is empty if Target is pruned.
+	Chain []GlobalUsage
+}
+
+// Print writes a readable form of the result.
+func (r WhyGlobalResult) Print(w io.Writer) {
+	if r.Pruned {
+		fmt.Fprintf(w, "%s will be pruned: no path exists from Ops.From files to it\n", r.Target)
+		return
+	}
+	fmt.Fprintf(w, "%s is kept because of this chain of usages:\n", r.Target)
+	for _, u := range r.Chain {
+		fmt.Fprintln(w, "\t * "+u.String())
+	}
+}
+
+// WhyGlobal returns the shortest chain of usages from an Ops.From global to the Ops.Dep global identified by
+// "<import path>.<name>", e.g. "origin.tld/user/proj/dep1.Type.Method", or indicates that it will be pruned.
+//
+// It must be called after Generate.
+func (a *Audit) WhyGlobal(target string) (res WhyGlobalResult, err error) {
+	res.Target = target
+
+	label := func(id cage_pkgs.GlobalId) string {
+		return id.PkgPath + "." + id.Name
+	}
+
+	vertices := make(map[string]cage_pkgs.GlobalId)
+	var targetKey string
+	for _, v := range a.DepGlobalIdUsageDag.Vertices() {
+		id := v.(cage_pkgs.GlobalId)
+		if a.graphNodeKind(id) != GraphNodeGlobal {
+			continue
+		}
+		vertices[id.String()] = id
+		if label(id) == target {
+			targetKey = id.String()
+		}
+	}
+
+	if targetKey == "" {
+		for _, id := range a.prunedDepGlobalIds() {
+			if label(id) == target {
+				res.Pruned = true
+				return res, nil
+			}
+		}
+		return WhyGlobalResult{}, errors.Errorf("global [%s] was not found in Ops.Dep packages", target)
+	}
+
+	// parents indexes each visited Ops.Dep global's predecessor in the shortest chain.
+	// Keys and values are both cage_pkgs.GlobalId.String() values, except that the values of the first
+	// Ops.Dep globals in each chain are keys of localUsers.
+	parents := make(map[string]string)
+	localUsers := make(map[string]cage_pkgs.GlobalId)
+
+	var queue []string
+
+	// Seed the search with the Ops.Dep globals used directly by Ops.From globals.
+	for _, dir := range a.inspector.GlobalIdNodes.SortedDirs() {
+		if !a.LocalInspectDirs.Contains(dir) {
+			continue
+		}
+
+		dirNodes := a.inspector.GlobalIdNodes[dir]
+
+		for _, pkgName := range dirNodes.SortedPkgNames() {
+			pkgNodes := dirNodes[pkgName]
+
+			for _, idName := range pkgNodes.SortedIds() {
+				node := pkgNodes[idName]
+				localId := cage_pkgs.NewGlobalId(node.InspectInfo.PkgPath, pkgName, node.InspectInfo.Filename, idName)
+
+				usedMap, idsErrs := a.inspector.GlobalIdsUsedByGlobal(dir, pkgName, idName)
+				if len(idsErrs) > 0 {
+					return WhyGlobalResult{}, errors.Wrapf(idsErrs[0], "failed to load inspection results about global [%s]", localId)
+				}
+
+				usedKeys := cage_strings.NewSet()
+				for _, used := range usedMap {
+					usedKeys.Add(used.GlobalId().String())
+				}
+
+				for _, usedKey := range usedKeys.SortedSlice() {
+					if _, ok := vertices[usedKey]; !ok {
+						continue
+					}
+					if _, ok := parents[usedKey]; ok {
+						continue
+					}
+					parents[usedKey] = localId.String()
+					localUsers[localId.String()] = localId
+					queue = append(queue, usedKey)
+				}
+			}
+		}
+	}
+
+	// Walk the edges between Ops.Dep globals breadth-first.
+	for len(queue) > 0 && parents[targetKey] == "" {
+		var key string
+		key, queue = queue[0], queue[1:]
+
+		toKeys := cage_strings.NewSet()
+		for _, to := range a.DepGlobalIdUsageDag.VerticesFrom(vertices[key]) {
+			toKeys.Add(to.(cage_pkgs.GlobalId).String())
+		}
+
+		for _, toKey := range toKeys.SortedSlice() {
+			if _, ok := vertices[toKey]; !ok {
+				continue
+			}
+			if _, ok := parents[toKey]; ok {
+				continue
+			}
+			parents[toKey] = key
+			queue = append(queue, toKey)
+		}
+	}
+
+	if parents[targetKey] == "" {
+		// For example, the target is an init function or a test package global, which the DAG connects
+		// to the root through its package directory rather than through a global which uses it.
+		id := vertices[targetKey]
+		pos, posErr := a.globalDeclPosition(id)
+		if posErr != nil {
+			return WhyGlobalResult{}, errors.WithStack(posErr)
+		}
+		res.Chain = []GlobalUsage{{From: "Ops.From", To: target, Position: pos, Reason: "declared in a used package directory"}}
+		return res, nil
+	}
+
+	for toKey := targetKey; ; {
+		fromKey := parents[toKey]
+		to := vertices[toKey]
+
+		from, isDep := vertices[fromKey]
+		if !isDep {
+			from = localUsers[fromKey]
+		}
+
+		usage := GlobalUsage{From: label(from), To: label(to)}
+
+		usage.Position, err = a.inspector.GlobalIdUsagePosition(from.Dir(), from.PkgName, from.Name, to)
+		if err != nil {
+			return WhyGlobalResult{}, errors.Wrapf(err, "failed to find where [%s] uses [%s]", from, to)
+		}
+		if !usage.Position.IsValid() {
+			if usage.Position, err = a.globalDeclPosition(to); err != nil {
+				return WhyGlobalResult{}, errors.WithStack(err)
+			}
+			if to.MethodType() == from.Name {
+				usage.Reason = "method of used type"
+			} else {
+				usage.Reason = "dependency of used global"
+			}
+		}
+
+		res.Chain = append([]GlobalUsage{usage}, res.Chain...)
+
+		if !isDep {
+			break
+		}
+		toKey = fromKey
+	}
+
+	return res, nil
+}
+
+// globalDeclPosition returns the location of the global's declaration.
+func (a *Audit) globalDeclPosition(id cage_pkgs.GlobalId) (token.Position, error) {
+	node, err := a.inspector.GlobalIdNode(id.Dir(), id.PkgName, id.Name)
+	if err != nil {
+		return token.Position{}, errors.Wrapf(err, "failed to load inspection results about global [%s]", id)
+	}
+
+	// Prefer the position of the global's own spec, e.g. in a `const (...)` block.
+	pos := node.Ast.Pos()
+	if queue, findErr := a.inspector.FindGlobalInAstNode(node.Ast, id.Name); findErr == nil && len(queue) > 0 {
+		pos = queue[0].Ast.Pos()
+	}
+
+	return a.inspector.FileSet.Position(pos), nil
+}